@@ -1,5 +1,11 @@
 package geminicli
 
+import (
+	"context"
+	"fmt"
+	"log/slog"
+)
+
 // LoggerAdapter adapts the main package logger to the geminicli Logger interface
 type LoggerAdapter struct {
 	debugWith func(msg string, keysAndValues ...interface{})
@@ -46,3 +52,66 @@ func (a *LoggerAdapter) ErrorWith(msg string, keysAndValues ...interface{}) {
 		a.errorWith(msg, keysAndValues...)
 	}
 }
+
+// slogMissingValue is recorded for a trailing key that has no value
+const slogMissingValue = "(MISSING)"
+
+// SlogAdapter adapts a log/slog logger to the geminicli Logger interface
+type SlogAdapter struct {
+	logger *slog.Logger
+}
+
+// NewSlogAdapter creates a Logger that writes to logger, or to slog.Default()
+// when logger is nil
+func NewSlogAdapter(logger *slog.Logger) Logger {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &SlogAdapter{logger: logger}
+}
+
+func (a *SlogAdapter) DebugWith(msg string, keysAndValues ...interface{}) {
+	a.log(slog.LevelDebug, msg, keysAndValues)
+}
+
+func (a *SlogAdapter) InfoWith(msg string, keysAndValues ...interface{}) {
+	a.log(slog.LevelInfo, msg, keysAndValues)
+}
+
+func (a *SlogAdapter) WarnWith(msg string, keysAndValues ...interface{}) {
+	a.log(slog.LevelWarn, msg, keysAndValues)
+}
+
+func (a *SlogAdapter) ErrorWith(msg string, keysAndValues ...interface{}) {
+	a.log(slog.LevelError, msg, keysAndValues)
+}
+
+// log emits a record at level, skipping attribute conversion when the level
+// is disabled
+func (a *SlogAdapter) log(level slog.Level, msg string, keysAndValues []interface{}) {
+	ctx := context.Background()
+	if !a.logger.Enabled(ctx, level) {
+		return
+	}
+	a.logger.LogAttrs(ctx, level, msg, slogAttrs(keysAndValues)...)
+}
+
+// slogAttrs converts alternating keys and values into slog attributes. Keys
+// that are not strings are formatted with fmt.Sprint, and a trailing key
+// without a value gets slogMissingValue.
+func slogAttrs(keysAndValues []interface{}) []slog.Attr {
+	attrs := make([]slog.Attr, 0, (len(keysAndValues)+1)/2)
+	for i := 0; i < len(keysAndValues); i += 2 {
+		key, ok := keysAndValues[i].(string)
+		if !ok {
+			key = fmt.Sprint(keysAndValues[i])
+		}
+
+		var value interface{} = slogMissingValue
+		if i+1 < len(keysAndValues) {
+			value = keysAndValues[i+1]
+		}
+		attrs = append(attrs, slog.Any(key, value))
+	}
+	return attrs
+}
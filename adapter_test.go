@@ -0,0 +1,72 @@
+package geminicli
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"testing"
+)
+
+// TestSlogAdapter tests forwarding log calls to log/slog
+func TestSlogAdapter(t *testing.T) {
+	tests := []struct {
+		name          string
+		log           func(Logger)
+		expectedLevel string
+		expectedAttrs map[string]interface{}
+		description   string
+	}{
+		{
+			name:          "Debug",
+			log:           func(l Logger) { l.DebugWith("msg", "command", "gemini", "attempt", 2) },
+			expectedLevel: "DEBUG",
+			expectedAttrs: map[string]interface{}{"command": "gemini", "attempt": float64(2)},
+			description:   "Key/value pairs should become attributes",
+		},
+		{
+			name:          "Info",
+			log:           func(l Logger) { l.InfoWith("msg") },
+			expectedLevel: "INFO",
+			expectedAttrs: map[string]interface{}{},
+			description:   "Messages without attributes should be logged",
+		},
+		{
+			name:          "Warn",
+			log:           func(l Logger) { l.WarnWith("msg", "dangling") },
+			expectedLevel: "WARN",
+			expectedAttrs: map[string]interface{}{"dangling": slogMissingValue},
+			description:   "An odd trailing key should get a placeholder value",
+		},
+		{
+			name:          "Error",
+			log:           func(l Logger) { l.ErrorWith("msg", 42, "answer") },
+			expectedLevel: "ERROR",
+			expectedAttrs: map[string]interface{}{"42": "answer"},
+			description:   "Non-string keys should be formatted",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			handler := slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})
+			tt.log(NewSlogAdapter(slog.New(handler)))
+
+			var record map[string]interface{}
+			if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+				t.Fatalf("Failed to decode log record %q: %v", buf.String(), err)
+			}
+			if record["level"] != tt.expectedLevel {
+				t.Errorf("%s: expected level %s, got %v", tt.description, tt.expectedLevel, record["level"])
+			}
+			if record["msg"] != "msg" {
+				t.Errorf("Expected message 'msg', got %v", record["msg"])
+			}
+			for key, expected := range tt.expectedAttrs {
+				if record[key] != expected {
+					t.Errorf("%s: expected %s=%v, got %v", tt.description, key, expected, record[key])
+				}
+			}
+		})
+	}
+}
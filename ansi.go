@@ -0,0 +1,12 @@
+package geminicli
+
+import "regexp"
+
+// ansiPattern matches CSI sequences such as colors and cursor movement, OSC
+// sequences such as hyperlinks and window titles, and two-byte escapes
+var ansiPattern = regexp.MustCompile(`\x1b\[[0-?]*[ -/]*[@-~]|\x1b\][^\x07\x1b]*(?:\x07|\x1b\\)|\x1b[@-Z\\-_]`)
+
+// StripANSI removes ANSI escape sequences from s
+func StripANSI(s string) string {
+	return ansiPattern.ReplaceAllString(s, "")
+}
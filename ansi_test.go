@@ -0,0 +1,58 @@
+package geminicli
+
+import (
+	"context"
+	"testing"
+)
+
+// TestStripANSI tests removing terminal escape sequences
+func TestStripANSI(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"Plain", "Hello, world", "Hello, world"},
+		{"Color", "\x1b[31mError\x1b[0m: failed", "Error: failed"},
+		{"BoldColor", "\x1b[1;32mok\x1b[m", "ok"},
+		{"TrueColor", "\x1b[38;2;255;128;0morange\x1b[39m", "orange"},
+		{"CursorMovement", "\x1b[2K\x1b[1Gprogress", "progress"},
+		{"PrivateMode", "\x1b[?25lhidden cursor\x1b[?25h", "hidden cursor"},
+		{"Hyperlink", "\x1b]8;;https://example.com\x07link\x1b]8;;\x07", "link"},
+		{"WindowTitleST", "\x1b]0;gemini\x1b\\text", "text"},
+		{"TwoByteEscape", "\x1bMline", "line"},
+		{"Multiline", "\x1b[33mone\x1b[0m\n\x1b[33mtwo\x1b[0m", "one\ntwo"},
+		{"BracketsKept", "array[0m] stays", "array[0m] stays"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := StripANSI(tt.input); got != tt.expected {
+				t.Errorf("StripANSI(%q) = %q, expected %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestStripANSIConfig tests that StripANSI cleans responses only when enabled
+func TestStripANSIConfig(t *testing.T) {
+	runner := &fakeRunner{run: func(ctx context.Context, inv Invocation) ([]byte, []byte, error) {
+		return []byte("\x1b[32mGo is a programming language.\x1b[0m\n"), nil, nil
+	}}
+
+	result, err := NewClientWithConfig(Config{StripANSI: true, Runner: runner}).Execute("What is Go?")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result != "Go is a programming language." {
+		t.Errorf("Expected escape codes to be removed, got %q", result)
+	}
+
+	result, err = NewClientWithConfig(Config{Runner: runner}).Execute("What is Go?")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result != "\x1b[32mGo is a programming language.\x1b[0m" {
+		t.Errorf("Expected output to be unchanged by default, got %q", result)
+	}
+}
@@ -0,0 +1,62 @@
+package geminicli
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// maxDuration is the largest representable time.Duration
+const maxDuration = time.Duration(1<<63 - 1)
+
+// BackoffPolicy decides how long to wait before a retry. attempt is the
+// 1-based number of the retry about to be made.
+type BackoffPolicy interface {
+	NextDelay(attempt int) time.Duration
+}
+
+// ExponentialBackoff doubles the delay after every attempt, starting at Base
+// and capped at Max. With Jitter set, the actual delay is drawn uniformly
+// from [0, delay] ("full jitter") so that many clients failing together do
+// not retry in lockstep.
+type ExponentialBackoff struct {
+	Base   time.Duration // Delay before the first retry
+	Max    time.Duration // Upper bound for a single delay (0 = unbounded)
+	Jitter bool          // Randomize each delay between zero and its computed value
+}
+
+// NextDelay implements BackoffPolicy
+func (b ExponentialBackoff) NextDelay(attempt int) time.Duration {
+	if b.Base <= 0 {
+		return 0
+	}
+	if attempt < 1 {
+		attempt = 1
+	}
+
+	delay := b.Base
+	for i := 1; i < attempt; i++ {
+		// Stop doubling at the cap, or before the duration would overflow
+		if (b.Max > 0 && delay >= b.Max) || delay > maxDuration/2 {
+			break
+		}
+		delay *= 2
+	}
+	if b.Max > 0 && delay > b.Max {
+		delay = b.Max
+	}
+
+	if b.Jitter {
+		return time.Duration(rand.Int64N(int64(delay) + 1))
+	}
+	return delay
+}
+
+// ConstantBackoff waits the same Delay before every retry
+type ConstantBackoff struct {
+	Delay time.Duration
+}
+
+// NextDelay implements BackoffPolicy
+func (b ConstantBackoff) NextDelay(attempt int) time.Duration {
+	return b.Delay
+}
@@ -0,0 +1,89 @@
+package geminicli
+
+import (
+	"testing"
+	"time"
+)
+
+// TestExponentialBackoff tests exponential delays with an optional cap
+func TestExponentialBackoff(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   ExponentialBackoff
+		expected []time.Duration
+	}{
+		{
+			name:     "Uncapped",
+			policy:   ExponentialBackoff{Base: 100 * time.Millisecond},
+			expected: []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond},
+		},
+		{
+			name:     "Capped",
+			policy:   ExponentialBackoff{Base: 100 * time.Millisecond, Max: 300 * time.Millisecond},
+			expected: []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 300 * time.Millisecond, 300 * time.Millisecond},
+		},
+		{
+			name:     "ZeroBase",
+			policy:   ExponentialBackoff{},
+			expected: []time.Duration{0, 0, 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i, want := range tt.expected {
+				if got := tt.policy.NextDelay(i + 1); got != want {
+					t.Errorf("Attempt %d: expected %v, got %v", i+1, want, got)
+				}
+			}
+		})
+	}
+
+	t.Run("NoOverflow", func(t *testing.T) {
+		policy := ExponentialBackoff{Base: time.Second}
+		if got := policy.NextDelay(200); got <= 0 {
+			t.Errorf("Expected a positive delay for large attempts, got %v", got)
+		}
+	})
+}
+
+// TestExponentialBackoffJitter tests that full jitter stays within bounds and varies
+func TestExponentialBackoffJitter(t *testing.T) {
+	policy := ExponentialBackoff{Base: time.Second, Max: 4 * time.Second, Jitter: true}
+
+	seen := make(map[time.Duration]bool)
+	for i := 0; i < 100; i++ {
+		got := policy.NextDelay(3)
+		if got < 0 || got > 4*time.Second {
+			t.Fatalf("Expected delay within [0, 4s], got %v", got)
+		}
+		seen[got] = true
+	}
+	if len(seen) < 2 {
+		t.Error("Expected jittered delays to vary")
+	}
+}
+
+// TestConstantBackoff tests fixed delays between retries
+func TestConstantBackoff(t *testing.T) {
+	policy := ConstantBackoff{Delay: 250 * time.Millisecond}
+	for attempt := 1; attempt <= 5; attempt++ {
+		if got := policy.NextDelay(attempt); got != 250*time.Millisecond {
+			t.Errorf("Attempt %d: expected 250ms, got %v", attempt, got)
+		}
+	}
+}
+
+// TestClientBackoffPolicy tests that the retry loop uses the configured policy
+func TestClientBackoffPolicy(t *testing.T) {
+	client := NewClientWithConfig(Config{
+		RetryBackoff: time.Second,
+		Backoff:      ConstantBackoff{Delay: 5 * time.Millisecond},
+	})
+
+	for attempt := 1; attempt <= 3; attempt++ {
+		if got := client.retryDelay(attempt); got != 5*time.Millisecond {
+			t.Errorf("Attempt %d: expected configured policy delay 5ms, got %v", attempt, got)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package geminicli
+
+import (
+	"runtime"
+	"sync"
+)
+
+// ExecuteBatch executes each prompt sequentially with the client's
+// configuration. The returned slices are aligned with prompts: errs[i] is
+// non-nil if prompts[i] failed, in which case results[i] is the zero Result.
+// A failing prompt, including an empty one, does not abort the batch.
+func (c *Client) ExecuteBatch(prompts []string) ([]Result, []error) {
+	results := make([]Result, len(prompts))
+	errs := make([]error, len(prompts))
+
+	for i, prompt := range prompts {
+		results[i], errs[i] = c.executeBatchItem(prompt)
+	}
+
+	return results, errs
+}
+
+// executeBatchItem executes a single batch prompt and dereferences the result
+func (c *Client) executeBatchItem(prompt string) (Result, error) {
+	result, err := c.ExecuteResult(prompt)
+	if err != nil {
+		c.logger.WarnWith("Batch prompt failed", "error", err)
+		return Result{}, err
+	}
+	return *result, nil
+}
+
+// ExecuteBatchConcurrent executes prompts using a fixed pool of concurrency
+// workers sharing this client. Results and errors are aligned with prompts by
+// index regardless of completion order. A concurrency of zero or less uses
+// runtime.NumCPU().
+func (c *Client) ExecuteBatchConcurrent(prompts []string, concurrency int) ([]Result, []error) {
+	results := make([]Result, len(prompts))
+	errs := make([]error, len(prompts))
+
+	if concurrency <= 0 {
+		concurrency = runtime.NumCPU()
+	}
+	if concurrency > len(prompts) {
+		concurrency = len(prompts)
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Each index is written by exactly one worker, so no locking is needed
+			for i := range indexes {
+				results[i], errs[i] = c.executeBatchItem(prompts[i])
+			}
+		}()
+	}
+
+	for i := range prompts {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	return results, errs
+}
@@ -0,0 +1,95 @@
+package geminicli
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// TestExecuteBatch tests sequential execution of multiple prompts
+func TestExecuteBatch(t *testing.T) {
+	installFakeGemini(t, `for last; do :; done; echo "answer: $last"`)
+
+	prompts := []string{"first", "", "third"}
+	results, errs := NewClient().ExecuteBatch(prompts)
+
+	if len(results) != len(prompts) || len(errs) != len(prompts) {
+		t.Fatalf("Expected %d results and errors, got %d and %d", len(prompts), len(results), len(errs))
+	}
+
+	tests := []struct {
+		index        int
+		expectedText string
+		expectError  bool
+	}{
+		{index: 0, expectedText: "answer: first", expectError: false},
+		{index: 1, expectedText: "", expectError: true},
+		{index: 2, expectedText: "answer: third", expectError: false},
+	}
+
+	for _, tt := range tests {
+		if tt.expectError {
+			if errs[tt.index] == nil || !strings.Contains(errs[tt.index].Error(), ErrEmptyPrompt) {
+				t.Errorf("Expected empty prompt error at index %d, got: %v", tt.index, errs[tt.index])
+			}
+			continue
+		}
+		if errs[tt.index] != nil {
+			t.Errorf("Unexpected error at index %d: %v", tt.index, errs[tt.index])
+		}
+		if results[tt.index].Text != tt.expectedText {
+			t.Errorf("Expected '%s' at index %d, got '%s'", tt.expectedText, tt.index, results[tt.index].Text)
+		}
+	}
+}
+
+// TestExecuteBatchConcurrent tests bounded parallel execution of prompts
+func TestExecuteBatchConcurrent(t *testing.T) {
+	installFakeGemini(t, `for last; do :; done; echo "answer: $last"`)
+
+	tests := []struct {
+		name        string
+		concurrency int
+	}{
+		{name: "DefaultConcurrency", concurrency: 0},
+		{name: "SingleWorker", concurrency: 1},
+		{name: "MoreWorkersThanPrompts", concurrency: 50},
+	}
+
+	prompts := make([]string, 20)
+	for i := range prompts {
+		prompts[i] = "prompt " + strconv.Itoa(i)
+	}
+	prompts[7] = ""
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, errs := NewClient().ExecuteBatchConcurrent(prompts, tt.concurrency)
+
+			if len(results) != len(prompts) || len(errs) != len(prompts) {
+				t.Fatalf("Expected %d results and errors, got %d and %d", len(prompts), len(results), len(errs))
+			}
+			for i, prompt := range prompts {
+				if prompt == "" {
+					if errs[i] == nil {
+						t.Errorf("Expected error for empty prompt at index %d", i)
+					}
+					continue
+				}
+				if errs[i] != nil {
+					t.Errorf("Unexpected error at index %d: %v", i, errs[i])
+				}
+				if expected := "answer: " + prompt; results[i].Text != expected {
+					t.Errorf("Expected '%s' at index %d, got '%s'", expected, i, results[i].Text)
+				}
+			}
+		})
+	}
+
+	t.Run("EmptyInput", func(t *testing.T) {
+		results, errs := NewClient().ExecuteBatchConcurrent(nil, 4)
+		if len(results) != 0 || len(errs) != 0 {
+			t.Errorf("Expected empty results, got %d results and %d errors", len(results), len(errs))
+		}
+	})
+}
@@ -0,0 +1,148 @@
+package geminicli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// CircuitBreakerConfig configures the optional circuit breaker. A zero
+// Threshold disables it.
+type CircuitBreakerConfig struct {
+	Threshold int           // Consecutive failures that open the circuit
+	Cooldown  time.Duration // How long the circuit stays open before a trial request
+}
+
+// CircuitState is the state of a client's circuit breaker
+type CircuitState int
+
+// Circuit breaker states
+const (
+	CircuitClosed   CircuitState = iota // Requests flow normally
+	CircuitOpen                         // Requests fail fast with ErrCircuitOpen
+	CircuitHalfOpen                     // A single trial request is allowed through
+)
+
+// String returns the state name
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return fmt.Sprintf("CircuitState(%d)", int(s))
+}
+
+// circuitBreaker fails fast after repeated failures. After the cooldown one
+// trial request is let through; its outcome closes or re-opens the circuit.
+// A nil *circuitBreaker is disabled and allows everything.
+type circuitBreaker struct {
+	config CircuitBreakerConfig
+	logger Logger
+	clock  Clock
+
+	mu       sync.Mutex
+	state    CircuitState
+	failures int       // Consecutive failures while closed
+	openedAt time.Time // When the circuit last opened
+	trial    bool      // Whether the half-open trial request is in flight
+}
+
+// newCircuitBreaker returns a breaker, or nil when config disables it
+func newCircuitBreaker(config CircuitBreakerConfig, logger Logger, clock Clock) *circuitBreaker {
+	if config.Threshold <= 0 {
+		return nil
+	}
+	return &circuitBreaker{config: config, logger: logger, clock: clock}
+}
+
+// allow reports whether a request may proceed, returning ErrCircuitOpen if not
+func (b *circuitBreaker) allow() error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == CircuitOpen {
+		remaining := b.config.Cooldown - b.clock.Now().Sub(b.openedAt)
+		if remaining > 0 {
+			return fmt.Errorf("%w: retry in %v", ErrCircuitOpen, remaining.Round(time.Millisecond))
+		}
+		b.state = CircuitHalfOpen
+		b.logger.InfoWith("Circuit breaker half-open, allowing trial request")
+	}
+
+	if b.state == CircuitHalfOpen {
+		if b.trial {
+			return fmt.Errorf("%w: trial request in progress", ErrCircuitOpen)
+		}
+		b.trial = true
+	}
+	return nil
+}
+
+// record updates the breaker with the outcome of an allowed request
+func (b *circuitBreaker) record(err error) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	wasTrial := b.trial
+	b.trial = false
+
+	switch {
+	case err == nil:
+		if b.state != CircuitClosed {
+			b.logger.InfoWith("Circuit breaker closed")
+		}
+		b.state = CircuitClosed
+		b.failures = 0
+	case errors.Is(err, context.Canceled):
+		// The caller gave up; this says nothing about gemini's health
+	case wasTrial, errors.Is(err, ErrAuthentication):
+		// Retrying won't fix credentials, and a failed trial means gemini
+		// is still unhealthy
+		b.open(err)
+	default:
+		b.failures++
+		if b.failures >= b.config.Threshold {
+			b.open(err)
+		}
+	}
+}
+
+// open moves the breaker to the open state; b.mu must be held
+func (b *circuitBreaker) open(err error) {
+	b.state = CircuitOpen
+	b.openedAt = b.clock.Now()
+	b.failures = 0
+	b.logger.WarnWith("Circuit breaker opened", "cooldown", b.config.Cooldown, "error", err)
+}
+
+// currentState returns the state as seen by the next request
+func (b *circuitBreaker) currentState() CircuitState {
+	if b == nil {
+		return CircuitClosed
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == CircuitOpen && b.clock.Now().Sub(b.openedAt) >= b.config.Cooldown {
+		return CircuitHalfOpen
+	}
+	return b.state
+}
+
+// CircuitState returns the current state of the client's circuit breaker;
+// it is always CircuitClosed when no breaker is configured
+func (c *Client) CircuitState() CircuitState {
+	return c.breaker.currentState()
+}
@@ -0,0 +1,143 @@
+package geminicli
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestCircuitBreaker tests that the breaker opens, fails fast and recovers
+func TestCircuitBreaker(t *testing.T) {
+	tests := []struct {
+		name          string
+		stderr        string
+		failures      int
+		expectedState CircuitState
+		description   string
+	}{
+		{
+			name:          "BelowThreshold",
+			failures:      2,
+			expectedState: CircuitClosed,
+			description:   "Failures below the threshold should keep the circuit closed",
+		},
+		{
+			name:          "AtThreshold",
+			failures:      3,
+			expectedState: CircuitOpen,
+			description:   "Reaching the threshold should open the circuit",
+		},
+		{
+			name:          "AuthFailure",
+			stderr:        "Error: invalid API key",
+			failures:      1,
+			expectedState: CircuitOpen,
+			description:   "An authentication failure should open the circuit immediately",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := &fakeRunner{run: func(ctx context.Context, inv Invocation) ([]byte, []byte, error) {
+				return nil, []byte(tt.stderr), errors.New("exit status 1")
+			}}
+			client := NewClientWithConfig(Config{
+				Runner:         runner,
+				CircuitBreaker: CircuitBreakerConfig{Threshold: 3, Cooldown: time.Hour},
+			})
+
+			for i := 0; i < tt.failures; i++ {
+				if _, err := client.Execute("hello"); err == nil || errors.Is(err, ErrCircuitOpen) {
+					t.Fatalf("%s: attempt %d should reach gemini and fail, got %v", tt.description, i+1, err)
+				}
+			}
+
+			if state := client.CircuitState(); state != tt.expectedState {
+				t.Errorf("%s: expected state %v, got %v", tt.description, tt.expectedState, state)
+			}
+
+			_, err := client.Execute("hello")
+			if open := errors.Is(err, ErrCircuitOpen); open != (tt.expectedState == CircuitOpen) {
+				t.Errorf("%s: expected fail fast %v, got %v", tt.description, tt.expectedState == CircuitOpen, err)
+			}
+			calls := len(runner.invocations())
+			if tt.expectedState == CircuitOpen && calls != tt.failures {
+				t.Errorf("%s: expected %d gemini runs, got %d", tt.description, tt.failures, calls)
+			}
+		})
+	}
+}
+
+// TestCircuitBreakerHalfOpen tests the trial request after the cooldown
+func TestCircuitBreakerHalfOpen(t *testing.T) {
+	tests := []struct {
+		name          string
+		trialErr      error
+		expectedState CircuitState
+		description   string
+	}{
+		{
+			name:          "TrialSucceeds",
+			expectedState: CircuitClosed,
+			description:   "A successful trial should close the circuit",
+		},
+		{
+			name:          "TrialFails",
+			trialErr:      errors.New("exit status 1"),
+			expectedState: CircuitOpen,
+			description:   "A failed trial should re-open the circuit",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runErr := errors.New("exit status 1")
+			runner := &fakeRunner{run: func(ctx context.Context, inv Invocation) ([]byte, []byte, error) {
+				return []byte("fake response"), nil, runErr
+			}}
+			clock := newFakeClock()
+			client := NewClientWithConfig(Config{
+				Clock:          clock,
+				Runner:         runner,
+				CircuitBreaker: CircuitBreakerConfig{Threshold: 1, Cooldown: 20 * time.Millisecond},
+			})
+
+			client.Execute("hello")
+			if state := client.CircuitState(); state != CircuitOpen {
+				t.Fatalf("%s: expected open circuit, got %v", tt.description, state)
+			}
+
+			clock.Advance(20 * time.Millisecond)
+			if state := client.CircuitState(); state != CircuitHalfOpen {
+				t.Fatalf("%s: expected half-open circuit after cooldown, got %v", tt.description, state)
+			}
+
+			runErr = tt.trialErr
+			client.Execute("hello")
+			if state := client.CircuitState(); state != tt.expectedState {
+				t.Errorf("%s: expected state %v, got %v", tt.description, tt.expectedState, state)
+			}
+			if calls := len(runner.invocations()); calls != 2 {
+				t.Errorf("%s: expected 2 gemini runs, got %d", tt.description, calls)
+			}
+		})
+	}
+}
+
+// TestCircuitBreakerDisabled tests that the zero config never trips
+func TestCircuitBreakerDisabled(t *testing.T) {
+	runner := &fakeRunner{run: func(ctx context.Context, inv Invocation) ([]byte, []byte, error) {
+		return nil, []byte("Error: invalid API key"), errors.New("exit status 1")
+	}}
+	client := NewClientWithConfig(Config{Runner: runner})
+
+	for i := 0; i < 5; i++ {
+		if _, err := client.Execute("hello"); errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("Disabled breaker should never fail fast, got %v", err)
+		}
+	}
+	if state := client.CircuitState(); state != CircuitClosed {
+		t.Errorf("Disabled breaker should report closed, got %v", state)
+	}
+}
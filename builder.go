@@ -0,0 +1,190 @@
+package geminicli
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// CommandBuilder assembles a single gemini call step by step as an
+// alternative to filling in a Config:
+//
+//	result, err := NewCommandBuilder().
+//		Model("gemini-2.5-pro").
+//		Prompt("Summarize ./notes.txt").
+//		WorkingDir("/project").
+//		Timeout(60 * time.Second).
+//		ExtraArg("--yolo").
+//		Execute()
+//
+// Each step checks its argument and any conflict with earlier steps. The
+// first problem is kept, later steps are ignored, and it is returned by
+// Err and by every terminal method, matching ErrInvalidConfig.
+type CommandBuilder struct {
+	config Config
+	prompt string
+	err    error
+}
+
+// NewCommandBuilder returns a builder starting from the default settings
+func NewCommandBuilder() *CommandBuilder {
+	return &CommandBuilder{}
+}
+
+// Model sets the model to request. It conflicts with OmitModelFlag.
+func (b *CommandBuilder) Model(model string) *CommandBuilder {
+	switch {
+	case strings.TrimSpace(model) == "":
+		b.fail("Model must not be blank")
+	case b.config.OmitModelFlag:
+		b.fail("Model %q conflicts with OmitModelFlag", model)
+	default:
+		b.set(func(config *Config) { config.Model = model })
+	}
+	return b
+}
+
+// OmitModelFlag leaves the model choice to gemini's own settings. It
+// conflicts with Model.
+func (b *CommandBuilder) OmitModelFlag() *CommandBuilder {
+	if b.config.Model != "" {
+		b.fail("OmitModelFlag conflicts with Model %q", b.config.Model)
+		return b
+	}
+	b.set(func(config *Config) { config.OmitModelFlag = true })
+	return b
+}
+
+// Prompt sets the prompt to send
+func (b *CommandBuilder) Prompt(prompt string) *CommandBuilder {
+	if prompt == "" {
+		b.fail("Prompt must not be empty")
+		return b
+	}
+	if b.err == nil {
+		b.prompt = prompt
+	}
+	return b
+}
+
+// WorkingDir sets the directory gemini runs in. Whether it exists is
+// checked when the client is built.
+func (b *CommandBuilder) WorkingDir(dir string) *CommandBuilder {
+	if strings.TrimSpace(dir) == "" {
+		b.fail("WorkingDir must not be blank")
+		return b
+	}
+	b.set(func(config *Config) { config.WorkingDirectory = dir })
+	return b
+}
+
+// Timeout sets the timeout for a single attempt
+func (b *CommandBuilder) Timeout(timeout time.Duration) *CommandBuilder {
+	if timeout <= 0 {
+		b.fail("Timeout must be positive, got %v", timeout)
+		return b
+	}
+	b.set(func(config *Config) { config.Timeout = timeout })
+	return b
+}
+
+// ExtraArg appends gemini flags that are passed through verbatim. The model
+// and prompt flags are rejected; use Model and Prompt instead.
+func (b *CommandBuilder) ExtraArg(args ...string) *CommandBuilder {
+	for _, arg := range args {
+		switch {
+		case strings.TrimSpace(arg) == "":
+			b.fail("ExtraArg must not be blank")
+			return b
+		case slices.Contains([]string{GeminiModelFlag, "--model"}, arg):
+			b.fail("ExtraArg %s conflicts with Model", arg)
+			return b
+		case slices.Contains([]string{GeminiPromptFlag, "--prompt"}, arg):
+			b.fail("ExtraArg %s conflicts with Prompt", arg)
+			return b
+		}
+	}
+	b.set(func(config *Config) { config.ExtraArgs = append(config.ExtraArgs, args...) })
+	return b
+}
+
+// Err returns the first problem found so far, or nil
+func (b *CommandBuilder) Err() error {
+	return b.err
+}
+
+// Config returns the accumulated settings, for callers that want to adjust
+// fields the builder does not cover before creating a client themselves
+func (b *CommandBuilder) Config() (Config, error) {
+	if b.err != nil {
+		return Config{}, b.err
+	}
+	config := b.config
+	config.ExtraArgs = slices.Clone(b.config.ExtraArgs)
+	return config, nil
+}
+
+// Client creates a client from the accumulated settings, checking them as
+// NewClientWithConfigValidated does
+func (b *CommandBuilder) Client() (*Client, error) {
+	config, err := b.Config()
+	if err != nil {
+		return nil, err
+	}
+	return NewClientWithConfigValidated(config)
+}
+
+// Args returns the argv Execute would run, without running gemini
+func (b *CommandBuilder) Args() ([]string, error) {
+	client, prompt, err := b.build()
+	if err != nil {
+		return nil, err
+	}
+	plan, err := client.Explain(prompt)
+	if err != nil {
+		return nil, err
+	}
+	return plan.Command, nil
+}
+
+// Execute runs the command and returns its result, as ExecuteResult does
+func (b *CommandBuilder) Execute() (*Result, error) {
+	return b.ExecuteContext(context.Background())
+}
+
+// ExecuteContext is Execute bounded by ctx
+func (b *CommandBuilder) ExecuteContext(ctx context.Context) (*Result, error) {
+	client, prompt, err := b.build()
+	if err != nil {
+		return nil, err
+	}
+	return client.executeContext(ctx, prompt, client.defaultExecOptions(prompt))
+}
+
+// build creates the client and checks that a prompt was given
+func (b *CommandBuilder) build() (*Client, string, error) {
+	client, err := b.Client()
+	if err != nil {
+		return nil, "", err
+	}
+	if b.prompt == "" {
+		return nil, "", fmt.Errorf("%w: Prompt was not set", ErrInvalidConfig)
+	}
+	return client, b.prompt, nil
+}
+
+// set applies a step unless an earlier step failed
+func (b *CommandBuilder) set(apply func(config *Config)) {
+	if b.err == nil {
+		apply(&b.config)
+	}
+}
+
+// fail records the first problem found
+func (b *CommandBuilder) fail(format string, args ...any) {
+	if b.err == nil {
+		b.err = fmt.Errorf("%w: %s", ErrInvalidConfig, fmt.Sprintf(format, args...))
+	}
+}
@@ -0,0 +1,117 @@
+package geminicli
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestCommandBuilder tests building commands step by step
+func TestCommandBuilder(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name        string
+		build       func() *CommandBuilder
+		expectedErr string
+		expected    []string
+		description string
+	}{
+		{
+			name: "FullCommand",
+			build: func() *CommandBuilder {
+				return NewCommandBuilder().Model("gemini-2.5-pro").Prompt("hello").WorkingDir(dir).Timeout(time.Minute).ExtraArg("--yolo")
+			},
+			expected:    []string{GeminiCommand, "-m", "gemini-2.5-pro", "--yolo", "-p", "hello"},
+			description: "All steps should be reflected in the argv",
+		},
+		{
+			name: "OmitModelFlag",
+			build: func() *CommandBuilder {
+				return NewCommandBuilder().OmitModelFlag().Prompt("hello")
+			},
+			expected:    []string{GeminiCommand, "-p", "hello"},
+			description: "OmitModelFlag should leave out the model flag",
+		},
+		{
+			name: "ModelAfterOmitModelFlag",
+			build: func() *CommandBuilder {
+				return NewCommandBuilder().OmitModelFlag().Model("gemini-2.5-pro").Prompt("hello")
+			},
+			expectedErr: "conflicts with OmitModelFlag",
+			description: "Model should conflict with an earlier OmitModelFlag",
+		},
+		{
+			name: "OmitModelFlagAfterModel",
+			build: func() *CommandBuilder {
+				return NewCommandBuilder().Model("gemini-2.5-pro").OmitModelFlag().Prompt("hello")
+			},
+			expectedErr: "OmitModelFlag conflicts with Model",
+			description: "OmitModelFlag should conflict with an earlier Model",
+		},
+		{
+			name: "FirstErrorKept",
+			build: func() *CommandBuilder {
+				return NewCommandBuilder().Timeout(0).Model(" ").Prompt("hello")
+			},
+			expectedErr: "Timeout must be positive",
+			description: "Only the first problem should be reported",
+		},
+		{
+			name: "PromptFlagInExtraArgs",
+			build: func() *CommandBuilder {
+				return NewCommandBuilder().ExtraArg("--yolo", "-p").Prompt("hello")
+			},
+			expectedErr: "ExtraArg -p conflicts with Prompt",
+			description: "The prompt flag should not be passed as an extra argument",
+		},
+		{
+			name: "MissingPrompt",
+			build: func() *CommandBuilder {
+				return NewCommandBuilder().Model("gemini-2.5-pro")
+			},
+			expectedErr: "Prompt was not set",
+			description: "A prompt should be required",
+		},
+		{
+			name: "MissingWorkingDir",
+			build: func() *CommandBuilder {
+				return NewCommandBuilder().WorkingDir(dir + "/missing").Prompt("hello")
+			},
+			expectedErr: "WorkingDirectory",
+			description: "The working directory should be checked when the client is built",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, err := tt.build().Args()
+
+			if tt.expectedErr != "" {
+				if !errors.Is(err, ErrInvalidConfig) || !strings.Contains(err.Error(), tt.expectedErr) {
+					t.Errorf("%s: expected error containing '%s', got %v", tt.description, tt.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("%s: unexpected error: %v", tt.description, err)
+			}
+			if strings.Join(args, " ") != strings.Join(tt.expected, " ") {
+				t.Errorf("%s: expected %v, got %v", tt.description, tt.expected, args)
+			}
+		})
+	}
+
+	t.Run("Execute", func(t *testing.T) {
+		installFakeGemini(t, `echo "ran in $(pwd)"`)
+
+		result, err := NewCommandBuilder().Prompt("hello").WorkingDir(dir).Execute()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if result.Text != "ran in "+dir {
+			t.Errorf("Expected 'ran in %s', got '%s'", dir, result.Text)
+		}
+	})
+}
@@ -0,0 +1,59 @@
+package geminicli
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ChainPlaceholder marks where ExecuteChain inserts the previous response
+// into a prompt
+const ChainPlaceholder = "{{previous}}"
+
+// Pipe formats earlier responses as context for another prompt. Each
+// response is trimmed and wrapped in numbered delimiters so the model can
+// tell them apart from each other and from the instructions that follow.
+// Empty responses are skipped.
+func Pipe(results ...string) string {
+	var b strings.Builder
+	n := 0
+	for _, result := range results {
+		result = strings.TrimSpace(result)
+		if result == "" {
+			continue
+		}
+		n++
+		if n > 1 {
+			b.WriteString("\n\n")
+		}
+		fmt.Fprintf(&b, "--- Previous response %d ---\n%s\n--- End of previous response %d ---", n, result, n)
+	}
+	return b.String()
+}
+
+// ExecuteChain executes prompts in order, feeding each response into the
+// next prompt: ChainPlaceholder is replaced with the previous response, or,
+// if the prompt has no placeholder, the response is appended with Pipe. The
+// first prompt is sent as is. On failure it returns the responses so far
+// with an error naming the failed step.
+func (c *Client) ExecuteChain(prompts []string) ([]string, error) {
+	responses := make([]string, 0, len(prompts))
+	for i, prompt := range prompts {
+		if i > 0 {
+			prompt = chainPrompt(prompt, responses[i-1])
+		}
+		response, err := c.Execute(prompt)
+		if err != nil {
+			return responses, fmt.Errorf("chain step %d of %d: %w", i+1, len(prompts), err)
+		}
+		responses = append(responses, response)
+	}
+	return responses, nil
+}
+
+// chainPrompt inserts previous into prompt for ExecuteChain
+func chainPrompt(prompt, previous string) string {
+	if strings.Contains(prompt, ChainPlaceholder) {
+		return strings.ReplaceAll(prompt, ChainPlaceholder, previous)
+	}
+	return prompt + "\n\n" + Pipe(previous)
+}
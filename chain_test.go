@@ -0,0 +1,88 @@
+package geminicli
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestPipe tests formatting earlier responses as context
+func TestPipe(t *testing.T) {
+	tests := []struct {
+		name        string
+		results     []string
+		expected    string
+		description string
+	}{
+		{
+			name:        "None",
+			expected:    "",
+			description: "No responses should give an empty string",
+		},
+		{
+			name:        "Single",
+			results:     []string{"  first\n"},
+			expected:    "--- Previous response 1 ---\nfirst\n--- End of previous response 1 ---",
+			description: "A response should be trimmed and delimited",
+		},
+		{
+			name:        "SkipsEmpty",
+			results:     []string{"first", " ", "second"},
+			expected:    "--- Previous response 1 ---\nfirst\n--- End of previous response 1 ---\n\n--- Previous response 2 ---\nsecond\n--- End of previous response 2 ---",
+			description: "Empty responses should be skipped without gaps in numbering",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Pipe(tt.results...); got != tt.expected {
+				t.Errorf("%s: expected %q, got %q", tt.description, tt.expected, got)
+			}
+		})
+	}
+}
+
+// TestExecuteChain tests feeding each response into the next prompt
+func TestExecuteChain(t *testing.T) {
+	runner := &fakeRunner{}
+	runner.run = func(ctx context.Context, inv Invocation) ([]byte, []byte, error) {
+		prompt := inv.Args[len(inv.Args)-1]
+		if strings.Contains(prompt, "fail") {
+			return nil, []byte("boom"), errors.New("exit status 1")
+		}
+		return []byte("answer " + string(rune('A'+len(runner.invocations())-1))), nil, nil
+	}
+	client := NewClientWithConfig(Config{RetryCount: -1, Runner: runner})
+
+	responses, err := client.ExecuteChain([]string{"outline", "expand: {{previous}}", "summarize"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if strings.Join(responses, "|") != "answer A|answer B|answer C" {
+		t.Errorf("Unexpected responses %q", responses)
+	}
+
+	calls := runner.invocations()
+	prompts := make([]string, len(calls))
+	for i, call := range calls {
+		prompts[i] = call.Args[len(call.Args)-1]
+	}
+	if prompts[0] != "outline" {
+		t.Errorf("Expected the first prompt unchanged, got %q", prompts[0])
+	}
+	if prompts[1] != "expand: answer A" {
+		t.Errorf("Expected the placeholder to be replaced, got %q", prompts[1])
+	}
+	if prompts[2] != "summarize\n\n"+Pipe("answer B") {
+		t.Errorf("Expected the previous response to be piped in, got %q", prompts[2])
+	}
+
+	responses, err = client.ExecuteChain([]string{"outline", "fail on {{previous}}", "never sent"})
+	if err == nil || !strings.Contains(err.Error(), "chain step 2 of 3") {
+		t.Errorf("Expected an error naming step 2, got %v", err)
+	}
+	if len(responses) != 1 {
+		t.Errorf("Expected the responses before the failure, got %q", responses)
+	}
+}
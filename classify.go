@@ -0,0 +1,74 @@
+package geminicli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrorKind is the class of a failed gemini command
+type ErrorKind int
+
+const (
+	KindUnknown          ErrorKind = iota // None of the classes below
+	KindAuth                              // Missing or rejected credentials
+	KindRateLimit                         // Quota or rate limit exhausted
+	KindModelNotFound                     // Unknown or misspelled model
+	KindModelUnavailable                  // Model overloaded or temporarily unavailable
+	KindTimeout                           // Timeout, StartupTimeout or TotalDeadline hit
+)
+
+// String returns a lower-case name for the kind
+func (k ErrorKind) String() string {
+	switch k {
+	case KindUnknown:
+		return "unknown"
+	case KindAuth:
+		return "auth"
+	case KindRateLimit:
+		return "rate limit"
+	case KindModelNotFound:
+		return "model not found"
+	case KindModelUnavailable:
+		return "model unavailable"
+	case KindTimeout:
+		return "timeout"
+	}
+	return fmt.Sprintf("ErrorKind(%d)", int(k))
+}
+
+// Classify returns the kind of a failed gemini command from its combined
+// stdout and stderr and the error it failed with, using the built-in
+// keywords. Errors returned by a Client are classified from err alone, so
+// output may be nil for them; for errors captured elsewhere pass the
+// command's output.
+func Classify(output []byte, err error) ErrorKind {
+	client := NewClient()
+	return client.classify(output, err)
+}
+
+// classify returns the kind of a failed command. Kinds already carried by
+// err take precedence over keyword matches in output.
+func (c *Client) classify(output []byte, err error) ErrorKind {
+	switch {
+	case errors.Is(err, ErrAuthentication):
+		return KindAuth
+	case errors.Is(err, ErrModelNotFound):
+		return KindModelNotFound
+	case errors.Is(err, ErrRateLimited):
+		return KindRateLimit
+	case errors.Is(err, ErrModelUnavailable):
+		return KindModelUnavailable
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, ErrStartupTimeout):
+		return KindTimeout
+	case c.detectAuthError(output):
+		return KindAuth
+	case c.detectModelError(output):
+		return KindModelNotFound
+	case c.detectRateLimitError(output):
+		return KindRateLimit
+	case c.detectModelUnavailableError(output):
+		return KindModelUnavailable
+	}
+	return KindUnknown
+}
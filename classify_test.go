@@ -0,0 +1,112 @@
+package geminicli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// TestClassify tests classification of failed commands from their output
+// and error
+func TestClassify(t *testing.T) {
+	exitErr := errors.New("exit status 1")
+
+	tests := []struct {
+		name        string
+		output      string
+		err         error
+		expected    ErrorKind
+		description string
+	}{
+		{
+			name:        "AuthStderr",
+			output:      "[API Error: 400 API key not valid. Please pass a valid API key. (Invalid API key)]",
+			err:         exitErr,
+			expected:    KindAuth,
+			description: "A rejected API key should be an auth failure",
+		},
+		{
+			name:        "RateLimitStderr",
+			output:      `[API Error: {"error":{"code":429,"message":"Quota exceeded for quota metric","status":"RESOURCE_EXHAUSTED"}}]`,
+			err:         exitErr,
+			expected:    KindRateLimit,
+			description: "Quota exhaustion should be a rate limit",
+		},
+		{
+			name:        "ModelNotFoundStderr",
+			output:      "[API Error: models/gemini-9-pro is not found for API version v1beta, or is not supported for generateContent.]",
+			err:         exitErr,
+			expected:    KindModelNotFound,
+			description: "An unknown model should be classified as not found",
+		},
+		{
+			name:        "ModelUnavailableStderr",
+			output:      "[API Error: The model is overloaded. Please try again later.]",
+			err:         exitErr,
+			expected:    KindModelUnavailable,
+			description: "An overloaded model should be unavailable",
+		},
+		{
+			name:        "Timeout",
+			err:         fmt.Errorf("%s after 30s: %w", ErrCommandTimeout, context.DeadlineExceeded),
+			expected:    KindTimeout,
+			description: "A deadline should be a timeout",
+		},
+		{
+			name:        "StartupTimeout",
+			err:         fmt.Errorf("%w: no output within 5s", ErrStartupTimeout),
+			expected:    KindTimeout,
+			description: "A startup timeout should be a timeout",
+		},
+		{
+			name:        "ClientError",
+			err:         fmt.Errorf("%s: %w", ErrCommandFailed, &AuthError{ExitCode: 1}),
+			expected:    KindAuth,
+			description: "Errors returned by a Client should be classified without output",
+		},
+		{
+			name:        "Unknown",
+			output:      "Error: something went wrong",
+			err:         exitErr,
+			expected:    KindUnknown,
+			description: "Unrecognized failures should be unknown",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Classify([]byte(tt.output), tt.err); got != tt.expected {
+				t.Errorf("%s: expected %v, got %v", tt.description, tt.expected, got)
+			}
+		})
+	}
+}
+
+// TestClassifyExecute tests that errors from Execute classify consistently
+// with the command's output
+func TestClassifyExecute(t *testing.T) {
+	tests := []struct {
+		name     string
+		stderr   string
+		expected ErrorKind
+	}{
+		{"Auth", "authentication failed", KindAuth},
+		{"RateLimit", "429 Too Many Requests", KindRateLimit},
+		{"ModelNotFound", "unknown model: gemini-9", KindModelNotFound},
+		{"ModelUnavailable", "model is overloaded", KindModelUnavailable},
+		{"Unknown", "something went wrong", KindUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := &fakeRunner{run: func(ctx context.Context, inv Invocation) ([]byte, []byte, error) {
+				return nil, []byte(tt.stderr), errors.New("exit status 1")
+			}}
+			_, err := NewClientWithConfig(Config{RetryCount: -1, Runner: runner}).Execute("hello")
+			if got := Classify(nil, err); got != tt.expected {
+				t.Errorf("Expected %v for %v, got %v", tt.expected, err, got)
+			}
+		})
+	}
+}
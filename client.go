@@ -1,15 +1,21 @@
 package geminicli
 
 import (
-	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"os/user"
 	"path/filepath"
 	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
 )
 
 // Constants
@@ -18,9 +24,55 @@ const (
 	GeminiCommand    = "gemini"
 	GeminiPromptFlag = "-p"
 	GeminiModelFlag  = "-m"
-	DefaultTimeout   = 30 * time.Second
-	DefaultModel     = "gemini-2.5-flash"
-	MaxRetries       = 3
+
+	// GeminiVersionFlag makes gemini print its version and exit
+	GeminiVersionFlag = "--version"
+
+	// GeminiAllFilesFlag makes gemini include every file in its working
+	// directory as context
+	GeminiAllFilesFlag = "--all-files"
+
+	// GeminiSettingsPathEnv points gemini at a settings file that takes
+	// precedence over the user and workspace settings
+	GeminiSettingsPathEnv = "GEMINI_CLI_SYSTEM_SETTINGS_PATH"
+
+	// GeminiAPIKeyEnv is the variable gemini reads its API key from
+	GeminiAPIKeyEnv = "GEMINI_API_KEY"
+
+	// Sandbox flags run gemini's tools in a container, optionally built
+	// from a specific image
+	GeminiSandboxFlag      = "--sandbox"
+	GeminiSandboxImageFlag = "--sandbox-image"
+
+	// GeminiExtensionsFlag restricts gemini to the named extensions; it is
+	// repeated once per extension
+	GeminiExtensionsFlag = "--extensions"
+
+	// GeminiDebugFlag makes gemini print diagnostic "[DEBUG]" lines
+	GeminiDebugFlag = "--debug"
+
+	// Sampling flags and the temperature range gemini accepts
+	GeminiTemperatureFlag     = "--temperature"
+	GeminiMaxOutputTokensFlag = "--max-output-tokens"
+	MinTemperature            = 0.0
+	MaxTemperature            = 2.0
+
+	// GeminiOutputFormatFlag selects gemini's output format
+	GeminiOutputFormatFlag = "--output-format"
+	OutputFormatText       = "text"
+	OutputFormatJSON       = "json"
+	OutputFormatStreamJSON = "stream-json"
+	DefaultTimeout         = 30 * time.Second
+	DefaultModel           = "gemini-2.5-flash"
+	MaxRetries             = 3
+
+	// DefaultRetryBackoff is the delay before the first retry; it doubles on
+	// every subsequent attempt
+	DefaultRetryBackoff = 500 * time.Millisecond
+
+	// DefaultGracePeriod is how long a timed-out process may take to exit
+	// after SIGTERM before it is killed
+	DefaultGracePeriod = 2 * time.Second
 
 	// Error messages
 	ErrEmptyPrompt     = "prompt cannot be empty"
@@ -31,303 +83,1019 @@ const (
 	ErrParseOutput     = "failed to parse Gemini output"
 	ErrEmptyOutput     = "empty output from Gemini command"
 	ErrAuthFailed      = "authentication error: please check your Gemini API credentials"
+	ErrReadPrompt      = "failed to read prompt"
+	ErrParseVersion    = "failed to parse Gemini version"
+	ErrBeforeExecute   = "before execute hook failed"
+	ErrAfterExecute    = "after execute hook failed"
+	ErrHealthCheck     = "health check failed"
+	ErrWarmup          = "warmup failed"
+	ErrDecodeJSON      = "failed to decode JSON response"
+	ErrWriteOutput     = "failed to write Gemini output"
+	ErrRateLimitWait   = "rate limiter wait failed"
 )
 
-// Client represents a Gemini CLI client
+// Client represents a Gemini CLI client.
+//
+// A Client is safe for concurrent use by multiple goroutines: its
+// configuration is fixed when NewClient returns and every call builds its
+// own command. Per-call overrides such as ExecuteWithModel and
+// ExecuteWithTimeout are passed down with the call and never modify the
+// client. The configured Logger, hooks and Runner must themselves be safe for
+// concurrent use if the client is shared.
 type Client struct {
-	logger           Logger
-	timeout          time.Duration
-	model            string // Model name to use
-	workingDirectory string // Working directory for command execution
+	logger                 Logger
+	timeout                time.Duration
+	model                  string               // Model name to use
+	workingDirectory       string               // Working directory for command execution
+	retryCount             int                  // Number of retries after the first attempt
+	retryBackoff           time.Duration        // Base delay between retries
+	promptViaStdin         bool                 // Send prompts on stdin instead of argv
+	stdinThreshold         int                  // Minimum prompt size in bytes for stdin mode
+	filterPatterns         []string             // Output lines containing any of these are removed
+	authKeywords           []string             // Keywords identifying authentication failures
+	gracePeriod            time.Duration        // Delay between SIGTERM and SIGKILL on timeout (0 = kill immediately)
+	extraArgs              []string             // Additional gemini flags placed before the prompt flag
+	outputFormat           string               // Output format requested from gemini (OutputFormatText or OutputFormatJSON)
+	runner                 CommandRunner        // Executes gemini processes
+	maxPromptLength        int                  // Maximum prompt size in bytes (0 = unlimited)
+	resolvePaths           bool                 // Rewrite relative paths in prompts when workingDirectory is set
+	pathExtensions         []string             // File extensions recognised by bare path matching
+	explicitPathsOnly      bool                 // Only resolve paths starting with ./ or ../
+	pathPattern            *regexp.Regexp       // Compiled path matcher built from pathExtensions
+	minVersion             string               // Minimum gemini version enforced by ValidateAvailable
+	onComplete             CompleteHook         // Called after every execution
+	beforeExecute          BeforeExecuteHook    // Rewrites or rejects prompts before execution
+	afterExecute           AfterExecuteHook     // Rewrites or rejects output after execution
+	env                    map[string]string    // Extra environment variables for the gemini process
+	dryRun                 bool                 // Return the command line instead of running it
+	returnPartialOnTimeout bool                 // Return output captured before a timeout alongside the error
+	binaryPath             string               // Name or path of the gemini executable
+	windowsPaths           bool                 // Recognise backslash separators and drive letters in prompt paths
+	temperature            *float64             // Sampling temperature, nil to use gemini's default
+	maxOutputTokens        *int                 // Response token limit, nil to use gemini's default
+	startupTimeout         time.Duration        // Kill processes that produce no output within this window (0 = disabled)
+	validateFileReferences bool                 // Check @path references exist before running
+	backoff                BackoffPolicy        // Delay between retries
+	circuitBreaker         CircuitBreakerConfig // Circuit breaker settings
+	breaker                *circuitBreaker      // Circuit breaker state, nil when disabled
+	omitModelFlag          bool                 // Leave out the model flag so gemini uses its configured default
+	modelFallback          []string             // Models tried in order when the primary is rate-limited or unavailable
+	promptFlag             string               // Flag that introduces the prompt
+	modelFlag              string               // Flag that selects the model
+	rateLimit              float64              // Requests per second when no limiter is given
+	limiter                Limiter              // Paces gemini invocations, nil for no limit
+	sessionMaxTurns        int                  // Turns a Session keeps (0 = DefaultSessionMaxTurns, negative = all)
+	preserveBlankLines     bool                 // Keep blank lines inside the response when filtering
+	filterRegexps          []*regexp.Regexp     // Additional line filters for dynamic system messages
+	onRetry                RetryHook            // Called before each retry sleep
+	totalDeadline          time.Duration        // Bound on a whole call, including retries and backoff
+	stripPromptEcho        bool                 // Remove the prompt from the start of text output
+	allFiles               bool                 // Pass --all-files so gemini reads the whole working directory
+	sandbox                bool                 // Pass --sandbox to run gemini tools in a container
+	sandboxImage           string               // Container image passed with --sandbox-image
+	stripANSI              bool                 // Remove ANSI escape sequences from responses
+	maxOutputBytes         int                  // Maximum stdout size in bytes (0 = unlimited)
+	retryableErrors        func(error) bool     // Decides which failures are retried; nil uses DefaultRetryableError
+	clock                  Clock                // Time source for timeouts, backoff and durations
+	requestID              string               // Added to every log message as "request_id"
+	logOutputLines         bool                 // Log each non-filtered output line at Info level
+	listModelsArgs         []string             // Arguments that make gemini list its models
+	modelCacheTTL          time.Duration        // How long ListModels results are reused
+	models                 modelCache           // Last ListModels result
+	includePromptInErrors  bool                 // Record the prompt in CommandError.Prompt
+	retryOnEmptyOutput     bool                 // Retry attempts whose filtered output is empty
+	extensions             []string             // Extensions passed with --extensions, sorted
+	logStderr              bool                 // Log each stderr line at Info level
+	idempotencyKey         string               // Key under which results are reused
+	idempotencyWindow      time.Duration        // How long a result is reused for a repeated key
+	debug                  bool                 // Pass --debug and filter its diagnostic lines
+	apiKeyFromFile         bool                 // GEMINI_API_KEY in env was read from APIKeyFile
+	apiKeyErr              error                // Why APIKeyFile could not be read, returned by every execution
+	strictWorkingDir       bool                 // Check the working directory before every execution
+	timeoutMessage         string               // Replaces the default timeout error text
+	retryBudgetConfig      RetryBudgetConfig    // Retry budget settings
+	retryBudget            *retryBudget         // Retry budget state, nil when disabled
+	fallbackDirectory      string               // Directory to run in when neither the current nor the home directory resolves
 }
 
 // Config represents configuration options for the client
 type Config struct {
-	Logger           Logger
-	Timeout          time.Duration
-	Model            string // Model name (e.g., "gemini-2.5-flash", "gemini-2.5-pro")
-	WorkingDirectory string // Working directory for command execution
+	Logger                 Logger
+	Timeout                time.Duration
+	Model                  string               // Model name (e.g., "gemini-2.5-flash", "gemini-2.5-pro")
+	WorkingDirectory       string               // Working directory for command execution
+	RetryCount             int                  // Retries for transient failures (0 = MaxRetries, negative disables retries)
+	RetryBackoff           time.Duration        // Base delay for exponential backoff between retries (default: DefaultRetryBackoff)
+	PromptViaStdin         bool                 // Send prompts on stdin instead of with -p
+	StdinThreshold         int                  // With PromptViaStdin, only prompts longer than this many bytes use stdin (0 = all)
+	FilterPatterns         []string             // Extra output filter patterns, appended to DefaultFilterPatterns()
+	AuthErrorKeywords      []string             // Extra auth error keywords, appended to DefaultAuthErrorKeywords()
+	GracePeriod            time.Duration        // Time between SIGTERM and SIGKILL on timeout (default: DefaultGracePeriod, negative kills immediately)
+	ExtraArgs              []string             // Additional gemini flags, inserted after the model flag and before -p
+	OutputFormat           string               // "text" (default) or "json" for structured output with token counts
+	Runner                 CommandRunner        // Custom command runner, e.g. a fake for tests (default: ExecRunner)
+	MaxPromptLength        int                  // Maximum prompt size in bytes; longer prompts fail with ErrPromptTooLong (0 = unlimited)
+	ResolvePaths           bool                 // Resolve relative paths in prompts against the current directory when WorkingDirectory is set (default: false)
+	PathExtensions         []string             // With ResolvePaths, extensions that mark bare words like "notes.txt" as paths (default: DefaultPathExtensions())
+	ExplicitPathsOnly      bool                 // With ResolvePaths, only resolve paths starting with ./ or ../
+	MinVersion             string               // Minimum gemini version (e.g. "0.1.12") enforced by ValidateAvailable; empty disables the check
+	OnComplete             CompleteHook         // Called once per Execute on every code path, e.g. for metrics
+	BeforeExecute          BeforeExecuteHook    // Inspects or rewrites the prompt before gemini runs; an error aborts the execution
+	AfterExecute           AfterExecuteHook     // Inspects or rewrites the filtered output; an error fails the execution
+	Env                    map[string]string    // Extra environment variables for gemini, overriding inherited ones with the same name
+	DryRun                 bool                 // Return the fully built command line as the result without running gemini
+	ReturnPartialOnTimeout bool                 // On timeout, return the output captured so far together with the timeout error
+	BinaryPath             string               // Name or path of the gemini executable (default: GeminiCommand)
+	Temperature            *float64             // Sampling temperature between MinTemperature and MaxTemperature; nil uses gemini's default
+	MaxOutputTokens        *int                 // Maximum response tokens, must be positive; nil uses gemini's default
+	StartupTimeout         time.Duration        // Kill gemini if it writes no output within this window, failing with ErrStartupTimeout (0 = disabled)
+	ValidateFileReferences bool                 // Fail with ErrFileReferenceNotFound if an @path reference in the prompt does not exist
+	Backoff                BackoffPolicy        // Delay policy between retries (default: ExponentialBackoff starting at RetryBackoff)
+	CircuitBreaker         CircuitBreakerConfig // Fail fast with ErrCircuitOpen after repeated failures (zero Threshold disables)
+	OmitModelFlag          bool                 // Never pass -m for the client model; gemini uses its own configured default
+	ModelFallback          []string             // Models tried in order when the primary model is rate-limited or unavailable
+	PromptFlag             string               // Flag that introduces the prompt (default: GeminiPromptFlag)
+	ModelFlag              string               // Flag that selects the model (default: GeminiModelFlag)
+	RateLimit              float64              // Maximum gemini invocations per second (0 = unlimited)
+	Limiter                Limiter              // Limiter shared with other clients; overrides RateLimit
+	SessionMaxTurns        int                  // Turns a Session keeps as context (0 = DefaultSessionMaxTurns, negative = unlimited)
+	PreserveBlankLines     bool                 // Keep blank lines inside the response instead of dropping every empty line
+	FilterRegexps          []*regexp.Regexp     // Lines matching any of these are filtered, after FilterPatterns
+	OnRetry                RetryHook            // Called before each retry sleep, e.g. for retry metrics
+	TotalDeadline          time.Duration        // Bound on a whole Execute call including retries and backoff (0 = none)
+	StripPromptEcho        bool                 // Remove the prompt if gemini echoes it at the start of the response
+	AllFiles               bool                 // Pass --all-files so gemini includes every file in the working directory as context
+	Sandbox                bool                 // Pass --sandbox so gemini runs its tools in a container
+	SandboxImage           string               // Container image for the sandbox; empty keeps gemini's default
+	StripANSI              bool                 // Remove ANSI escape sequences such as colors from responses
+	MaxOutputBytes         int                  // Maximum output size in bytes; gemini is killed and ErrOutputTooLarge returned beyond it (0 = unlimited)
+	RetryableErrors        func(error) bool     // Decides which failed attempts are retried (default: DefaultRetryableError)
+	Clock                  Clock                // Time source for timeouts, retry backoff and durations (default: real clock)
+	RequestID              string               // Added to every log message as "request_id"; empty generates a UUID
+	LogOutputLines         bool                 // Log each non-filtered output line at Info level as gemini prints it
+	ConfigPath             string               // gemini settings file for this client, passed via GEMINI_CLI_SYSTEM_SETTINGS_PATH
+	ListModelsArgs         []string             // Arguments that make gemini list its models, e.g. {"models", "list"}; empty makes ListModels fail with ErrUnsupported
+	ModelCacheTTL          time.Duration        // How long ListModels results are reused (0 = DefaultModelCacheTTL, negative disables)
+	IncludePromptInErrors  bool                 // Record the (truncated) prompt in CommandError.Prompt and its message; off to avoid leaking prompts into logs
+	RetryOnEmptyOutput     bool                 // Retry attempts whose output is empty after filtering (ErrNoOutput), up to RetryCount
+	Extensions             []string             // Enable only these gemini extensions (--extensions), in sorted order; nil enables all, an empty non-nil list is invalid
+	LogStderr              bool                 // Log each non-blank stderr line at Info level with a "stderr" key, on success and failure
+	IdempotencyKey         string               // Reuse the result of a recent or running call with this key and prompt instead of running gemini again
+	IdempotencyWindow      time.Duration        // How long a result is reused for IdempotencyKey (0 = DefaultIdempotencyWindow)
+	Debug                  bool                 // Pass --debug; its [DEBUG] lines are removed from responses and, with LogStderr, logged
+	APIKeyFile             string               // File holding the API key, passed to gemini as GEMINI_API_KEY; never logged
+	StrictWorkingDir       bool                 // Fail with ErrWorkingDirNotFound before starting gemini if WorkingDirectory is missing
+	TimeoutMessage         string               // Text of timeout errors instead of "command timed out after ..."; still matches context.DeadlineExceeded
+	RetryBudget            RetryBudgetConfig    // Retries allowed per window across all calls; once spent, failures are returned at once with ErrRetryBudgetExhausted (zero Retries disables)
+	FallbackDirectory      string               // Directory to run in when WorkingDirectory is unset and neither the current nor the home directory resolves; otherwise calls fail with ErrNoWorkingDir
 }
 
-// NewClient creates a new Gemini CLI client with default configuration
-func NewClient() *Client {
-	return &Client{
-		logger:  NewNoOpLogger(),
-		timeout: DefaultTimeout,
-		model:   DefaultModel,
-	}
+// GeminiClient is the set of operations a Gemini client provides. Accept it
+// instead of *Client to substitute a stub in tests.
+type GeminiClient interface {
+	Execute(prompt string) (string, error)
+	ExecuteWithTimeout(prompt string, timeout time.Duration) (string, error)
+	ExecuteWithModel(prompt, model string) (string, error)
+	ValidateAvailable() error
 }
 
-// NewClientWithConfig creates a new Gemini CLI client with custom configuration
-func NewClientWithConfig(config Config) *Client {
+// Ensure *Client satisfies GeminiClient
+var _ GeminiClient = (*Client)(nil)
+
+// NewClient creates a new Gemini CLI client with default configuration,
+// adjusted by the given options
+func NewClient(opts ...Option) *Client {
 	client := &Client{
-		timeout: DefaultTimeout,
-		model:   DefaultModel,
+		logger:            NewNoOpLogger(),
+		binaryPath:        GeminiCommand,
+		promptFlag:        GeminiPromptFlag,
+		modelFlag:         GeminiModelFlag,
+		windowsPaths:      runtime.GOOS == "windows",
+		timeout:           GetDefaultTimeout(),
+		outputFormat:      OutputFormatText,
+		model:             GetDefaultModel(),
+		retryCount:        MaxRetries,
+		retryBackoff:      DefaultRetryBackoff,
+		gracePeriod:       DefaultGracePeriod,
+		modelCacheTTL:     DefaultModelCacheTTL,
+		idempotencyWindow: DefaultIdempotencyWindow,
 	}
+	client.filterPatterns = DefaultFilterPatterns()
+	client.authKeywords = DefaultAuthErrorKeywords()
 
-	if config.Logger != nil {
-		client.logger = config.Logger
-	} else {
-		client.logger = NewNoOpLogger()
+	for _, opt := range opts {
+		opt(client)
 	}
 
-	if config.Timeout > 0 {
-		client.timeout = config.Timeout
+	if client.runner == nil {
+		client.runner = &ExecRunner{GracePeriod: client.gracePeriod}
 	}
-
-	if config.Model != "" {
-		client.model = config.Model
+	if client.clock == nil {
+		client.clock = realClock{}
 	}
-
-	if config.WorkingDirectory != "" {
-		client.workingDirectory = config.WorkingDirectory
+	if client.requestID == "" {
+		client.requestID = newRequestID()
+	}
+	client.logger = withFields(client.logger, "request_id", client.requestID)
+	if client.pathExtensions == nil {
+		client.pathExtensions = DefaultPathExtensions()
 	}
+	if client.limiter == nil && client.rateLimit > 0 {
+		client.limiter = NewRateLimiter(client.rateLimit, 1)
+	}
+	client.breaker = newCircuitBreaker(client.circuitBreaker, client.logger, client.clock)
+	client.retryBudget = newRetryBudget(client.retryBudgetConfig, client.clock)
+	client.pathPattern = buildPathPattern(client.pathExtensions, client.explicitPathsOnly, client.windowsPaths)
 
 	return client
 }
 
+// RequestID returns the id added to every log message as "request_id",
+// generated when Config.RequestID was empty
+func (c *Client) RequestID() string {
+	return c.requestID
+}
+
+// NewClientWithConfig creates a new Gemini CLI client with custom
+// configuration. Invalid values are ignored in favour of defaults; prefer
+// NewClientWithConfigValidated to have them reported.
+func NewClientWithConfig(config Config) *Client {
+	return NewClient(config.options()...)
+}
+
+// NewClientWithConfigValidated creates a new Gemini CLI client after checking
+// the configuration. Every problem found is reported, each matching
+// ErrInvalidConfig.
+func NewClientWithConfigValidated(config Config) (*Client, error) {
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	return NewClientWithConfig(config), nil
+}
+
 // Execute executes a Gemini command with the given prompt
 func (c *Client) Execute(prompt string) (string, error) {
-	if prompt == "" {
-		return "", fmt.Errorf(ErrEmptyPrompt)
+	return c.ExecuteContext(context.Background(), prompt)
+}
+
+// ExecuteContext executes a Gemini command with the given prompt, killing the
+// child process as soon as ctx is cancelled or its deadline passes
+func (c *Client) ExecuteContext(ctx context.Context, prompt string) (string, error) {
+	return resultText(c.executeContext(ctx, prompt, c.defaultExecOptions(prompt)))
+}
+
+// ExecuteResult executes a Gemini command and returns the filtered text along
+// with the raw output, exit code, elapsed time and the exact argv used
+func (c *Client) ExecuteResult(prompt string) (*Result, error) {
+	return c.executeContext(context.Background(), prompt, c.defaultExecOptions(prompt))
+}
+
+// ExecuteWithTimeout executes Gemini command with custom timeout
+func (c *Client) ExecuteWithTimeout(prompt string, timeout time.Duration) (string, error) {
+	opts := c.defaultExecOptions(prompt)
+	opts.timeout = timeout
+	return resultText(c.executeContext(context.Background(), prompt, opts))
+}
+
+// ExecuteWithModel executes a Gemini command with the given model for this
+// call only; an empty model uses the client's configured model
+func (c *Client) ExecuteWithModel(prompt, model string) (string, error) {
+	opts := c.defaultExecOptions(prompt)
+	if model != "" {
+		opts.model = model
 	}
+	return resultText(c.executeContext(context.Background(), prompt, opts))
+}
 
-	// Resolve relative paths if working directory is set
-	resolvedPrompt := prompt
-	if c.workingDirectory != "" {
-		currentDir, err := os.Getwd()
+// ExecuteInDir executes a Gemini command in dir for this call only, using it
+// as the base for path resolution and @file checks; an empty dir uses the
+// client's configured working directory. A dir that does not exist or is not
+// a directory fails with ErrWorkingDirNotFound before gemini is started.
+func (c *Client) ExecuteInDir(prompt, dir string) (string, error) {
+	opts := c.defaultExecOptions(prompt)
+	if dir != "" {
+		opts.dir = dir
+		opts.dirOverride = true
+	}
+	return resultText(c.executeContext(context.Background(), prompt, opts))
+}
+
+// checkWorkingDir reports whether dir exists and is a directory
+func checkWorkingDir(dir string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrWorkingDirNotFound, err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%w: %s is not a directory", ErrWorkingDirNotFound, dir)
+	}
+	return nil
+}
+
+// checkSetup returns the error every call fails with before gemini runs:
+// an unreadable APIKeyFile or, with StrictWorkingDir, a missing dir
+func (c *Client) checkSetup(dir string) error {
+	if c.apiKeyErr != nil {
+		return c.apiKeyErr
+	}
+	return c.checkStrictWorkingDir(dir)
+}
+
+// checkStrictWorkingDir checks dir with checkWorkingDir when StrictWorkingDir
+// is set, so a missing directory fails before gemini is started
+func (c *Client) checkStrictWorkingDir(dir string) error {
+	if !c.strictWorkingDir || dir == "" {
+		return nil
+	}
+	return checkWorkingDir(dir)
+}
+
+// ExecuteStdin executes a Gemini command, writing the prompt to the process's
+// standard input instead of passing it with the prompt flag. Use this for
+// prompts large enough to hit the operating system's argument size limit.
+func (c *Client) ExecuteStdin(prompt string) (string, error) {
+	opts := c.defaultExecOptions(prompt)
+	opts.stdin = true
+	return resultText(c.executeContext(context.Background(), prompt, opts))
+}
+
+// execOptions holds per-call settings derived from the client configuration
+type execOptions struct {
+	model   string        // Model to request
+	timeout time.Duration // Timeout for a single attempt
+	stdin   bool          // Pass the prompt on stdin instead of with the prompt flag
+	dir     string        // Working directory; empty runs in the current directory
+	format  string        // Output format requested from gemini
+
+	// dirOverride marks dir as given for this call: it must exist and is
+	// the base for relative paths instead of the current directory
+	dirOverride bool
+}
+
+// defaultExecOptions returns the per-call settings implied by the client
+// configuration for the given prompt
+func (c *Client) defaultExecOptions(prompt string) execOptions {
+	return execOptions{
+		model:   c.modelArg(),
+		timeout: c.timeout,
+		stdin:   c.promptViaStdin && len(prompt) > c.stdinThreshold,
+		dir:     c.workingDirectory,
+		format:  c.outputFormat,
+	}
+}
+
+// executeContext validates the prompt and runs a Gemini invocation bounded
+// by ctx, the total deadline and opts.timeout, with retries, behind the
+// circuit breaker. The
+// BeforeExecute and AfterExecute hooks wrap the whole retry loop, and
+// OnComplete fires on every return path, including validation failures.
+func (c *Client) executeContext(ctx context.Context, prompt string, opts execOptions) (result *Result, err error) {
+	start := c.clock.Now()
+	if c.onComplete != nil {
+		defer func() {
+			c.onComplete(prompt, result, err, c.clock.Now().Sub(start))
+		}()
+	}
+
+	if c.totalDeadline > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = c.withTimeoutCause(ctx, c.totalDeadline, errTotalDeadline)
+		defer cancel()
+	}
+
+	execPrompt, err := c.preparePrompt(prompt, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.breaker.allow(); err != nil {
+		c.logger.WarnWith("Circuit breaker rejected Gemini command", "error", err)
+		return nil, err
+	}
+	result, err = c.executeIdempotent(ctx, execPrompt, func() (*Result, error) {
+		return c.executeWithFallback(ctx, execPrompt, opts)
+	})
+	c.breaker.record(err)
+	if err != nil {
+		return result, err
+	}
+
+	if c.afterExecute != nil {
+		if result.Text, err = c.afterExecute(result.Text); err != nil {
+			return nil, fmt.Errorf("%s: %w", ErrAfterExecute, err)
+		}
+	}
+	result.Duration = c.clock.Now().Sub(start)
+	return result, nil
+}
+
+// preparePrompt checks the APIKeyFile and, with StrictWorkingDir, the
+// working directory, validates prompt and the sampling settings, applies the
+// BeforeExecute hook and checks @file references, returning the prompt to
+// send
+func (c *Client) preparePrompt(prompt string, opts execOptions) (string, error) {
+	if err := c.checkSetup(opts.dir); err != nil {
+		return "", err
+	}
+	if opts.dirOverride {
+		if err := checkWorkingDir(opts.dir); err != nil {
+			return "", err
+		}
+	}
+	if err := c.validatePrompt(prompt); err != nil {
+		return "", err
+	}
+	if err := c.validateSampling(); err != nil {
+		return "", err
+	}
+
+	execPrompt := prompt
+	if c.beforeExecute != nil {
+		var err error
+		execPrompt, err = c.beforeExecute(prompt)
 		if err != nil {
-			c.logger.WarnWith("Failed to get current directory for path resolution", "error", err)
-		} else {
-			resolvedPrompt, err = c.resolveRelativePaths(prompt, currentDir)
-			if err != nil {
-				c.logger.WarnWith("Failed to resolve relative paths", "error", err)
-				resolvedPrompt = prompt // Use original prompt if resolution fails
-			}
+			return "", fmt.Errorf("%s: %w", ErrBeforeExecute, err)
+		}
+		if err := c.validatePrompt(execPrompt); err != nil {
+			return "", err
 		}
 	}
 
-	// Build command
-	cmdArgs := c.buildGeminiCommandWithModel(resolvedPrompt)
+	if c.validateFileReferences {
+		if err := c.validateFileReferencesIn(execPrompt, opts.dir); err != nil {
+			return "", err
+		}
+	}
+	return execPrompt, nil
+}
 
-	// Log command execution for debugging
-	c.logger.DebugWith("Executing Gemini command", "command", cmdArgs[0], "args", cmdArgs[1:])
+// executeWithFallback runs the retry loop for opts.model and, while it
+// fails because the model is rate-limited or unavailable, again for each
+// fallback model in order
+func (c *Client) executeWithFallback(ctx context.Context, prompt string, opts execOptions) (*Result, error) {
+	result, err := c.executeWithRetries(ctx, prompt, opts)
+	for _, model := range c.modelFallback {
+		if err == nil || !isFallbackError(err) {
+			break
+		}
+		if model == opts.model {
+			continue
+		}
 
-	// Create command with full path to avoid module resolution issues
-	geminiPath, err := exec.LookPath(cmdArgs[0])
-	if err != nil {
-		c.logger.ErrorWith("Failed to find gemini command", "error", err)
-		return "", fmt.Errorf("gemini command not found: %w", err)
+		c.logger.WarnWith("Falling back to another Gemini model", "from", opts.model, "to", model, "error", err)
+		opts.model = model
+		result, err = c.executeWithRetries(ctx, prompt, opts)
 	}
+	return result, err
+}
 
-	c.logger.DebugWith("Using gemini path", "path", geminiPath)
-	cmd := exec.Command(geminiPath, cmdArgs[1:]...)
+// executeWithRetries runs executeOnce, retrying transient failures according
+// to the client's retry settings. Each attempt builds its invocation afresh,
+// so relative paths in the prompt and the default working directory follow
+// the current directory at the time of that attempt.
+func (c *Client) executeWithRetries(ctx context.Context, prompt string, opts execOptions) (*Result, error) {
+	for attempt := 1; ; attempt++ {
+		result, err := c.executeOnce(ctx, prompt, opts)
+		if err == nil {
+			return result, nil
+		}
+		if attempt > c.retryCount || !c.shouldRetry(ctx, err) {
+			return result, err
+		}
+		if !c.retryBudget.spend() {
+			c.logger.WarnWith("Retry budget exhausted, not retrying Gemini command", "attempt", attempt, "error", err)
+			return result, fmt.Errorf("%w: %w", ErrRetryBudgetExhausted, err)
+		}
 
-	// Set working directory based on configuration or fallback to current directory
-	if c.workingDirectory != "" {
-		cmd.Dir = c.workingDirectory
-		c.logger.DebugWith("Using configured working directory", "dir", cmd.Dir)
-	} else {
-		// Use current working directory as default
-		cmd.Dir, err = os.Getwd()
-		if err != nil || cmd.Dir == "" {
-			// Fallback to home directory if current directory cannot be determined
-			cmd.Dir = os.Getenv("HOME")
-			if cmd.Dir == "" {
-				// Final fallback to current user's home directory
-				if user, err := user.Current(); err == nil {
-					cmd.Dir = user.HomeDir
-				}
-			}
+		delay := c.retryDelay(attempt)
+		c.logger.WarnWith("Retrying Gemini command", "attempt", attempt, "max_retries", c.retryCount, "delay", delay, "error", err)
+		if c.onRetry != nil {
+			c.onRetry(attempt, err, delay)
+		}
+		if sleepErr := c.sleep(ctx, delay); sleepErr != nil {
+			return nil, fmt.Errorf("%s: %w (last error: %w)", ErrCommandFailed, sleepErr, err)
 		}
-		c.logger.DebugWith("Using current/default directory", "dir", cmd.Dir)
+	}
+}
+
+// validatePrompt rejects empty prompts and prompts longer than the
+// configured limit, before any process is spawned
+func (c *Client) validatePrompt(prompt string) error {
+	if prompt == "" {
+		return fmt.Errorf(ErrEmptyPrompt)
+	}
+	if c.maxPromptLength > 0 && len(prompt) > c.maxPromptLength {
+		return fmt.Errorf("%w: %d bytes exceeds limit of %d", ErrPromptTooLong, len(prompt), c.maxPromptLength)
+	}
+	return nil
+}
+
+// validateSampling rejects out-of-range sampling parameters so they fail
+// before any process is spawned
+func (c *Client) validateSampling() error {
+	return checkSampling(c.temperature, c.maxOutputTokens)
+}
+
+// checkSampling validates optional temperature and max output token values
+func checkSampling(temperature *float64, maxOutputTokens *int) error {
+	if temperature != nil && (*temperature < MinTemperature || *temperature > MaxTemperature) {
+		return fmt.Errorf("%w: %v is outside [%v, %v]", ErrInvalidTemperature, *temperature, MinTemperature, MaxTemperature)
+	}
+	if maxOutputTokens != nil && *maxOutputTokens <= 0 {
+		return fmt.Errorf("%w: %d must be positive", ErrInvalidMaxOutputTokens, *maxOutputTokens)
+	}
+	return nil
+}
+
+// dryRunCommandLine logs and returns the shell-quoted command line that a
+// dry run reports instead of running inv
+func (c *Client) dryRunCommandLine(inv Invocation) string {
+	commandLine := formatCommandLine(append([]string{inv.Name}, inv.Args...))
+	c.logger.InfoWith("Dry run, not executing Gemini command", "command", commandLine, "dir", inv.Dir)
+	return commandLine
+}
+
+// executeOnce runs a single Gemini invocation bounded by ctx and opts.timeout
+func (c *Client) executeOnce(ctx context.Context, prompt string, opts execOptions) (*Result, error) {
+	if err := contextErr(ctx); err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrCommandFailed, err)
+	}
+
+	inv, err := c.newInvocation(prompt, opts)
+	if err != nil {
+		return nil, err
+	}
+	command := append([]string{inv.Name}, inv.Args...)
+
+	if c.dryRun {
+		return &Result{Text: c.dryRunCommandLine(inv), Command: command, Model: opts.model}, nil
+	}
+
+	// Time spent waiting for the rate limiter does not count towards the
+	// timeout
+	if err := c.waitLimiter(ctx); err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrCommandFailed, err)
 	}
 
-	// Execute with timeout
-	output, err := c.runCommandWithTimeout(cmd, c.timeout)
+	// Derive the per-invocation deadline so the child is killed on timeout
+	ctx, cancel := c.withTimeout(ctx, opts.timeout)
+	defer cancel()
+
+	// Execute bounded by the derived context, timing the first output byte
+	ctx, watch, stopWatch := c.watchStartup(ctx, &inv)
+	flushLines := c.logOutput(&inv)
+	ctx, stopLimit := c.limitOutput(ctx, &inv)
+	output, stderr, err := c.runCommand(ctx, inv, opts.timeout)
+	stopLimit()
+	flushLines()
+	stopWatch()
+	execDuration := c.clock.Now().Sub(watch.start)
 	if err != nil {
+		c.attachPrompt(err, prompt)
 		c.logger.ErrorWith("Gemini command execution failed", "error", err)
-		return "", fmt.Errorf("%s: %w", ErrCommandFailed, err)
+		err = fmt.Errorf("%s: %w", ErrCommandFailed, err)
+		if len(output) > 0 {
+			// Partial output salvaged from a timed-out process or cut
+			// off at MaxOutputBytes
+			partial := &Result{
+				RawOutput:       output,
+				Text:            c.filterGeminiOutput(strings.TrimSpace(string(output))),
+				ExitCode:        processExitCode(err),
+				Command:         command,
+				Model:           opts.model,
+				StartupDuration: watch.startupDuration(),
+				ExecDuration:    execDuration,
+			}
+			partial.RawLineCount, partial.FilteredLineCount = c.countLines(output)
+			partial.Truncated = LooksTruncated(partial.Text)
+			partial.UpdateNotice = updateNotice(output, stderr)
+			c.logger.WarnWith("Returning partial Gemini output", "output_length", len(output))
+			return partial, err
+		}
+		return nil, err
 	}
 
 	// Parse output
-	result, err := c.parseGeminiOutput(output)
+	result := &Result{
+		RawOutput:       output,
+		Command:         command,
+		Model:           opts.model,
+		StartupDuration: watch.startupDuration(),
+		ExecDuration:    execDuration,
+	}
+	if c.outputFormat == OutputFormatJSON {
+		var response *JSONResponse
+		response, err = c.parseGeminiJSONOutput(output)
+		if err == nil {
+			result.Text = response.Text
+			result.PromptTokens = response.PromptTokens
+			result.ResponseTokens = response.ResponseTokens
+		}
+	} else {
+		result.RawLineCount, result.FilteredLineCount = c.countLines(output)
+		result.Text, err = c.parseGeminiOutput(output)
+		if err == nil && c.stripPromptEcho {
+			result.Text = stripPromptEcho(result.Text, prompt)
+		}
+	}
 	if err != nil {
 		c.logger.ErrorWith("Failed to parse Gemini output", "error", err, "output_length", len(output))
-		return "", fmt.Errorf("%s: %w", ErrParseOutput, err)
+		return nil, fmt.Errorf("%s: %w", ErrParseOutput, err)
 	}
+	result.Truncated = LooksTruncated(result.Text)
+	result.UpdateNotice = updateNotice(output, stderr)
 
-	c.logger.DebugWith("Gemini command completed successfully", "response_length", len(result))
+	c.logger.DebugWith("Gemini command completed successfully", "response_length", len(result.Text), "raw_lines", result.RawLineCount, "filtered_lines", result.FilteredLineCount)
 	return result, nil
 }
 
-// ExecuteWithTimeout executes Gemini command with custom timeout
-func (c *Client) ExecuteWithTimeout(prompt string, timeout time.Duration) (string, error) {
-	if prompt == "" {
-		return "", fmt.Errorf(ErrEmptyPrompt)
+// newInvocation resolves the prompt and builds the gemini invocation,
+// including its arguments and working directory
+func (c *Client) newInvocation(prompt string, opts execOptions) (Invocation, error) {
+	dir, err := c.invocationDir(opts.dir)
+	if err != nil {
+		return Invocation{}, err
+	}
+	resolvedPrompt := c.resolvePrompt(prompt, opts)
+
+	// Build command
+	cmdArgs := c.geminiArgs(opts.model, opts.format, resolvedPrompt, opts.stdin)
+
+	// Log command execution for debugging
+	c.logger.DebugWith("Executing Gemini command", "command", cmdArgs[0], "args", cmdArgs[1:], "timeout", opts.timeout, "stdin", opts.stdin)
+
+	inv := Invocation{Name: cmdArgs[0], Args: cmdArgs[1:], Env: c.environment()}
+	if opts.stdin {
+		inv.Stdin = strings.NewReader(resolvedPrompt)
 	}
 
-	// Resolve relative paths if working directory is set
-	resolvedPrompt := prompt
-	if c.workingDirectory != "" {
+	inv.Dir = dir
+	return inv, nil
+}
+
+// resolvePrompt rewrites relative paths in prompt to absolute ones when path
+// resolution is enabled and gemini runs in opts.dir rather than the current
+// directory. Paths are relative to the current directory, or to opts.dir when
+// it was given for the call.
+func (c *Client) resolvePrompt(prompt string, opts execOptions) string {
+	if !c.resolvePaths || opts.dir == "" {
+		return prompt
+	}
+
+	baseDir := opts.dir
+	if !opts.dirOverride {
 		currentDir, err := os.Getwd()
 		if err != nil {
 			c.logger.WarnWith("Failed to get current directory for path resolution", "error", err)
-		} else {
-			resolvedPrompt, err = c.resolveRelativePaths(prompt, currentDir)
-			if err != nil {
-				c.logger.WarnWith("Failed to resolve relative paths", "error", err)
-				resolvedPrompt = prompt // Use original prompt if resolution fails
-			}
+			return prompt
 		}
+		baseDir = currentDir
 	}
+	resolvedPrompt, err := c.resolveRelativePaths(prompt, baseDir)
+	if err != nil {
+		c.logger.WarnWith("Failed to resolve relative paths", "error", err)
+		return prompt // Use original prompt if resolution fails
+	}
+	return resolvedPrompt
+}
 
-	// Build command
-	cmdArgs := c.buildGeminiCommandWithModel(resolvedPrompt)
-
-	// Log command execution for debugging
-	c.logger.DebugWith("Executing Gemini command with timeout", "command", cmdArgs[0], "args", cmdArgs[1:], "timeout", timeout)
+// invocationDir returns dir, or when dir is empty the current directory,
+// falling back to the home directory and then FallbackDirectory. It fails
+// with ErrNoWorkingDir if none of them resolve.
+func (c *Client) invocationDir(dir string) (string, error) {
+	if dir != "" {
+		c.logger.DebugWith("Using configured working directory", "dir", dir)
+		return dir, nil
+	}
 
-	// Create command with full path to avoid module resolution issues
-	geminiPath, err := exec.LookPath(cmdArgs[0])
-	if err != nil {
-		c.logger.ErrorWith("Failed to find gemini command", "error", err)
-		return "", fmt.Errorf("gemini command not found: %w", err)
+	// Use current working directory as default
+	dir, err := os.Getwd()
+	if err != nil || dir == "" {
+		// Fallback to home directory if current directory cannot be determined
+		dir = homeDirectory()
+	}
+	if dir == "" {
+		if c.fallbackDirectory == "" {
+			return "", fmt.Errorf("%w: current and home directories are unavailable and FallbackDirectory is not set", ErrNoWorkingDir)
+		}
+		if err := checkWorkingDir(c.fallbackDirectory); err != nil {
+			return "", fmt.Errorf("%w: FallbackDirectory: %w", ErrNoWorkingDir, err)
+		}
+		dir = c.fallbackDirectory
 	}
+	c.logger.DebugWith("Using current/default directory", "dir", dir)
+	return dir, nil
+}
 
-	c.logger.DebugWith("Using gemini path", "path", geminiPath)
-	cmd := exec.Command(geminiPath, cmdArgs[1:]...)
+// formatCommandLine renders argv as a POSIX shell command line, single-quoting
+// arguments that contain anything other than safe characters
+func formatCommandLine(argv []string) string {
+	quoted := make([]string, len(argv))
+	for i, arg := range argv {
+		quoted[i] = shellQuote(arg)
+	}
+	return strings.Join(quoted, " ")
+}
 
-	// Set working directory based on configuration or fallback to current directory
-	if c.workingDirectory != "" {
-		cmd.Dir = c.workingDirectory
-		c.logger.DebugWith("Using configured working directory", "dir", cmd.Dir)
-	} else {
-		// Use current working directory as default
-		cmd.Dir, err = os.Getwd()
-		if err != nil || cmd.Dir == "" {
-			// Fallback to home directory if current directory cannot be determined
-			cmd.Dir = os.Getenv("HOME")
-			if cmd.Dir == "" {
-				// Final fallback to current user's home directory
-				if user, err := user.Current(); err == nil {
-					cmd.Dir = user.HomeDir
-				}
-			}
+// shellQuote quotes s for a POSIX shell when necessary
+func shellQuote(s string) string {
+	if s == "" {
+		return "''"
+	}
+	safe := true
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./:=@%+,", r)) {
+			safe = false
+			break
 		}
-		c.logger.DebugWith("Using current/default directory", "dir", cmd.Dir)
 	}
+	if safe {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
 
-	// Execute with custom timeout
-	output, err := c.runCommandWithTimeout(cmd, timeout)
-	if err != nil {
-		c.logger.ErrorWith("Gemini command execution failed", "error", err)
-		return "", fmt.Errorf("%s: %w", ErrCommandFailed, err)
+// environment returns the environment for the gemini process: the current
+// process's environment with the configured variables appended. Later
+// entries win when exec.Cmd deduplicates keys, so configured values take
+// precedence. It returns nil to inherit unchanged when nothing is configured.
+func (c *Client) environment() []string {
+	if len(c.env) == 0 {
+		return nil
 	}
 
-	// Parse output
-	result, err := c.parseGeminiOutput(output)
-	if err != nil {
-		c.logger.ErrorWith("Failed to parse Gemini output", "error", err, "output_length", len(output))
-		return "", fmt.Errorf("%s: %w", ErrParseOutput, err)
+	keys := make([]string, 0, len(c.env))
+	for key := range c.env {
+		keys = append(keys, key)
 	}
+	sort.Strings(keys)
 
-	c.logger.DebugWith("Gemini command completed successfully", "response_length", len(result))
-	return result, nil
+	env := os.Environ()
+	for _, key := range keys {
+		env = append(env, key+"="+c.env[key])
+	}
+	return env
+}
+
+// currentUser looks up the OS user for homeDirectory
+var currentUser = user.Current
+
+// homeDirectory returns the user's home directory, checking HOME, then
+// USERPROFILE (set on Windows, where HOME usually is not), then the OS user
+// database. It returns an empty string if none of them resolve.
+func homeDirectory() string {
+	if dir := os.Getenv("HOME"); dir != "" {
+		return dir
+	}
+	if dir := os.Getenv("USERPROFILE"); dir != "" {
+		return dir
+	}
+	// Final fallback to current user's home directory
+	if user, err := currentUser(); err == nil {
+		return user.HomeDir
+	}
+	return ""
 }
 
-// ValidateAvailable checks if Gemini command is available
+// ValidateAvailable checks if Gemini command is available and, when a
+// minimum version is configured, that it is recent enough
 func (c *Client) ValidateAvailable() error {
-	_, err := exec.LookPath(GeminiCommand)
+	_, err := exec.LookPath(c.binaryPath)
 	if err != nil {
-		return fmt.Errorf("%s: %w", ErrCommandNotFound, err)
+		return fmt.Errorf("%w: %w", ErrGeminiNotFound, err)
 	}
-	return nil
+	if c.minVersion == "" {
+		return nil
+	}
+	return c.checkMinVersion()
+}
+
+// ValidateAvailableContext checks that gemini is on PATH and actually runs,
+// by executing gemini --version bounded by ctx and the client's timeout and
+// requiring a zero exit. A missing binary fails with ErrGeminiNotFound, and
+// one that cannot be started, exits non-zero or does not finish in time
+// fails with ErrGeminiNotRunnable. MinVersion is enforced as in
+// ValidateAvailable.
+func (c *Client) ValidateAvailableContext(ctx context.Context) error {
+	if _, err := exec.LookPath(c.binaryPath); err != nil {
+		return fmt.Errorf("%w: %w", ErrGeminiNotFound, err)
+	}
+
+	ctx, cancel := c.withTimeout(ctx, c.timeout)
+	defer cancel()
+
+	output, err := c.runVersion(ctx)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrGeminiNotRunnable, err)
+	}
+	if c.minVersion == "" {
+		return nil
+	}
+
+	installed, err := parseVersion(string(output))
+	if err != nil {
+		return err
+	}
+	return c.requireMinVersion(installed)
 }
 
 // buildGeminiCommand builds the command arguments for Gemini
 func (c *Client) buildGeminiCommand(prompt string) []string {
-	return []string{GeminiCommand, GeminiPromptFlag, prompt}
+	return append([]string{GeminiCommand}, c.promptArgs(prompt)...)
 }
 
-// buildGeminiCommandWithModel builds the command arguments for Gemini with model specification
+// buildGeminiCommandWithModel builds the command arguments for Gemini with
+// model specification. Extra arguments go between the model and the prompt
+// flag so the prompt always remains last.
 func (c *Client) buildGeminiCommandWithModel(prompt string) []string {
-	return []string{GeminiCommand, GeminiModelFlag, c.model, GeminiPromptFlag, prompt}
+	return c.geminiArgs(c.modelArg(), c.outputFormat, prompt, false)
 }
 
-// runCommandWithTimeout executes a command with the specified timeout
-func (c *Client) runCommandWithTimeout(cmd *exec.Cmd, timeout time.Duration) ([]byte, error) {
-	// Start the command
-	var stdout bytes.Buffer
-	var stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+// modelArg returns the model to pass with the model flag, or "" when
+// OmitModelFlag leaves the choice to gemini's own settings
+func (c *Client) modelArg() string {
+	if c.omitModelFlag {
+		return ""
+	}
+	return c.model
+}
 
-	err := cmd.Start()
-	if err != nil {
-		return nil, fmt.Errorf("%s: %w", ErrCommandStart, err)
+// geminiArgs builds the full argument list for the given model. The model
+// flag is omitted when model is empty and the prompt flag when the prompt is
+// sent on stdin. It only reads client
+// configuration, so it is safe to call concurrently.
+func (c *Client) geminiArgs(model, format, prompt string, stdin bool) []string {
+	args := []string{c.binaryPath}
+	if model != "" {
+		args = append(args, c.modelFlag, model)
+	}
+	if format == OutputFormatJSON || format == OutputFormatStreamJSON {
+		args = append(args, GeminiOutputFormatFlag, format)
+	}
+	if c.temperature != nil {
+		args = append(args, GeminiTemperatureFlag, strconv.FormatFloat(*c.temperature, 'f', -1, 64))
 	}
+	if c.maxOutputTokens != nil {
+		args = append(args, GeminiMaxOutputTokensFlag, strconv.Itoa(*c.maxOutputTokens))
+	}
+	if c.allFiles {
+		args = append(args, GeminiAllFilesFlag)
+	}
+	if c.sandbox {
+		args = append(args, GeminiSandboxFlag)
+	}
+	if c.sandboxImage != "" {
+		args = append(args, GeminiSandboxImageFlag, c.sandboxImage)
+	}
+	for _, extension := range c.extensions {
+		args = append(args, GeminiExtensionsFlag, extension)
+	}
+	if c.debug {
+		args = append(args, GeminiDebugFlag)
+	}
+	args = append(args, c.extraArgs...)
+	if stdin {
+		return args
+	}
+	return append(args, c.promptArgs(prompt)...)
+}
+
+// promptArgs returns the prompt flag and its value. A prompt starting with a
+// dash is joined to the flag as "-p=<prompt>" so gemini cannot mistake it for
+// a flag of its own; a separate "--" would end option parsing and detach the
+// prompt from the flag.
+func (c *Client) promptArgs(prompt string) []string {
+	if strings.HasPrefix(prompt, "-") {
+		return []string{c.promptFlag + "=" + prompt}
+	}
+	return []string{c.promptFlag, prompt}
+}
 
-	// Channel to signal command completion
-	done := make(chan error, 1)
-	go func() {
-		done <- cmd.Wait()
-	}()
+// runCommandWithTimeout runs an invocation through the client's runner until
+// it exits or ctx is done, and classifies any failure. ctx is expected to
+// carry the deadline derived from timeout.
+func (c *Client) runCommandWithTimeout(ctx context.Context, inv Invocation, timeout time.Duration) ([]byte, error) {
+	stdout, _, err := c.runCommand(ctx, inv, timeout)
+	return stdout, err
+}
 
-	// Wait for completion or timeout
-	select {
-	case err := <-done:
-		if err != nil {
-			// Capture both stdout and stderr for detailed error reporting
-			stdoutStr := strings.TrimSpace(string(stdout.Bytes()))
-			stderrStr := strings.TrimSpace(string(stderr.Bytes()))
-			combined := append(stdout.Bytes(), stderr.Bytes()...)
-
-			// Check if it's an authentication error
-			if c.detectAuthError(combined) {
-				return nil, fmt.Errorf(ErrAuthFailed)
-			}
+// runCommand is runCommandWithTimeout that also returns the captured stderr
+func (c *Client) runCommand(ctx context.Context, inv Invocation, timeout time.Duration) ([]byte, []byte, error) {
+	stdout, stderr, err := c.runner.Run(ctx, inv)
+	c.logStderrLines(stderr)
+	c.logDebugLines(stdout)
+	if c.outputTooLarge(ctx, stdout) {
+		truncated := stdout[:min(len(stdout), c.maxOutputBytes)]
+		return truncated, stderr, &OutputTooLargeError{Limit: c.maxOutputBytes, Output: string(truncated)}
+	}
+	if err == nil {
+		return stdout, stderr, nil
+	}
 
-			// Create detailed error message
-			errorMsg := fmt.Sprintf("command failed: %v", err)
-			if stderrStr != "" {
-				errorMsg += fmt.Sprintf(" | stderr: %s", stderrStr)
-			}
-			if stdoutStr != "" {
-				errorMsg += fmt.Sprintf(" | stdout: %s", stdoutStr)
+	// A process killed by the context reports a signal error; surface the
+	// context error instead so callers can match it
+	if ctxErr := contextErr(ctx); ctxErr != nil {
+		if errors.Is(context.Cause(ctx), ErrStartupTimeout) {
+			return nil, stderr, fmt.Errorf("%w: no output within %v", ErrStartupTimeout, c.startupTimeout)
+		}
+		if errors.Is(context.Cause(ctx), errTotalDeadline) {
+			if c.timeoutMessage != "" {
+				return nil, stderr, fmt.Errorf("%s: %w", c.timeoutMessage, ctxErr)
 			}
-
-			return nil, fmt.Errorf("%s", errorMsg)
+			return nil, stderr, fmt.Errorf("%s: total deadline of %v exceeded: %w", ErrCommandTimeout, c.totalDeadline, ctxErr)
+		}
+		if c.returnPartialOnTimeout && errors.Is(ctxErr, context.DeadlineExceeded) {
+			return stdout, stderr, c.contextError(ctxErr, timeout)
 		}
-		return stdout.Bytes(), nil
-	case <-time.After(timeout):
-		// Kill the process
-		if cmd.Process != nil {
-			cmd.Process.Kill()
+		return nil, stderr, c.contextError(ctxErr, timeout)
+	}
+
+	// Nothing ran, so there is no output to classify
+	if isStartError(err) {
+		return nil, stderr, err
+	}
+
+	// Authentication failures become an *AuthError; model, quota and
+	// availability failures keep the command details but are matchable with
+	// errors.Is
+	combined := append(append([]byte{}, stdout...), stderr...)
+	cmdErr := newCommandError(err, stdout, stderr, append([]string{inv.Name}, inv.Args...))
+	switch c.classify(combined, nil) {
+	case KindAuth:
+		return nil, stderr, &AuthError{ExitCode: processExitCode(err), Stdout: string(stdout), Stderr: string(stderr)}
+	case KindModelNotFound:
+		return nil, stderr, fmt.Errorf("%w: %w", ErrModelNotFound, cmdErr)
+	case KindRateLimit:
+		return nil, stderr, fmt.Errorf("%w: %w", ErrRateLimited, cmdErr)
+	case KindModelUnavailable:
+		return nil, stderr, fmt.Errorf("%w: %w", ErrModelUnavailable, cmdErr)
+	}
+	return nil, stderr, cmdErr
+}
+
+// contextError wraps a context error so errors.Is works with context.Canceled
+// and context.DeadlineExceeded. Timeouts use TimeoutMessage when set.
+func (c *Client) contextError(err error, timeout time.Duration) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		if c.timeoutMessage != "" {
+			return fmt.Errorf("%s: %w", c.timeoutMessage, err)
 		}
-		return nil, fmt.Errorf("%s after %v", ErrCommandTimeout, timeout)
+		return fmt.Errorf("%s after %v: %w", ErrCommandTimeout, timeout, err)
 	}
+	return fmt.Errorf("command canceled: %w", err)
 }
 
 // parseGeminiOutput parses the output from Gemini command
 func (c *Client) parseGeminiOutput(output []byte) (string, error) {
 	if len(output) == 0 {
-		return "", fmt.Errorf(ErrEmptyOutput)
+		return "", ErrNoOutput
 	}
 
 	// Convert to string and trim whitespace
-	result := strings.TrimSpace(string(output))
+	result := string(output)
+	if c.stripANSI {
+		result = StripANSI(result)
+	}
+	result = strings.TrimSpace(result)
 
 	// Filter out authentication and system messages
 	result = c.filterGeminiOutput(result)
 
 	if result == "" {
-		return "", fmt.Errorf(ErrEmptyOutput)
+		return "", ErrNoOutput
 	}
 
 	return result, nil
 }
 
+// stripPromptEcho removes prompt from the start of output. Runs of whitespace
+// match each other and letters match case-insensitively, and the echo must
+// end at a word boundary. output is returned unchanged if it does not start
+// with the prompt or consists of nothing else.
+func stripPromptEcho(output, prompt string) string {
+	words := strings.Fields(prompt)
+	if len(words) == 0 {
+		return output
+	}
+
+	rest := output
+	for _, word := range words {
+		rest = strings.TrimLeftFunc(rest, unicode.IsSpace)
+		if len(rest) < len(word) || !strings.EqualFold(rest[:len(word)], word) {
+			return output
+		}
+		rest = rest[len(word):]
+	}
+
+	if r, _ := utf8.DecodeRuneInString(rest); rest != "" && !unicode.IsSpace(r) {
+		return output
+	}
+	if rest = strings.TrimSpace(rest); rest == "" {
+		return output
+	}
+	return rest
+}
+
 // detectAuthError detects authentication-related errors in command output
 func (c *Client) detectAuthError(output []byte) bool {
 	return c.containsAnyKeyword(string(output), c.getAuthErrorKeywords())
@@ -335,6 +1103,13 @@ func (c *Client) detectAuthError(output []byte) bool {
 
 // getAuthErrorKeywords returns list of authentication error keywords
 func (c *Client) getAuthErrorKeywords() []string {
+	return c.authKeywords
+}
+
+// DefaultAuthErrorKeywords returns the built-in list of keywords used to
+// detect authentication failures. A new slice is returned on every call, so
+// callers may extend it freely.
+func DefaultAuthErrorKeywords() []string {
 	return []string{
 		"authentication failed",
 		"invalid api key",
@@ -344,11 +1119,59 @@ func (c *Client) getAuthErrorKeywords() []string {
 	}
 }
 
+// detectModelError detects unknown or misspelled model names in command output
+func (c *Client) detectModelError(output []byte) bool {
+	return c.containsAnyKeyword(string(output), c.getModelErrorKeywords())
+}
+
+// getModelErrorKeywords returns list of model-not-found error keywords
+func (c *Client) getModelErrorKeywords() []string {
+	return []string{
+		"model not found",
+		"unknown model",
+		"invalid model",
+		"is not found for api version",
+	}
+}
+
+// detectRateLimitError detects rate-limit and quota errors in command output
+func (c *Client) detectRateLimitError(output []byte) bool {
+	return c.containsAnyKeyword(string(output), c.getRateLimitKeywords())
+}
+
+// getRateLimitKeywords returns list of rate-limit error keywords
+func (c *Client) getRateLimitKeywords() []string {
+	return []string{
+		"429",
+		"rate limit exceeded",
+		"quota exceeded",
+		"resource_exhausted",
+		"too many requests",
+	}
+}
+
+// detectModelUnavailableError detects overloaded or unavailable models in
+// command output
+func (c *Client) detectModelUnavailableError(output []byte) bool {
+	return c.containsAnyKeyword(string(output), c.getModelUnavailableKeywords())
+}
+
+// getModelUnavailableKeywords returns list of model-unavailable error keywords
+func (c *Client) getModelUnavailableKeywords() []string {
+	return []string{
+		"model is overloaded",
+		"model is not available",
+		"model not available",
+		"model unavailable",
+		"503 service unavailable",
+	}
+}
+
 // containsAnyKeyword checks if text contains any of the specified keywords (case-insensitive)
 func (c *Client) containsAnyKeyword(text string, keywords []string) bool {
 	lowerText := strings.ToLower(text)
 	for _, keyword := range keywords {
-		if strings.Contains(lowerText, keyword) {
+		if strings.Contains(lowerText, strings.ToLower(keyword)) {
 			return true
 		}
 	}
@@ -361,8 +1184,54 @@ func (c *Client) filterGeminiOutput(output string) string {
 	lines := strings.Split(output, "\n")
 	var filteredLines []string
 
-	// Filter patterns that should be removed
-	filterPatterns := []string{
+	for _, line := range lines {
+		// Keep the line if it doesn't match filter patterns and isn't empty
+		// (or blank lines are preserved)
+		if !c.shouldFilterLine(line) {
+			filteredLines = append(filteredLines, line)
+		}
+	}
+
+	// Join filtered lines and normalize whitespace, which also drops leading
+	// and trailing blank lines
+	result := strings.Join(filteredLines, "\n")
+	return strings.TrimSpace(result)
+}
+
+// shouldFilterLine reports whether a single output line is a known
+// authentication/system message, or blank unless PreserveBlankLines is set
+func (c *Client) shouldFilterLine(line string) bool {
+	trimmedLine := strings.TrimSpace(line)
+	if trimmedLine == "" {
+		return !c.preserveBlankLines
+	}
+
+	if c.debug && debugLinePattern.MatchString(trimmedLine) {
+		return true
+	}
+	if isUpdateLine(trimmedLine) {
+		return true
+	}
+
+	// Check if line matches any filter pattern
+	for _, pattern := range c.filterPatterns {
+		if strings.Contains(trimmedLine, pattern) {
+			return true
+		}
+	}
+	for _, re := range c.filterRegexps {
+		if re.MatchString(trimmedLine) {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultFilterPatterns returns the built-in list of system messages removed
+// from Gemini output. A new slice is returned on every call, so callers may
+// extend it freely.
+func DefaultFilterPatterns() []string {
+	return []string{
 		"Loaded cached credentials.",
 		"Loading cached credentials",
 		"Authenticating",
@@ -371,53 +1240,84 @@ func (c *Client) filterGeminiOutput(output string) string {
 		"Using cached token",
 		"Token refreshed",
 	}
+}
 
-	for _, line := range lines {
-		trimmedLine := strings.TrimSpace(line)
-		shouldFilter := false
-
-		// Check if line matches any filter pattern
-		for _, pattern := range filterPatterns {
-			if strings.Contains(trimmedLine, pattern) {
-				shouldFilter = true
-				break
-			}
-		}
+// DefaultPathExtensions returns the built-in list of file extensions that
+// mark a bare word such as "notes.txt" as a path during path resolution. A
+// new slice is returned on every call, so callers may extend it freely.
+func DefaultPathExtensions() []string {
+	return []string{
+		"txt", "md", "go", "js", "py", "json", "yaml", "yml", "xml", "html", "css", "sh",
+		"conf", "cfg", "ini", "log", "out", "err", "csv", "tsv", "sql", "db", "lock", "mod",
+		"sum", "env", "toml", "proto", "pb", "rs", "c", "cpp", "h", "hpp", "java", "kt",
+		"php", "rb", "swift", "dart", "scala", "clj", "hs", "elm", "ml", "fs", "pl", "r",
+		"m", "mm", "vue", "jsx", "tsx", "svelte", "astro", "wasm", "zip", "tar", "gz", "bz2",
+		"xz", "7z", "rar", "pdf", "doc", "docx", "xls", "xlsx", "ppt", "pptx", "png", "jpg",
+		"jpeg", "gif", "bmp", "svg", "webp", "ico", "mp3", "mp4", "avi", "mov", "wmv", "flv",
+		"mkv", "webm", "wav", "ogg", "flac", "aac", "m4a", "ttf", "otf", "woff", "woff2", "eot",
+	}
+}
 
-		// Keep the line if it doesn't match filter patterns and isn't empty
-		if !shouldFilter && trimmedLine != "" {
-			filteredLines = append(filteredLines, line)
+// buildPathPattern compiles the regular expression used to find paths in
+// prompts. It matches:
+// - ./file.txt, ../file.txt (explicit relative paths)
+// - file.txt, subdir/file.txt (files with one of extensions), unless explicitOnly
+// - /absolute/path/file.txt (absolute paths, preserved by the caller)
+// With windows set, backslashes are accepted as separators as well and bare
+// paths may carry a drive letter, e.g. .\main.go or C:\src\main.go.
+func buildPathPattern(extensions []string, explicitOnly bool, windows bool) *regexp.Regexp {
+	separators := `\/`
+	drive := ``
+	if windows {
+		separators = `\\\/`
+		drive = `(?:[A-Za-z]:)?`
+	}
+
+	pattern := `\.\.?[` + separators + `][\w\-\.` + separators + `]+`
+	if !explicitOnly && len(extensions) > 0 {
+		quoted := make([]string, len(extensions))
+		for i, ext := range extensions {
+			quoted[i] = regexp.QuoteMeta(strings.TrimPrefix(ext, "."))
 		}
+		pattern += `|` + drive + `[\w\-\.` + separators + `]*\.(?:` + strings.Join(quoted, "|") + `)\b`
 	}
+	return regexp.MustCompile(pattern)
+}
 
-	// Join filtered lines and normalize whitespace
-	result := strings.Join(filteredLines, "\n")
-	return strings.TrimSpace(result)
+// isAbsPromptPath reports whether a path found in a prompt is absolute and
+// must be preserved. A leading slash is treated as absolute on every OS since
+// filepath.IsAbs requires a volume on Windows; with windows set, a leading
+// backslash or a drive letter also counts.
+func isAbsPromptPath(path string, windows bool) bool {
+	if filepath.IsAbs(path) || strings.HasPrefix(path, "/") {
+		return true
+	}
+	if !windows {
+		return false
+	}
+	return strings.HasPrefix(path, `\`) || len(path) >= 2 && path[1] == ':'
 }
 
 // resolveRelativePaths resolves relative paths in the prompt to absolute paths
 func (c *Client) resolveRelativePaths(prompt string, baseDir string) (string, error) {
-	// Regular expression to match file paths
-	// This pattern matches:
-	// - ./file.txt, ../file.txt (explicit relative paths)
-	// - file.txt, subdir/file.txt (files with common extensions)
-	// - /absolute/path/file.txt (absolute paths, preserved)
-	pathPattern := regexp.MustCompile(`(?:\./|\.\./)[\w\-\.\/]+|[\w\-\.\/]*\.(?:txt|md|go|js|py|json|yaml|yml|xml|html|css|sh|conf|cfg|ini|log|out|err|csv|tsv|sql|db|lock|mod|sum|env|toml|proto|pb|rs|c|cpp|h|hpp|java|kt|php|rb|swift|dart|scala|clj|hs|elm|ml|fs|pl|r|m|mm|vue|jsx|tsx|svelte|astro|wasm|zip|tar|gz|bz2|xz|7z|rar|pdf|doc|docx|xls|xlsx|ppt|pptx|png|jpg|jpeg|gif|bmp|svg|webp|ico|mp3|mp4|avi|mov|wmv|flv|mkv|webm|wav|ogg|flac|aac|m4a|ttf|otf|woff|woff2|eot)\b`)
-
 	// Replace matches with resolved paths
-	result := pathPattern.ReplaceAllStringFunc(prompt, func(match string) string {
+	result := c.pathPattern.ReplaceAllStringFunc(prompt, func(match string) string {
 		match = strings.TrimSpace(match)
 		if match == "" {
 			return match
 		}
 
 		// Skip if already absolute path
-		if filepath.IsAbs(match) {
+		if isAbsPromptPath(match, c.windowsPaths) {
 			return match
 		}
 
-		// Resolve relative path
-		resolvedPath := filepath.Join(baseDir, match)
+		// Resolve relative path using the host OS separator
+		relative := match
+		if c.windowsPaths {
+			relative = strings.ReplaceAll(relative, `\`, "/")
+		}
+		resolvedPath := filepath.Join(baseDir, filepath.FromSlash(relative))
 		cleanPath := filepath.Clean(resolvedPath)
 
 		c.logger.DebugWith("Resolved relative path", "original", match, "resolved", cleanPath)
@@ -431,14 +1331,27 @@ func (c *Client) resolveRelativePaths(prompt string, baseDir string) (string, er
 
 // Execute executes a Gemini command with the given prompt using default client
 func Execute(prompt string) (string, error) {
+	return ExecuteContext(context.Background(), prompt)
+}
+
+// ExecuteContext is Execute bounded by ctx
+func ExecuteContext(ctx context.Context, prompt string) (string, error) {
 	client := NewClient()
-	return client.Execute(prompt)
+	return client.ExecuteContext(ctx, prompt)
 }
 
 // ExecuteWithTimeout executes Gemini command with custom timeout using default client
 func ExecuteWithTimeout(prompt string, timeout time.Duration) (string, error) {
+	return ExecuteWithTimeoutContext(context.Background(), prompt, timeout)
+}
+
+// ExecuteWithTimeoutContext is ExecuteWithTimeout bounded by ctx; the call
+// ends at whichever of ctx and timeout expires first
+func ExecuteWithTimeoutContext(ctx context.Context, prompt string, timeout time.Duration) (string, error) {
 	client := NewClient()
-	return client.ExecuteWithTimeout(prompt, timeout)
+	opts := client.defaultExecOptions(prompt)
+	opts.timeout = timeout
+	return resultText(client.executeContext(ctx, prompt, opts))
 }
 
 // ValidateAvailable checks if Gemini command is available using default client
@@ -459,6 +1372,18 @@ func DetectAuthError(output []byte) bool {
 	return client.detectAuthError(output)
 }
 
+// DetectRateLimitError detects rate-limit and quota errors in command output
+func DetectRateLimitError(output []byte) bool {
+	client := NewClient()
+	return client.detectRateLimitError(output)
+}
+
+// DetectModelError detects unknown or misspelled model names in command output
+func DetectModelError(output []byte) bool {
+	client := NewClient()
+	return client.detectModelError(output)
+}
+
 // ParseGeminiOutput parses the output from Gemini command
 func ParseGeminiOutput(output []byte) (string, error) {
 	client := NewClient()
@@ -467,39 +1392,64 @@ func ParseGeminiOutput(output []byte) (string, error) {
 
 // ExecuteWithModel executes a Gemini command with the specified model
 func ExecuteWithModel(prompt, model string) (string, error) {
+	return ExecuteWithModelContext(context.Background(), prompt, model)
+}
+
+// ExecuteWithModelContext is ExecuteWithModel bounded by ctx
+func ExecuteWithModelContext(ctx context.Context, prompt, model string) (string, error) {
 	config := Config{Model: model}
 	client := NewClientWithConfig(config)
-	return client.Execute(prompt)
+	return client.ExecuteContext(ctx, prompt)
 }
 
 // ExecuteWithModelAndTimeout executes a Gemini command with the specified model and timeout
 func ExecuteWithModelAndTimeout(prompt, model string, timeout time.Duration) (string, error) {
+	return ExecuteWithModelAndTimeoutContext(context.Background(), prompt, model, timeout)
+}
+
+// ExecuteWithModelAndTimeoutContext is ExecuteWithModelAndTimeout bounded by ctx
+func ExecuteWithModelAndTimeoutContext(ctx context.Context, prompt, model string, timeout time.Duration) (string, error) {
 	config := Config{Model: model, Timeout: timeout}
 	client := NewClientWithConfig(config)
-	return client.Execute(prompt)
+	return client.ExecuteContext(ctx, prompt)
 }
 
 // ExecuteWithWorkingDirectory executes a Gemini command with the specified working directory
 func ExecuteWithWorkingDirectory(prompt, workingDirectory string) (string, error) {
+	return ExecuteWithWorkingDirectoryContext(context.Background(), prompt, workingDirectory)
+}
+
+// ExecuteWithWorkingDirectoryContext is ExecuteWithWorkingDirectory bounded by ctx
+func ExecuteWithWorkingDirectoryContext(ctx context.Context, prompt, workingDirectory string) (string, error) {
 	config := Config{WorkingDirectory: workingDirectory}
 	client := NewClientWithConfig(config)
-	return client.Execute(prompt)
+	return client.ExecuteContext(ctx, prompt)
 }
 
 // ExecuteWithWorkingDirectoryAndTimeout executes a Gemini command with the specified working directory and timeout
 func ExecuteWithWorkingDirectoryAndTimeout(prompt, workingDirectory string, timeout time.Duration) (string, error) {
+	return ExecuteWithWorkingDirectoryAndTimeoutContext(context.Background(), prompt, workingDirectory, timeout)
+}
+
+// ExecuteWithWorkingDirectoryAndTimeoutContext is ExecuteWithWorkingDirectoryAndTimeout bounded by ctx
+func ExecuteWithWorkingDirectoryAndTimeoutContext(ctx context.Context, prompt, workingDirectory string, timeout time.Duration) (string, error) {
 	config := Config{WorkingDirectory: workingDirectory, Timeout: timeout}
 	client := NewClientWithConfig(config)
-	return client.Execute(prompt)
+	return client.ExecuteContext(ctx, prompt)
 }
 
 // ExecuteWithFullConfig executes a Gemini command with all configuration options
 func ExecuteWithFullConfig(prompt, model, workingDirectory string, timeout time.Duration) (string, error) {
+	return ExecuteWithFullConfigContext(context.Background(), prompt, model, workingDirectory, timeout)
+}
+
+// ExecuteWithFullConfigContext is ExecuteWithFullConfig bounded by ctx
+func ExecuteWithFullConfigContext(ctx context.Context, prompt, model, workingDirectory string, timeout time.Duration) (string, error) {
 	config := Config{
 		Model:            model,
 		WorkingDirectory: workingDirectory,
 		Timeout:          timeout,
 	}
 	client := NewClientWithConfig(config)
-	return client.Execute(prompt)
+	return client.ExecuteContext(ctx, prompt)
 }
@@ -1,8 +1,15 @@
 package geminicli
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
 	"os"
+	"os/user"
 	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -291,28 +298,39 @@ func TestDetectAuthError(t *testing.T) {
 	}
 }
 
-// TestCommandTimeout tests timeout handling
+// TestCommandTimeout tests timeout handling, driven by a fake clock so no
+// real time passes
 func TestCommandTimeout(t *testing.T) {
-	// Test that commands respect timeout settings
-	timeout := 1 * time.Second
-
-	t.Run("TimeoutRespected", func(t *testing.T) {
-		start := time.Now()
+	timeout := 30 * time.Second
+	clock := newFakeClock()
+	client := NewClientWithConfig(Config{Clock: clock, RetryCount: -1, Runner: blockingRunner()})
 
-		client := NewClient()
+	done := make(chan error, 1)
+	go func() {
 		_, err := client.ExecuteWithTimeout("test prompt", timeout)
+		done <- err
+	}()
+
+	clock.BlockUntil(t, 1)
+	clock.Advance(timeout - time.Second)
+	select {
+	case err := <-done:
+		t.Fatalf("Command returned before the timeout: %v", err)
+	case <-time.After(20 * time.Millisecond):
+	}
 
-		elapsed := time.Since(start)
-
-		if err == nil {
-			t.Skip("Function not implemented yet, skipping timeout test")
+	clock.Advance(time.Second)
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected context.DeadlineExceeded, got: %v", err)
 		}
-
-		// Should not take much longer than the timeout
-		if elapsed > timeout+time.Second {
-			t.Errorf("Command took too long: %v (timeout was %v)", elapsed, timeout)
+		if err != nil && !strings.Contains(err.Error(), ErrCommandTimeout) {
+			t.Errorf("Expected timeout message, got: %v", err)
 		}
-	})
+	case <-time.After(5 * time.Second):
+		t.Fatal("Command did not time out once the clock passed the timeout")
+	}
 }
 
 // TestConvenienceExecuteWithTimeout tests the convenience function for timeout handling
@@ -726,6 +744,90 @@ func TestConvenienceExecuteWithFullConfig(t *testing.T) {
 	}
 }
 
+// TestConvenienceContextFunctions tests that the package-level Context
+// variants honor ctx and still apply their settings
+func TestConvenienceContextFunctions(t *testing.T) {
+	installFakeGemini(t, `echo "$@"`)
+	dir := t.TempDir()
+
+	tests := []struct {
+		name        string
+		execute     func(ctx context.Context) (string, error)
+		expected    string
+		description string
+	}{
+		{
+			name:        "ExecuteContext",
+			execute:     func(ctx context.Context) (string, error) { return ExecuteContext(ctx, "hi") },
+			expected:    "-m gemini-2.5-flash -p hi",
+			description: "Default client should be used",
+		},
+		{
+			name: "ExecuteWithTimeoutContext",
+			execute: func(ctx context.Context) (string, error) {
+				return ExecuteWithTimeoutContext(ctx, "hi", time.Minute)
+			},
+			expected:    "-m gemini-2.5-flash -p hi",
+			description: "Default client should be used with the timeout",
+		},
+		{
+			name: "ExecuteWithModelContext",
+			execute: func(ctx context.Context) (string, error) {
+				return ExecuteWithModelContext(ctx, "hi", "gemini-2.5-pro")
+			},
+			expected:    "-m gemini-2.5-pro -p hi",
+			description: "Model should be passed",
+		},
+		{
+			name: "ExecuteWithModelAndTimeoutContext",
+			execute: func(ctx context.Context) (string, error) {
+				return ExecuteWithModelAndTimeoutContext(ctx, "hi", "gemini-2.5-pro", time.Minute)
+			},
+			expected:    "-m gemini-2.5-pro -p hi",
+			description: "Model should be passed",
+		},
+		{
+			name: "ExecuteWithWorkingDirectoryContext",
+			execute: func(ctx context.Context) (string, error) {
+				return ExecuteWithWorkingDirectoryContext(ctx, "hi", dir)
+			},
+			expected:    "-m gemini-2.5-flash -p hi",
+			description: "Working directory should be accepted",
+		},
+		{
+			name: "ExecuteWithWorkingDirectoryAndTimeoutContext",
+			execute: func(ctx context.Context) (string, error) {
+				return ExecuteWithWorkingDirectoryAndTimeoutContext(ctx, "hi", dir, time.Minute)
+			},
+			expected:    "-m gemini-2.5-flash -p hi",
+			description: "Working directory should be accepted",
+		},
+		{
+			name: "ExecuteWithFullConfigContext",
+			execute: func(ctx context.Context) (string, error) {
+				return ExecuteWithFullConfigContext(ctx, "hi", "gemini-2.5-pro", dir, time.Minute)
+			},
+			expected:    "-m gemini-2.5-pro -p hi",
+			description: "All settings should be applied",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := tt.execute(context.Background())
+			if err != nil || result != tt.expected {
+				t.Errorf("%s: expected %q, got %q (%v)", tt.description, tt.expected, result, err)
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			if _, err := tt.execute(ctx); !errors.Is(err, context.Canceled) {
+				t.Errorf("Expected context.Canceled for a cancelled context, got %v", err)
+			}
+		})
+	}
+}
+
 // TestResolveRelativePaths tests the relative path resolution functionality
 func TestResolveRelativePaths(t *testing.T) {
 	// Create a temporary client for testing
@@ -862,3 +964,1648 @@ func TestWorkingDirectoryPathResolution(t *testing.T) {
 		t.Errorf("Expected result to contain '%s', got: %s", expectedPath, result)
 	}
 }
+
+// installFakeGemini writes a shell script named gemini into a temporary
+// directory and puts it first on PATH for the duration of the test
+func installFakeGemini(t *testing.T, script string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, GeminiCommand)
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script+"\n"), 0755); err != nil {
+		t.Fatalf("Failed to write fake gemini: %v", err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+// TestExecuteContext tests context-aware execution
+func TestExecuteContext(t *testing.T) {
+	t.Run("EmptyPrompt", func(t *testing.T) {
+		client := NewClient()
+		if _, err := client.ExecuteContext(context.Background(), ""); err == nil {
+			t.Error("Expected error for empty prompt, but got none")
+		}
+	})
+
+	t.Run("AlreadyCancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		client := NewClient()
+		_, err := client.ExecuteContext(ctx, "test prompt")
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected context.Canceled, got: %v", err)
+		}
+	})
+
+	t.Run("CancelKillsProcess", func(t *testing.T) {
+		installFakeGemini(t, "exec sleep 10")
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		client := NewClient()
+		_, err := client.ExecuteContext(ctx, "test prompt")
+		elapsed := time.Since(start)
+
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected context.DeadlineExceeded, got: %v", err)
+		}
+		if elapsed > 2*time.Second {
+			t.Errorf("Cancellation took too long: %v", elapsed)
+		}
+	})
+
+	t.Run("ClientTimeoutWrapsDeadline", func(t *testing.T) {
+		installFakeGemini(t, "exec sleep 10")
+
+		client := NewClientWithConfig(Config{Timeout: 100 * time.Millisecond, RetryCount: -1})
+		_, err := client.ExecuteContext(context.Background(), "test prompt")
+
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected context.DeadlineExceeded, got: %v", err)
+		}
+		if err != nil && !strings.Contains(err.Error(), ErrCommandTimeout) {
+			t.Errorf("Expected timeout message, got: %v", err)
+		}
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		installFakeGemini(t, "echo 'Loaded cached credentials.'; echo \"$@\"")
+
+		client := NewClient()
+		result, err := client.ExecuteContext(context.Background(), "hello")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if result != "-m gemini-2.5-flash -p hello" {
+			t.Errorf("Expected echoed arguments, got '%s'", result)
+		}
+	})
+}
+
+// TestExecuteStdin tests passing the prompt on standard input
+func TestExecuteStdin(t *testing.T) {
+	// The fake gemini echoes its arguments followed by whatever it reads on stdin
+	installFakeGemini(t, `echo "args: $@"; echo "stdin: $(cat)"`)
+
+	t.Run("ExplicitStdin", func(t *testing.T) {
+		client := NewClient()
+		result, err := client.ExecuteStdin("large prompt")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		expected := "args: -m gemini-2.5-flash\nstdin: large prompt"
+		if result != expected {
+			t.Errorf("Expected '%s', got '%s'", expected, result)
+		}
+	})
+
+	t.Run("AutomaticSwitchAboveThreshold", func(t *testing.T) {
+		tests := []struct {
+			name          string
+			prompt        string
+			expectedStdin bool
+		}{
+			{name: "ShortPrompt", prompt: "short", expectedStdin: false},
+			{name: "LongPrompt", prompt: "a prompt longer than the threshold", expectedStdin: true},
+		}
+
+		client := NewClientWithConfig(Config{PromptViaStdin: true, StdinThreshold: 10})
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				result, err := client.Execute(tt.prompt)
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+
+				usedStdin := strings.Contains(result, "stdin: "+tt.prompt)
+				if usedStdin != tt.expectedStdin {
+					t.Errorf("Expected stdin=%v for prompt '%s', got output '%s'", tt.expectedStdin, tt.prompt, result)
+				}
+			})
+		}
+	})
+}
+
+// TestCustomFilterPatterns tests configurable output filter patterns
+func TestCustomFilterPatterns(t *testing.T) {
+	output := []byte("Loaded cached credentials.\nFlushing log events\nData collection is disabled\nHello, world!")
+
+	t.Run("DefaultsOnly", func(t *testing.T) {
+		result, err := NewClient().parseGeminiOutput(output)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		expected := "Flushing log events\nData collection is disabled\nHello, world!"
+		if result != expected {
+			t.Errorf("Expected '%s', got '%s'", expected, result)
+		}
+	})
+
+	t.Run("AppendedToDefaults", func(t *testing.T) {
+		client := NewClientWithConfig(Config{
+			FilterPatterns: []string{"Flushing log events", "Data collection is disabled"},
+		})
+		result, err := client.parseGeminiOutput(output)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if result != "Hello, world!" {
+			t.Errorf("Expected 'Hello, world!', got '%s'", result)
+		}
+	})
+
+	t.Run("Regexps", func(t *testing.T) {
+		output := []byte("Loaded credentials for user@example.com\nSession 8f3a expires in 59m\nHello, world!")
+		client := NewClientWithConfig(Config{
+			FilterRegexps: []*regexp.Regexp{
+				regexp.MustCompile(`^Loaded credentials for \S+@\S+$`),
+				regexp.MustCompile(`^Session [0-9a-f]+ expires in \d+m$`),
+			},
+		})
+		result, err := client.parseGeminiOutput(output)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if result != "Hello, world!" {
+			t.Errorf("Expected 'Hello, world!', got '%s'", result)
+		}
+	})
+
+	t.Run("DefaultFilterPatternsIsACopy", func(t *testing.T) {
+		patterns := DefaultFilterPatterns()
+		patterns[0] = "mutated"
+		if DefaultFilterPatterns()[0] == "mutated" {
+			t.Error("DefaultFilterPatterns should return a fresh slice")
+		}
+	})
+}
+
+// TestCustomAuthErrorKeywords tests configurable authentication error keywords
+func TestCustomAuthErrorKeywords(t *testing.T) {
+	tests := []struct {
+		name        string
+		output      []byte
+		expectAuth  bool
+		description string
+	}{
+		{
+			name:        "BuiltInKeyword",
+			output:      []byte("Error: invalid API key"),
+			expectAuth:  true,
+			description: "Should still detect built-in keywords",
+		},
+		{
+			name:        "CustomKeyword",
+			output:      []byte("Error: Credential Error while loading profile"),
+			expectAuth:  true,
+			description: "Should detect custom keywords case-insensitively",
+		},
+		{
+			name:        "MixedCaseCustomKeyword",
+			output:      []byte("oauth token expired"),
+			expectAuth:  true,
+			description: "Should match custom keywords configured in mixed case",
+		},
+		{
+			name:        "NoMatch",
+			output:      []byte("Normal Gemini response"),
+			expectAuth:  false,
+			description: "Should not detect auth error in normal output",
+		},
+	}
+
+	client := NewClientWithConfig(Config{
+		AuthErrorKeywords: []string{"credential error", "Token Expired"},
+	})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := client.detectAuthError(tt.output); result != tt.expectAuth {
+				t.Errorf("Expected %v, got %v for test case '%s'", tt.expectAuth, result, tt.name)
+			}
+		})
+	}
+
+	if DetectAuthError([]byte("credential error")) {
+		t.Error("Custom keywords should not leak into the default client")
+	}
+}
+
+// TestHomeDirectoryFallback tests the home directory fallback chain
+func TestHomeDirectoryFallback(t *testing.T) {
+	t.Run("PrefersHOME", func(t *testing.T) {
+		t.Setenv("HOME", "/home/gemini")
+		t.Setenv("USERPROFILE", "/users/gemini")
+
+		if dir := homeDirectory(); dir != "/home/gemini" {
+			t.Errorf("Expected '/home/gemini', got '%s'", dir)
+		}
+	})
+
+	t.Run("FallsBackToUSERPROFILE", func(t *testing.T) {
+		t.Setenv("HOME", "")
+		t.Setenv("USERPROFILE", `C:\Users\gemini`)
+
+		if dir := homeDirectory(); dir != `C:\Users\gemini` {
+			t.Errorf("Expected USERPROFILE to be used, got '%s'", dir)
+		}
+	})
+}
+
+// TestFallbackDirectory tests the final fallback when neither the current
+// nor the home directory resolves
+func TestFallbackDirectory(t *testing.T) {
+	gone := filepath.Join(t.TempDir(), "gone")
+	if err := os.Mkdir(gone, 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(gone)
+	if err := os.Remove(gone); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("HOME", "")
+	t.Setenv("USERPROFILE", "")
+	lookupUser := currentUser
+	currentUser = func() (*user.User, error) { return nil, errors.New("no user database") }
+	t.Cleanup(func() { currentUser = lookupUser })
+
+	fallback := t.TempDir()
+	tests := []struct {
+		name        string
+		fallback    string
+		expectedErr error
+		description string
+	}{
+		{
+			name:        "UsesFallbackDirectory",
+			fallback:    fallback,
+			description: "FallbackDirectory should be used as the last resort",
+		},
+		{
+			name:        "NoFallbackDirectory",
+			expectedErr: ErrNoWorkingDir,
+			description: "Without FallbackDirectory the call should fail instead of running with an empty Dir",
+		},
+		{
+			name:        "MissingFallbackDirectory",
+			fallback:    filepath.Join(fallback, "missing"),
+			expectedErr: ErrWorkingDirNotFound,
+			description: "A missing FallbackDirectory should be reported",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := &fakeRunner{}
+			client := NewClientWithConfig(Config{FallbackDirectory: tt.fallback, RetryCount: -1, Runner: runner})
+
+			_, err := client.Execute("test prompt")
+
+			calls := runner.invocations()
+			if tt.expectedErr != nil {
+				if !errors.Is(err, ErrNoWorkingDir) || !errors.Is(err, tt.expectedErr) {
+					t.Errorf("%s: expected %v, got %v", tt.description, tt.expectedErr, err)
+				}
+				if len(calls) != 0 {
+					t.Errorf("%s: expected gemini not to run, got %d invocations", tt.description, len(calls))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("%s: unexpected error: %v", tt.description, err)
+			}
+			if len(calls) != 1 || calls[0].Dir != tt.fallback {
+				t.Errorf("%s: expected gemini to run in %s, got %+v", tt.description, tt.fallback, calls)
+			}
+		})
+
+		t.Run(tt.name+"Version", func(t *testing.T) {
+			runner := &fakeRunner{run: func(ctx context.Context, inv Invocation) ([]byte, []byte, error) {
+				return []byte("0.1.0\n"), nil, nil
+			}}
+			client := NewClientWithConfig(Config{FallbackDirectory: tt.fallback, Runner: runner})
+
+			_, err := client.Version()
+
+			calls := runner.invocations()
+			if tt.expectedErr != nil {
+				if !errors.Is(err, ErrNoWorkingDir) || !errors.Is(err, tt.expectedErr) {
+					t.Errorf("%s: expected %v, got %v", tt.description, tt.expectedErr, err)
+				}
+				if len(calls) != 0 {
+					t.Errorf("%s: expected gemini not to run, got %d invocations", tt.description, len(calls))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("%s: unexpected error: %v", tt.description, err)
+			}
+			if len(calls) != 1 || calls[0].Dir != tt.fallback {
+				t.Errorf("%s: expected gemini --version to run in %s, got %+v", tt.description, tt.fallback, calls)
+			}
+		})
+	}
+}
+
+// TestGracefulTermination tests SIGTERM-then-SIGKILL handling on timeout
+func TestGracefulTermination(t *testing.T) {
+	t.Run("ProcessReceivesSIGTERM", func(t *testing.T) {
+		marker := filepath.Join(t.TempDir(), "terminated")
+		installFakeGemini(t, `trap 'touch "`+marker+`"; kill $pid; exit 0' TERM
+sleep 10 &
+pid=$!
+wait`)
+
+		client := NewClientWithConfig(Config{Timeout: 200 * time.Millisecond, GracePeriod: 2 * time.Second, RetryCount: -1})
+		_, err := client.Execute("test prompt")
+
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected context.DeadlineExceeded, got: %v", err)
+		}
+		if _, statErr := os.Stat(marker); statErr != nil {
+			t.Errorf("Expected process to handle SIGTERM before exiting: %v", statErr)
+		}
+	})
+
+	t.Run("KilledAfterGracePeriod", func(t *testing.T) {
+		// Ignored signal dispositions survive exec, so sleep ignores SIGTERM
+		installFakeGemini(t, "trap '' TERM; exec sleep 10")
+
+		timeout := 200 * time.Millisecond
+		grace := 300 * time.Millisecond
+		client := NewClientWithConfig(Config{Timeout: timeout, GracePeriod: grace, RetryCount: -1})
+
+		start := time.Now()
+		_, err := client.Execute("test prompt")
+		elapsed := time.Since(start)
+
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected context.DeadlineExceeded, got: %v", err)
+		}
+		if elapsed < timeout+grace {
+			t.Errorf("Expected to wait for the grace period, returned after %v", elapsed)
+		}
+		if elapsed > timeout+grace+2*time.Second {
+			t.Errorf("Process was not killed after the grace period: %v", elapsed)
+		}
+	})
+
+	t.Run("KillsChildProcesses", func(t *testing.T) {
+		// The background child keeps stdout open and would outlive a
+		// gemini process killed on its own
+		marker := filepath.Join(t.TempDir(), "orphan")
+		installFakeGemini(t, `(sleep 1; touch "`+marker+`") &
+exec sleep 10`)
+
+		client := NewClientWithConfig(Config{Timeout: 200 * time.Millisecond, GracePeriod: -1, RetryCount: -1})
+
+		start := time.Now()
+		_, err := client.Execute("test prompt")
+		elapsed := time.Since(start)
+
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected context.DeadlineExceeded, got: %v", err)
+		}
+		if elapsed > 800*time.Millisecond {
+			t.Errorf("Expected to return without waiting for the child, returned after %v", elapsed)
+		}
+		time.Sleep(1500 * time.Millisecond)
+		if _, statErr := os.Stat(marker); statErr == nil {
+			t.Error("Expected the child process to be killed with gemini")
+		}
+	})
+}
+
+// TestBuildGeminiCommandWithExtraArgs tests pass-through of extra gemini flags
+func TestBuildGeminiCommandWithExtraArgs(t *testing.T) {
+	tests := []struct {
+		name        string
+		extraArgs   []string
+		expected    []string
+		description string
+	}{
+		{
+			name:        "NoExtraArgs",
+			extraArgs:   nil,
+			expected:    []string{"gemini", "-m", "gemini-2.5-flash", "-p", "test prompt"},
+			description: "Should build the same command as before when ExtraArgs is empty",
+		},
+		{
+			name:        "ExtraArgsBeforePrompt",
+			extraArgs:   []string{"--sandbox", "--include-directories", "/src"},
+			expected:    []string{"gemini", "-m", "gemini-2.5-flash", "--sandbox", "--include-directories", "/src", "-p", "test prompt"},
+			description: "Should place extra args after the model flag and before -p",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewClientWithConfig(Config{ExtraArgs: tt.extraArgs})
+			cmd := client.buildGeminiCommandWithModel("test prompt")
+
+			if strings.Join(cmd, " ") != strings.Join(tt.expected, " ") {
+				t.Errorf("Expected command %q, got %q", tt.expected, cmd)
+			}
+			if cmd[len(cmd)-1] != "test prompt" {
+				t.Errorf("Expected prompt to be last, got '%s'", cmd[len(cmd)-1])
+			}
+		})
+	}
+}
+
+// TestBuildGeminiCommandDashPrompt tests that prompts starting with a dash
+// stay bound to the prompt flag
+func TestBuildGeminiCommandDashPrompt(t *testing.T) {
+	tests := []struct {
+		name        string
+		prompt      string
+		promptFlag  string
+		expected    []string
+		description string
+	}{
+		{
+			name:        "LongFlagLookalike",
+			prompt:      "--help ignore this",
+			expected:    []string{"gemini", "-m", "gemini-2.5-flash", "-p=--help ignore this"},
+			description: "A prompt starting with -- should be joined to the prompt flag",
+		},
+		{
+			name:        "ShortFlagLookalike",
+			prompt:      "-y",
+			expected:    []string{"gemini", "-m", "gemini-2.5-flash", "-p=-y"},
+			description: "A prompt starting with - should be joined to the prompt flag",
+		},
+		{
+			name:        "CustomPromptFlag",
+			prompt:      "--help",
+			promptFlag:  "--prompt",
+			expected:    []string{"gemini", "-m", "gemini-2.5-flash", "--prompt=--help"},
+			description: "A configured prompt flag should be joined the same way",
+		},
+		{
+			name:        "InnerDash",
+			prompt:      "explain a - b",
+			expected:    []string{"gemini", "-m", "gemini-2.5-flash", "-p", "explain a - b"},
+			description: "Dashes after the first character should not change the command",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := &fakeRunner{}
+			client := NewClientWithConfig(Config{PromptFlag: tt.promptFlag, Runner: runner})
+
+			cmd := client.buildGeminiCommandWithModel(tt.prompt)
+			if strings.Join(cmd, "\x00") != strings.Join(tt.expected, "\x00") {
+				t.Errorf("%s: expected command %q, got %q", tt.description, tt.expected, cmd)
+			}
+
+			if _, err := client.Execute(tt.prompt); err != nil {
+				t.Fatalf("%s: unexpected error: %v", tt.description, err)
+			}
+			for _, arg := range runner.invocations()[0].Args {
+				if arg == tt.prompt && strings.HasPrefix(arg, "-") {
+					t.Errorf("%s: prompt %q was passed as a standalone argument", tt.description, tt.prompt)
+				}
+			}
+		})
+	}
+}
+
+// TestBuildGeminiCommandWithAllFiles tests placement of the --all-files flag
+func TestBuildGeminiCommandWithAllFiles(t *testing.T) {
+	tests := []struct {
+		name        string
+		allFiles    bool
+		expected    []string
+		description string
+	}{
+		{
+			name:        "Disabled",
+			expected:    []string{"gemini", "-m", "gemini-2.5-flash", "--yolo", "-p", "test prompt"},
+			description: "The flag should be omitted entirely when AllFiles is false",
+		},
+		{
+			name:        "Enabled",
+			allFiles:    true,
+			expected:    []string{"gemini", "-m", "gemini-2.5-flash", "--all-files", "--yolo", "-p", "test prompt"},
+			description: "The flag should follow the model flag and precede extra args and -p",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewClientWithConfig(Config{AllFiles: tt.allFiles, ExtraArgs: []string{"--yolo"}})
+			cmd := client.buildGeminiCommandWithModel("test prompt")
+
+			if strings.Join(cmd, " ") != strings.Join(tt.expected, " ") {
+				t.Errorf("%s: expected command %q, got %q", tt.description, tt.expected, cmd)
+			}
+		})
+	}
+}
+
+// TestBuildGeminiCommandWithSandbox tests placement of the sandbox flags
+func TestBuildGeminiCommandWithSandbox(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      Config
+		expected    []string
+		description string
+	}{
+		{
+			name:        "Disabled",
+			expected:    []string{"gemini", "-m", "gemini-2.5-flash", "--yolo", "-p", "test prompt"},
+			description: "No sandbox flags should be passed by default",
+		},
+		{
+			name:        "Sandbox",
+			config:      Config{Sandbox: true},
+			expected:    []string{"gemini", "-m", "gemini-2.5-flash", "--sandbox", "--yolo", "-p", "test prompt"},
+			description: "--sandbox should precede extra args and -p",
+		},
+		{
+			name:        "SandboxImage",
+			config:      Config{Sandbox: true, SandboxImage: "example/sandbox:1.0"},
+			expected:    []string{"gemini", "-m", "gemini-2.5-flash", "--sandbox", "--sandbox-image", "example/sandbox:1.0", "--yolo", "-p", "test prompt"},
+			description: "The image should follow --sandbox",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.ExtraArgs = []string{"--yolo"}
+			client := NewClientWithConfig(tt.config)
+			cmd := client.buildGeminiCommandWithModel("test prompt")
+
+			if strings.Join(cmd, " ") != strings.Join(tt.expected, " ") {
+				t.Errorf("%s: expected command %q, got %q", tt.description, tt.expected, cmd)
+			}
+		})
+	}
+}
+
+// TestBuildGeminiCommandWithExtensions tests deterministic --extensions flags
+func TestBuildGeminiCommandWithExtensions(t *testing.T) {
+	tests := []struct {
+		name        string
+		extensions  []string
+		expected    []string
+		description string
+	}{
+		{
+			name:        "Unset",
+			expected:    []string{"gemini", "-m", "gemini-2.5-flash", "--yolo", "-p", "test prompt"},
+			description: "No --extensions flag should be passed by default",
+		},
+		{
+			name:        "Single",
+			extensions:  []string{"github"},
+			expected:    []string{"gemini", "-m", "gemini-2.5-flash", "--extensions", "github", "--yolo", "-p", "test prompt"},
+			description: "One extension should produce one flag before extra args",
+		},
+		{
+			name:        "SortedAndDeduplicated",
+			extensions:  []string{"search", "github", "search"},
+			expected:    []string{"gemini", "-m", "gemini-2.5-flash", "--extensions", "github", "--extensions", "search", "--yolo", "-p", "test prompt"},
+			description: "Extensions should be sorted and deduplicated",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewClientWithConfig(Config{Extensions: tt.extensions, ExtraArgs: []string{"--yolo"}})
+			cmd := client.buildGeminiCommandWithModel("test prompt")
+
+			if strings.Join(cmd, " ") != strings.Join(tt.expected, " ") {
+				t.Errorf("%s: expected command %q, got %q", tt.description, tt.expected, cmd)
+			}
+		})
+	}
+}
+
+// stubClient is a minimal GeminiClient implementation used to verify the
+// interface can be satisfied by downstream stubs
+type stubClient struct{}
+
+func (stubClient) Execute(prompt string) (string, error) { return "stub", nil }
+func (stubClient) ExecuteWithTimeout(prompt string, timeout time.Duration) (string, error) {
+	return "stub", nil
+}
+func (stubClient) ExecuteWithModel(prompt, model string) (string, error) { return "stub", nil }
+func (stubClient) ValidateAvailable() error                              { return nil }
+
+// TestGeminiClientInterface tests that clients can be substituted through GeminiClient
+func TestGeminiClientInterface(t *testing.T) {
+	clients := map[string]GeminiClient{
+		"Client": NewClient(),
+		"Stub":   stubClient{},
+	}
+
+	for name, client := range clients {
+		t.Run(name, func(t *testing.T) {
+			if _, err := client.Execute(""); name == "Client" && err == nil {
+				t.Error("Expected error for empty prompt from real client")
+			}
+		})
+	}
+}
+
+// TestClientExecuteWithModel tests per-call model overrides
+func TestClientExecuteWithModel(t *testing.T) {
+	runner := &fakeRunner{}
+	client := NewClientWithConfig(Config{Model: "gemini-2.5-flash", Runner: runner})
+
+	if _, err := client.ExecuteWithModel("hello", "gemini-2.5-pro"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := client.ExecuteWithModel("hello", ""); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	calls := runner.invocations()
+	expectedModels := []string{"gemini-2.5-pro", "gemini-2.5-flash"}
+	for i, expected := range expectedModels {
+		if calls[i].Args[1] != expected {
+			t.Errorf("Expected model '%s' for call %d, got '%s'", expected, i, calls[i].Args[1])
+		}
+	}
+	if client.model != "gemini-2.5-flash" {
+		t.Errorf("Client model should not be mutated, got '%s'", client.model)
+	}
+}
+
+// TestClientExecuteInDir tests per-call working directory overrides
+func TestClientExecuteInDir(t *testing.T) {
+	configured := t.TempDir()
+	override := t.TempDir()
+
+	runner := &fakeRunner{}
+	client := NewClientWithConfig(Config{WorkingDirectory: configured, ResolvePaths: true, Runner: runner})
+
+	if _, err := client.ExecuteInDir("Review ./main.go", override); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := client.ExecuteInDir("hello", ""); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	calls := runner.invocations()
+	if calls[0].Dir != override {
+		t.Errorf("Expected dir '%s', got '%s'", override, calls[0].Dir)
+	}
+	if prompt := calls[0].Args[len(calls[0].Args)-1]; prompt != "Review "+filepath.Join(override, "main.go") {
+		t.Errorf("Expected paths to be resolved against the override, got '%s'", prompt)
+	}
+	if calls[1].Dir != configured {
+		t.Errorf("Expected an empty dir to use the configured directory, got '%s'", calls[1].Dir)
+	}
+	if client.workingDirectory != configured {
+		t.Errorf("Client working directory should not be mutated, got '%s'", client.workingDirectory)
+	}
+
+	file := filepath.Join(override, "file.txt")
+	if err := os.WriteFile(file, []byte("x"), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	for _, dir := range []string{filepath.Join(override, "missing"), file} {
+		_, err := client.ExecuteInDir("hello", dir)
+		if !errors.Is(err, ErrWorkingDirNotFound) {
+			t.Errorf("Expected ErrWorkingDirNotFound for '%s', got %v", dir, err)
+		}
+		if err != nil && strings.Contains(err.Error(), ErrCommandFailed) {
+			t.Errorf("Expected a directory error distinct from command failures, got %v", err)
+		}
+	}
+	if len(runner.invocations()) != 2 {
+		t.Errorf("Expected no gemini runs for invalid directories, got %d", len(runner.invocations())-2)
+	}
+}
+
+// TestClientExecuteInDirFileReference tests that a relative @file reference
+// is checked and resolved against the per-call directory
+func TestClientExecuteInDirFileReference(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("x"), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	runner := &fakeRunner{}
+	client := NewClientWithConfig(Config{
+		WorkingDirectory:       t.TempDir(),
+		ResolvePaths:           true,
+		ValidateFileReferences: true,
+		Runner:                 runner,
+	})
+
+	if _, err := client.ExecuteInDir("Summarize @notes.txt", dir); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	calls := runner.invocations()
+	if prompt := calls[0].Args[len(calls[0].Args)-1]; prompt != "Summarize @"+filepath.Join(dir, "notes.txt") {
+		t.Errorf("Expected the reference to be resolved against dir, got '%s'", prompt)
+	}
+
+	if _, err := client.Execute("Summarize @notes.txt"); !errors.Is(err, ErrFileReferenceNotFound) {
+		t.Errorf("Expected the reference to be missing from the configured directory, got %v", err)
+	}
+}
+
+// TestClientExecuteInDirOnComplete tests that OnComplete sees an invalid
+// per-call directory
+func TestClientExecuteInDirOnComplete(t *testing.T) {
+	var completeErr error
+	calls := 0
+	runner := &fakeRunner{}
+	client := NewClientWithConfig(Config{
+		Runner: runner,
+		OnComplete: func(prompt string, result *Result, err error, duration time.Duration) {
+			calls++
+			completeErr = err
+		},
+	})
+
+	_, err := client.ExecuteInDir("hello", filepath.Join(t.TempDir(), "missing"))
+
+	if !errors.Is(err, ErrWorkingDirNotFound) {
+		t.Fatalf("Expected ErrWorkingDirNotFound, got %v", err)
+	}
+	if calls != 1 || !errors.Is(completeErr, ErrWorkingDirNotFound) {
+		t.Errorf("Expected OnComplete to see the directory error once, got %d calls with %v", calls, completeErr)
+	}
+	if len(runner.invocations()) != 0 {
+		t.Errorf("Expected gemini not to run, got %d invocations", len(runner.invocations()))
+	}
+}
+
+// TestStrictWorkingDir tests failing fast on a missing working directory
+func TestStrictWorkingDir(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing")
+
+	tests := []struct {
+		name        string
+		config      Config
+		expectedErr error
+		expectRun   bool
+		description string
+	}{
+		{
+			name:        "Lazy",
+			config:      Config{WorkingDirectory: missing},
+			expectRun:   true,
+			description: "Without StrictWorkingDir gemini should still be started",
+		},
+		{
+			name:        "Strict",
+			config:      Config{WorkingDirectory: missing, StrictWorkingDir: true},
+			expectedErr: ErrWorkingDirNotFound,
+			description: "A missing directory should fail before gemini is started",
+		},
+		{
+			name:        "StrictExisting",
+			config:      Config{WorkingDirectory: t.TempDir(), StrictWorkingDir: true},
+			expectRun:   true,
+			description: "An existing directory should pass",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := &fakeRunner{}
+			tt.config.Runner = runner
+			client := NewClientWithConfig(tt.config)
+
+			_, err := client.Execute("hello")
+			streamErr := client.ExecuteTo("hello", io.Discard)
+			if tt.expectedErr != nil && (!errors.Is(err, tt.expectedErr) || !errors.Is(streamErr, tt.expectedErr)) {
+				t.Errorf("%s: expected %v, got %v and %v", tt.description, tt.expectedErr, err, streamErr)
+			}
+			if ran := len(runner.invocations()) > 0; ran != tt.expectRun {
+				t.Errorf("%s: expected gemini started %v, got %v", tt.description, tt.expectRun, ran)
+			}
+		})
+	}
+}
+
+// TestMaxPromptLength tests that oversized prompts fail before spawning gemini
+func TestMaxPromptLength(t *testing.T) {
+	tests := []struct {
+		name        string
+		prompt      string
+		maxLength   int
+		expectError bool
+		description string
+	}{
+		{
+			name:        "Unlimited",
+			prompt:      strings.Repeat("a", 10000),
+			maxLength:   0,
+			expectError: false,
+			description: "Zero limit should accept any prompt",
+		},
+		{
+			name:        "AtLimit",
+			prompt:      "hello",
+			maxLength:   5,
+			expectError: false,
+			description: "Prompt exactly at the limit should be accepted",
+		},
+		{
+			name:        "OverLimit",
+			prompt:      "hello!",
+			maxLength:   5,
+			expectError: true,
+			description: "Prompt over the limit should be rejected",
+		},
+		{
+			name:        "CountsBytesNotRunes",
+			prompt:      "こんにちは",
+			maxLength:   5,
+			expectError: true,
+			description: "Five multi-byte runes exceed a five byte limit",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := &fakeRunner{}
+			client := NewClientWithConfig(Config{MaxPromptLength: tt.maxLength, Runner: runner})
+
+			_, err := client.Execute(tt.prompt)
+
+			if tt.expectError {
+				if !errors.Is(err, ErrPromptTooLong) {
+					t.Errorf("%s: expected ErrPromptTooLong, got %v", tt.description, err)
+				}
+				if len(runner.invocations()) != 0 {
+					t.Errorf("%s: expected no process to be spawned", tt.description)
+				}
+			} else if err != nil {
+				t.Errorf("%s: unexpected error: %v", tt.description, err)
+			}
+		})
+	}
+}
+
+// TestPathResolutionOptions tests that path resolution is opt-in and configurable
+func TestPathResolutionOptions(t *testing.T) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get current directory: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		config      Config
+		prompt      string
+		expected    string
+		description string
+	}{
+		{
+			name:        "DisabledByDefault",
+			config:      Config{WorkingDirectory: "/tmp"},
+			prompt:      "Summarize notes.txt and ./main.go",
+			expected:    "Summarize notes.txt and ./main.go",
+			description: "Prompts should pass through untouched unless ResolvePaths is set",
+		},
+		{
+			name:        "Enabled",
+			config:      Config{WorkingDirectory: "/tmp", ResolvePaths: true},
+			prompt:      "Summarize notes.txt",
+			expected:    "Summarize " + filepath.Join(cwd, "notes.txt"),
+			description: "Bare file names should be resolved with the default extensions",
+		},
+		{
+			name:        "RequiresWorkingDirectory",
+			config:      Config{ResolvePaths: true},
+			prompt:      "Summarize notes.txt",
+			expected:    "Summarize notes.txt",
+			description: "Without a working directory gemini already runs in the current directory",
+		},
+		{
+			name:        "CustomExtensions",
+			config:      Config{WorkingDirectory: "/tmp", ResolvePaths: true, PathExtensions: []string{".go"}},
+			prompt:      "Compare notes.txt with main.go",
+			expected:    "Compare notes.txt with " + filepath.Join(cwd, "main.go"),
+			description: "Only the configured extensions should mark bare words as paths",
+		},
+		{
+			name:        "ExplicitPathsOnly",
+			config:      Config{WorkingDirectory: "/tmp", ResolvePaths: true, ExplicitPathsOnly: true},
+			prompt:      "Compare notes.txt with ./main.go",
+			expected:    "Compare notes.txt with " + filepath.Join(cwd, "main.go"),
+			description: "Bare words should be left alone when only explicit paths are resolved",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := &fakeRunner{}
+			tt.config.Runner = runner
+			client := NewClientWithConfig(tt.config)
+
+			if _, err := client.Execute(tt.prompt); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			args := runner.invocations()[0].Args
+			if got := args[len(args)-1]; got != tt.expected {
+				t.Errorf("%s: expected prompt '%s', got '%s'", tt.description, tt.expected, got)
+			}
+		})
+	}
+}
+
+// TestEnv tests passing custom environment variables to gemini
+func TestEnv(t *testing.T) {
+	installFakeGemini(t, `echo "key=$GEMINI_API_KEY project=$GOOGLE_CLOUD_PROJECT"`)
+	t.Setenv("GEMINI_API_KEY", "from-parent")
+	t.Setenv("GOOGLE_CLOUD_PROJECT", "parent-project")
+
+	tests := []struct {
+		name        string
+		env         map[string]string
+		expected    string
+		description string
+	}{
+		{
+			name:        "InheritsByDefault",
+			env:         nil,
+			expected:    "key=from-parent project=parent-project",
+			description: "Without Env the parent environment should be inherited unchanged",
+		},
+		{
+			name:        "ConfigWins",
+			env:         map[string]string{"GEMINI_API_KEY": "from-config"},
+			expected:    "key=from-config project=parent-project",
+			description: "Config.Env should override inherited values and keep the rest",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewClientWithConfig(Config{Env: tt.env})
+
+			result, err := client.Execute("hello")
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("%s: expected '%s', got '%s'", tt.description, tt.expected, result)
+			}
+		})
+	}
+}
+
+// TestConfigPath tests pointing each client at its own gemini settings file
+func TestConfigPath(t *testing.T) {
+	installFakeGemini(t, `echo "settings=$`+GeminiSettingsPathEnv+` key=$GEMINI_API_KEY"`)
+	dir := t.TempDir()
+	t.Chdir(dir)
+
+	tests := []struct {
+		name        string
+		config      Config
+		expected    string
+		description string
+	}{
+		{
+			name:        "Unset",
+			expected:    "settings= key=",
+			description: "Without ConfigPath the variable should not be set",
+		},
+		{
+			name:        "Absolute",
+			config:      Config{ConfigPath: "/etc/tenants/a/settings.json"},
+			expected:    "settings=/etc/tenants/a/settings.json key=",
+			description: "The path should be passed through the settings variable",
+		},
+		{
+			name:        "Relative",
+			config:      Config{ConfigPath: "tenant-b.json", WorkingDirectory: os.TempDir()},
+			expected:    "settings=" + filepath.Join(dir, "tenant-b.json") + " key=",
+			description: "A relative path should resolve against the caller's directory, not gemini's",
+		},
+		{
+			name:        "ComposesWithEnv",
+			config:      Config{ConfigPath: "/etc/tenants/c/settings.json", Env: map[string]string{"GEMINI_API_KEY": "tenant-c"}},
+			expected:    "settings=/etc/tenants/c/settings.json key=tenant-c",
+			description: "ConfigPath should be added alongside Config.Env",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(GeminiSettingsPathEnv, "")
+			t.Setenv("GEMINI_API_KEY", "")
+			result, err := NewClientWithConfig(tt.config).Execute("hello")
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("%s: expected '%s', got '%s'", tt.description, tt.expected, result)
+			}
+		})
+	}
+}
+
+// TestAPIKeyFile tests passing an API key read from a file to gemini
+func TestAPIKeyFile(t *testing.T) {
+	installFakeGemini(t, `echo "key=$GEMINI_API_KEY"`)
+	t.Setenv("GEMINI_API_KEY", "")
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "api-key")
+	if err := os.WriteFile(keyFile, []byte("  file-secret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	emptyFile := filepath.Join(dir, "empty")
+	if err := os.WriteFile(emptyFile, []byte("\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		config      Config
+		expected    string
+		expectError bool
+		description string
+	}{
+		{
+			name:        "Key",
+			config:      Config{APIKeyFile: keyFile},
+			expected:    "key=file-secret",
+			description: "The trimmed key should be passed as GEMINI_API_KEY",
+		},
+		{
+			name:        "OverridesEnv",
+			config:      Config{APIKeyFile: keyFile, Env: map[string]string{"GEMINI_API_KEY": "env-secret"}},
+			expected:    "key=file-secret",
+			description: "APIKeyFile should take precedence over Env",
+		},
+		{
+			name:        "Missing",
+			config:      Config{APIKeyFile: filepath.Join(dir, "missing")},
+			expectError: true,
+			description: "An unreadable file should fail every execution",
+		},
+		{
+			name:        "Empty",
+			config:      Config{APIKeyFile: emptyFile},
+			expectError: true,
+			description: "An empty file should fail every execution",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := NewRecordingLogger()
+			tt.config.Logger = logger
+			result, err := NewClientWithConfig(tt.config).Execute("hello")
+
+			if tt.expectError {
+				if !errors.Is(err, ErrInvalidConfig) {
+					t.Errorf("%s: expected ErrInvalidConfig, got %v", tt.description, err)
+				}
+				if _, err := NewClientWithConfigValidated(tt.config); !errors.Is(err, ErrInvalidConfig) {
+					t.Errorf("%s: expected validation to fail, got %v", tt.description, err)
+				}
+				return
+			}
+			if err != nil || result != tt.expected {
+				t.Errorf("%s: expected '%s', got '%s' (%v)", tt.description, tt.expected, result, err)
+			}
+			for _, e := range logger.Entries() {
+				if strings.Contains(fmt.Sprint(e.Msg, e.KV), "file-secret") {
+					t.Errorf("API key was logged: %+v", e)
+				}
+			}
+		})
+	}
+
+	client := NewClientWithConfig(Config{APIKeyFile: keyFile})
+	plan, err := client.Explain("hello")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if plan.Env["GEMINI_API_KEY"] == "file-secret" {
+		t.Error("Expected Explain to redact the API key")
+	}
+}
+
+// TestPreserveBlankLines tests filtering with and without internal blank lines
+func TestPreserveBlankLines(t *testing.T) {
+	output := "Loaded cached credentials.\n\ndef f():\n    return 1\n\n\ndef g():\n    return 2\n\n"
+
+	tests := []struct {
+		name           string
+		preserveBlanks bool
+		expected       string
+		description    string
+	}{
+		{
+			name:        "Default",
+			expected:    "def f():\n    return 1\ndef g():\n    return 2",
+			description: "Blank lines should be dropped by default",
+		},
+		{
+			name:           "Preserved",
+			preserveBlanks: true,
+			expected:       "def f():\n    return 1\n\n\ndef g():\n    return 2",
+			description:    "Internal blank lines should be kept and edges trimmed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewClientWithConfig(Config{PreserveBlankLines: tt.preserveBlanks})
+
+			result, err := client.parseGeminiOutput([]byte(output))
+			if err != nil {
+				t.Fatalf("%s: unexpected error: %v", tt.description, err)
+			}
+			if result != tt.expected {
+				t.Errorf("%s: expected %q, got %q", tt.description, tt.expected, result)
+			}
+		})
+	}
+}
+
+// TestStripPromptEcho tests removing an echoed prompt from the response
+func TestStripPromptEcho(t *testing.T) {
+	tests := []struct {
+		name        string
+		output      string
+		prompt      string
+		expected    string
+		description string
+	}{
+		{
+			name:        "ExactEcho",
+			output:      "What is Go?\nGo is a programming language.",
+			prompt:      "What is Go?",
+			expected:    "Go is a programming language.",
+			description: "An exact echo should be removed",
+		},
+		{
+			name:        "NormalizedEcho",
+			output:      "what  IS\ngo? Go is a programming language.",
+			prompt:      "What is Go?",
+			expected:    "Go is a programming language.",
+			description: "Whitespace and case differences should be tolerated",
+		},
+		{
+			name:        "NoEcho",
+			output:      "Go is a programming language.",
+			prompt:      "What is Go?",
+			expected:    "Go is a programming language.",
+			description: "Output not starting with the prompt should be unchanged",
+		},
+		{
+			name:        "PartialWord",
+			output:      "hiking is fun",
+			prompt:      "hi",
+			expected:    "hiking is fun",
+			description: "The echo should end at a word boundary",
+		},
+		{
+			name:        "OnlyEcho",
+			output:      "hello",
+			prompt:      "hello",
+			expected:    "hello",
+			description: "Output consisting only of the prompt should be kept",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := &fakeRunner{run: func(ctx context.Context, inv Invocation) ([]byte, []byte, error) {
+				return []byte(tt.output), nil, nil
+			}}
+			client := NewClientWithConfig(Config{StripPromptEcho: true, Runner: runner})
+
+			result, err := client.Execute(tt.prompt)
+			if err != nil {
+				t.Fatalf("%s: unexpected error: %v", tt.description, err)
+			}
+			if result != tt.expected {
+				t.Errorf("%s: expected %q, got %q", tt.description, tt.expected, result)
+			}
+		})
+	}
+
+	t.Run("Disabled", func(t *testing.T) {
+		runner := &fakeRunner{run: func(ctx context.Context, inv Invocation) ([]byte, []byte, error) {
+			return []byte("hello world"), nil, nil
+		}}
+		result, err := NewClientWithConfig(Config{Runner: runner}).Execute("hello")
+		if err != nil || result != "hello world" {
+			t.Errorf("Expected output to be unchanged by default, got %q (%v)", result, err)
+		}
+	})
+}
+
+// TestDryRun tests that dry runs return the command line without running gemini
+func TestDryRun(t *testing.T) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get current directory: %v", err)
+	}
+
+	runner := &fakeRunner{}
+	client := NewClientWithConfig(Config{
+		Model:            "gemini-2.5-pro",
+		WorkingDirectory: "/tmp",
+		ResolvePaths:     true,
+		ExtraArgs:        []string{"--yolo"},
+		DryRun:           true,
+		Runner:           runner,
+	})
+
+	result, err := client.Execute("Review ./main.go")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := "gemini -m gemini-2.5-pro --yolo -p " + shellQuote("Review "+filepath.Join(cwd, "main.go"))
+	if result != expected {
+		t.Errorf("Expected '%s', got '%s'", expected, result)
+	}
+	if len(runner.invocations()) != 0 {
+		t.Error("Expected no process to be spawned in dry run mode")
+	}
+}
+
+// TestOmitModelFlag tests that the model flag can be left to gemini's settings
+func TestOmitModelFlag(t *testing.T) {
+	tests := []struct {
+		name          string
+		omitModelFlag bool
+		model         string
+		expectedArgs  string
+		description   string
+	}{
+		{
+			name:         "Default",
+			expectedArgs: "-m gemini-2.5-pro -p hello",
+			description:  "The model flag should be passed by default",
+		},
+		{
+			name:          "Omitted",
+			omitModelFlag: true,
+			expectedArgs:  "-p hello",
+			description:   "OmitModelFlag should leave out the model flag",
+		},
+		{
+			name:          "ExplicitModel",
+			omitModelFlag: true,
+			model:         "gemini-2.5-flash",
+			expectedArgs:  "-m gemini-2.5-flash -p hello",
+			description:   "ExecuteWithModel should still pass the model it is given",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := &fakeRunner{}
+			client := NewClientWithConfig(Config{
+				Model:         "gemini-2.5-pro",
+				OmitModelFlag: tt.omitModelFlag,
+				Runner:        runner,
+			})
+
+			var err error
+			if tt.model != "" {
+				_, err = client.ExecuteWithModel("hello", tt.model)
+			} else {
+				_, err = client.Execute("hello")
+			}
+			if err != nil {
+				t.Fatalf("%s: unexpected error: %v", tt.description, err)
+			}
+
+			if args := strings.Join(runner.invocations()[0].Args, " "); args != tt.expectedArgs {
+				t.Errorf("%s: expected args '%s', got '%s'", tt.description, tt.expectedArgs, args)
+			}
+		})
+	}
+}
+
+// TestShellQuote tests POSIX shell quoting of command arguments
+func TestShellQuote(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"gemini", "gemini"},
+		{"--output-format", "--output-format"},
+		{"/path/to/file.go", "/path/to/file.go"},
+		{"", "''"},
+		{"hello world", "'hello world'"},
+		{"it's", `'it'\''s'`},
+		{"$HOME", "'$HOME'"},
+	}
+
+	for _, tt := range tests {
+		if got := shellQuote(tt.input); got != tt.expected {
+			t.Errorf("shellQuote(%q): expected %s, got %s", tt.input, tt.expected, got)
+		}
+	}
+}
+
+// TestReturnPartialOnTimeout tests salvaging output printed before a timeout
+func TestReturnPartialOnTimeout(t *testing.T) {
+	installFakeGemini(t, `echo "Loaded cached credentials."; echo "partial answer"; exec sleep 10`)
+
+	tests := []struct {
+		name           string
+		returnPartial  bool
+		expectedOutput string
+		description    string
+	}{
+		{
+			name:           "DisabledByDefault",
+			returnPartial:  false,
+			expectedOutput: "",
+			description:    "Partial output should be discarded unless opted in",
+		},
+		{
+			name:           "Enabled",
+			returnPartial:  true,
+			expectedOutput: "partial answer",
+			description:    "Filtered partial output should be returned with the timeout error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewClientWithConfig(Config{
+				Timeout:                200 * time.Millisecond,
+				GracePeriod:            -1,
+				RetryCount:             -1,
+				ReturnPartialOnTimeout: tt.returnPartial,
+			})
+
+			output, err := client.Execute("test prompt")
+
+			if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), ErrCommandTimeout) {
+				t.Errorf("%s: expected timeout error, got %v", tt.description, err)
+			}
+			if output != tt.expectedOutput {
+				t.Errorf("%s: expected output '%s', got '%s'", tt.description, tt.expectedOutput, output)
+			}
+		})
+	}
+}
+
+// TestTimeoutMessage tests replacing the timeout error text
+func TestTimeoutMessage(t *testing.T) {
+	runner := &fakeRunner{run: func(ctx context.Context, inv Invocation) ([]byte, []byte, error) {
+		<-ctx.Done()
+		return nil, nil, ctx.Err()
+	}}
+
+	tests := []struct {
+		name        string
+		config      Config
+		expected    string
+		unexpected  string
+		description string
+	}{
+		{
+			name:        "Default",
+			config:      Config{Timeout: 10 * time.Millisecond},
+			expected:    ErrCommandTimeout + " after 10ms",
+			description: "The default text should be kept",
+		},
+		{
+			name:        "Custom",
+			config:      Config{Timeout: 10 * time.Millisecond, TimeoutMessage: "Gemini took too long, try a shorter prompt"},
+			expected:    "Gemini took too long, try a shorter prompt",
+			unexpected:  ErrCommandTimeout,
+			description: "The custom message should replace the default",
+		},
+		{
+			name:        "TotalDeadline",
+			config:      Config{Timeout: time.Minute, TotalDeadline: 10 * time.Millisecond, TimeoutMessage: "Gemini took too long"},
+			expected:    "Gemini took too long",
+			unexpected:  "total deadline",
+			description: "The custom message should apply to TotalDeadline too",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.RetryCount = -1
+			tt.config.Runner = runner
+			_, err := NewClientWithConfig(tt.config).Execute("test prompt")
+
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Fatalf("%s: expected context.DeadlineExceeded, got %v", tt.description, err)
+			}
+			if !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("%s: expected %q in %q", tt.description, tt.expected, err)
+			}
+			if tt.unexpected != "" && strings.Contains(err.Error(), tt.unexpected) {
+				t.Errorf("%s: unexpected %q in %q", tt.description, tt.unexpected, err)
+			}
+		})
+	}
+}
+
+// TestResolveWindowsPaths tests resolution of backslash-separated paths when
+// Windows path handling is enabled
+func TestResolveWindowsPaths(t *testing.T) {
+	client := NewClient()
+	client.windowsPaths = true
+	client.pathPattern = buildPathPattern(DefaultPathExtensions(), false, true)
+
+	tests := []struct {
+		name     string
+		prompt   string
+		expected string
+	}{
+		{
+			name:     "DotBackslash",
+			prompt:   `Analyze .\main.go`,
+			expected: "Analyze " + filepath.Join("/project", "main.go"),
+		},
+		{
+			name:     "DotDotBackslash",
+			prompt:   `Check ..\config\app.json`,
+			expected: "Check " + filepath.Join("/", "config", "app.json"),
+		},
+		{
+			name:     "BareBackslashPath",
+			prompt:   `Review src\util.go`,
+			expected: "Review " + filepath.Join("/project", "src", "util.go"),
+		},
+		{
+			name:     "DriveLetterPreserved",
+			prompt:   `Analyze C:\src\main.go`,
+			expected: `Analyze C:\src\main.go`,
+		},
+		{
+			name:     "LeadingBackslashPreserved",
+			prompt:   `Analyze \src\main.go`,
+			expected: `Analyze \src\main.go`,
+		},
+		{
+			name:     "ForwardSlashStillWorks",
+			prompt:   "Analyze ./main.go",
+			expected: "Analyze " + filepath.Join("/project", "main.go"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := client.resolveRelativePaths(tt.prompt, "/project")
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("Expected '%s', got '%s'", tt.expected, result)
+			}
+		})
+	}
+}
+
+// TestSamplingParameters tests temperature and max output token flags
+func TestSamplingParameters(t *testing.T) {
+	floatPtr := func(v float64) *float64 { return &v }
+	intPtr := func(v int) *int { return &v }
+
+	tests := []struct {
+		name          string
+		config        Config
+		expectedFlags []string
+		expectedErr   error
+		description   string
+	}{
+		{
+			name:          "Unset",
+			config:        Config{},
+			expectedFlags: nil,
+			description:   "No flags should be passed when unset",
+		},
+		{
+			name:          "ZeroTemperature",
+			config:        Config{Temperature: floatPtr(0)},
+			expectedFlags: []string{GeminiTemperatureFlag, "0"},
+			description:   "A zero temperature should be passed, not treated as unset",
+		},
+		{
+			name:          "Both",
+			config:        Config{Temperature: floatPtr(0.7), MaxOutputTokens: intPtr(256)},
+			expectedFlags: []string{GeminiTemperatureFlag, "0.7", GeminiMaxOutputTokensFlag, "256"},
+			description:   "Both flags should be passed when set",
+		},
+		{
+			name:        "TemperatureTooHigh",
+			config:      Config{Temperature: floatPtr(2.5)},
+			expectedErr: ErrInvalidTemperature,
+			description: "Temperature above the range should be rejected",
+		},
+		{
+			name:        "NegativeTemperature",
+			config:      Config{Temperature: floatPtr(-0.1)},
+			expectedErr: ErrInvalidTemperature,
+			description: "Negative temperature should be rejected",
+		},
+		{
+			name:        "ZeroMaxOutputTokens",
+			config:      Config{MaxOutputTokens: intPtr(0)},
+			expectedErr: ErrInvalidMaxOutputTokens,
+			description: "Non-positive max output tokens should be rejected",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := &fakeRunner{}
+			tt.config.Runner = runner
+			client := NewClientWithConfig(tt.config)
+
+			_, err := client.Execute("hello")
+
+			if tt.expectedErr != nil {
+				if !errors.Is(err, tt.expectedErr) {
+					t.Errorf("%s: expected %v, got %v", tt.description, tt.expectedErr, err)
+				}
+				if len(runner.invocations()) != 0 {
+					t.Errorf("%s: expected no process to be spawned", tt.description)
+				}
+				if _, err := NewClientWithConfigValidated(tt.config); !errors.Is(err, ErrInvalidConfig) || !errors.Is(err, tt.expectedErr) {
+					t.Errorf("%s: expected validated constructor to reject config, got %v", tt.description, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("%s: unexpected error: %v", tt.description, err)
+			}
+
+			// Sampling flags sit between the model and the prompt flag
+			args := runner.invocations()[0].Args
+			flags := args[2 : len(args)-2]
+			if strings.Join(flags, " ") != strings.Join(tt.expectedFlags, " ") {
+				t.Errorf("%s: expected flags %v, got %v", tt.description, tt.expectedFlags, flags)
+			}
+		})
+	}
+}
+
+// TestConcurrentExecute tests sharing one client across many goroutines.
+// Run with -race to detect unsynchronized access to client state.
+func TestConcurrentExecute(t *testing.T) {
+	runner := &fakeRunner{run: func(ctx context.Context, inv Invocation) ([]byte, []byte, error) {
+		// Echo the model and prompt so each caller can check its own call
+		return []byte(inv.Args[1] + "|" + inv.Args[len(inv.Args)-1]), nil, nil
+	}}
+	client := NewClientWithConfig(Config{
+		Model:     "gemini-2.5-flash",
+		ExtraArgs: []string{"--yolo"},
+		Runner:    runner,
+	})
+
+	const goroutines = 100
+	errs := make(chan error, goroutines)
+	for i := 0; i < goroutines; i++ {
+		go func(i int) {
+			prompt := "prompt " + strconv.Itoa(i)
+			model := "gemini-2.5-flash"
+
+			var result string
+			var err error
+			switch i % 3 {
+			case 0:
+				result, err = client.Execute(prompt)
+			case 1:
+				model = "gemini-2.5-pro"
+				result, err = client.ExecuteWithModel(prompt, model)
+			case 2:
+				result, err = client.ExecuteWithTimeout(prompt, time.Minute)
+			}
+
+			if err == nil && result != model+"|"+prompt {
+				err = errors.New("goroutine " + strconv.Itoa(i) + " got '" + result + "'")
+			}
+			errs <- err
+		}(i)
+	}
+
+	for i := 0; i < goroutines; i++ {
+		if err := <-errs; err != nil {
+			t.Error(err)
+		}
+	}
+
+	if len(runner.invocations()) != goroutines {
+		t.Errorf("Expected %d invocations, got %d", goroutines, len(runner.invocations()))
+	}
+	if client.model != "gemini-2.5-flash" || client.timeout != DefaultTimeout {
+		t.Errorf("Expected client configuration to be unchanged, got model '%s' and timeout %v", client.model, client.timeout)
+	}
+}
@@ -0,0 +1,76 @@
+package geminicli
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Clock is the time source behind timeouts, TotalDeadline, StartupTimeout,
+// retry backoff, RetryBudget, the circuit breaker cooldown and the durations
+// reported in Result. Inject a fake with Config.Clock to trigger timeouts in
+// tests without waiting in real time.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+// realClock is the default Clock, backed by the time package
+type realClock struct{}
+
+// Now implements Clock
+func (realClock) Now() time.Time { return time.Now() }
+
+// After implements Clock
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// withTimeout derives a context that is done once timeout has elapsed on the
+// client's clock, or returns a cancellable ctx when timeout is not positive
+func (c *Client) withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	return c.withTimeoutCause(ctx, timeout, context.DeadlineExceeded)
+}
+
+// withTimeoutCause is withTimeout with a custom cancellation cause, which
+// should wrap context.DeadlineExceeded so contextErr reports a deadline.
+// The real clock uses a context deadline; other clocks cancel the context
+// when their After channel fires.
+func (c *Client) withTimeoutCause(ctx context.Context, timeout time.Duration, cause error) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	if _, ok := c.clock.(realClock); ok {
+		return context.WithTimeoutCause(ctx, timeout, cause)
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	expired := c.clock.After(timeout)
+	go func() {
+		select {
+		case <-expired:
+			cancel(cause)
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, func() { cancel(context.Canceled) }
+}
+
+// contextErr returns ctx.Err(), except that a context cancelled by a
+// Clock-driven timeout reports context.DeadlineExceeded like a real deadline
+func contextErr(ctx context.Context) error {
+	err := ctx.Err()
+	if err != nil && errors.Is(context.Cause(ctx), context.DeadlineExceeded) {
+		return context.DeadlineExceeded
+	}
+	return err
+}
+
+// sleep waits for d on the client's clock or until ctx is done, whichever
+// comes first
+func (c *Client) sleep(ctx context.Context, d time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return contextErr(ctx)
+	case <-c.clock.After(d):
+		return nil
+	}
+}
@@ -0,0 +1,158 @@
+package geminicli
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a manually advanced Clock for deterministic timeout tests
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []fakeWaiter
+}
+
+// fakeWaiter is a pending After call
+type fakeWaiter struct {
+	at time.Time
+	ch chan time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+// Now implements Clock
+func (f *fakeClock) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// After implements Clock
+func (f *fakeClock) After(d time.Duration) <-chan time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- f.now
+		return ch
+	}
+	f.waiters = append(f.waiters, fakeWaiter{at: f.now.Add(d), ch: ch})
+	return ch
+}
+
+// Advance moves the clock forward by d, firing every After that is due
+func (f *fakeClock) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.now = f.now.Add(d)
+	pending := f.waiters[:0]
+	for _, w := range f.waiters {
+		if w.at.After(f.now) {
+			pending = append(pending, w)
+		} else {
+			w.ch <- f.now
+		}
+	}
+	f.waiters = pending
+}
+
+// BlockUntil waits until n After calls are pending, so the code under test
+// has armed its timers before the clock is advanced
+func (f *fakeClock) BlockUntil(t *testing.T, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		f.mu.Lock()
+		pending := len(f.waiters)
+		f.mu.Unlock()
+		if pending >= n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("Timed out waiting for %d pending timers", n)
+}
+
+// blockingRunner returns a fakeRunner that runs until its context is done
+func blockingRunner() *fakeRunner {
+	return &fakeRunner{run: func(ctx context.Context, inv Invocation) ([]byte, []byte, error) {
+		<-ctx.Done()
+		return nil, nil, ctx.Err()
+	}}
+}
+
+// TestClockRetryBackoff tests that retry backoff waits on the client's clock
+func TestClockRetryBackoff(t *testing.T) {
+	clock := newFakeClock()
+	runner := &fakeRunner{run: func(ctx context.Context, inv Invocation) ([]byte, []byte, error) {
+		return nil, []byte("429 Too Many Requests"), errors.New("exit status 1")
+	}}
+	client := NewClientWithConfig(Config{
+		Clock:        clock,
+		RetryCount:   1,
+		RetryBackoff: time.Hour,
+		Runner:       runner,
+	})
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := client.Execute("test prompt")
+		done <- err
+	}()
+
+	// The first attempt's timeout and the backoff
+	clock.BlockUntil(t, 2)
+	if calls := len(runner.invocations()); calls != 1 {
+		t.Fatalf("Expected one attempt before the backoff elapsed, got %d", calls)
+	}
+	clock.Advance(time.Hour)
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, ErrRateLimited) {
+			t.Errorf("Expected ErrRateLimited after retries, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Execute did not return after the backoff elapsed")
+	}
+	if calls := len(runner.invocations()); calls != 2 {
+		t.Errorf("Expected a retry after the backoff, got %d attempts", calls)
+	}
+}
+
+// TestClockTotalDeadline tests that TotalDeadline follows the client's clock
+func TestClockTotalDeadline(t *testing.T) {
+	clock := newFakeClock()
+	client := NewClientWithConfig(Config{
+		Clock:         clock,
+		TotalDeadline: time.Minute,
+		Timeout:       time.Hour,
+		Runner:        blockingRunner(),
+	})
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := client.Execute("test prompt")
+		done <- err
+	}()
+
+	// The total deadline and the attempt's timeout
+	clock.BlockUntil(t, 2)
+	clock.Advance(time.Minute)
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Execute did not return after the total deadline")
+	}
+}
@@ -0,0 +1,52 @@
+package geminicli
+
+import (
+	"sync"
+	"time"
+)
+
+// Process-wide defaults read by NewClient
+var (
+	defaultsMu     sync.RWMutex
+	defaultModel   = DefaultModel
+	defaultTimeout = DefaultTimeout
+)
+
+// SetDefaultModel changes the model used by clients created afterwards with
+// NewClient and by the package-level convenience functions. An empty model
+// restores DefaultModel. Existing clients keep their model.
+func SetDefaultModel(model string) {
+	if model == "" {
+		model = DefaultModel
+	}
+	defaultsMu.Lock()
+	defer defaultsMu.Unlock()
+	defaultModel = model
+}
+
+// GetDefaultModel returns the model new clients use when none is configured
+func GetDefaultModel() string {
+	defaultsMu.RLock()
+	defer defaultsMu.RUnlock()
+	return defaultModel
+}
+
+// SetDefaultTimeout changes the timeout used by clients created afterwards
+// with NewClient and by the package-level convenience functions.
+// Non-positive values are ignored. Existing clients keep their timeout.
+func SetDefaultTimeout(timeout time.Duration) {
+	if timeout <= 0 {
+		return
+	}
+	defaultsMu.Lock()
+	defer defaultsMu.Unlock()
+	defaultTimeout = timeout
+}
+
+// GetDefaultTimeout returns the timeout new clients use when none is
+// configured
+func GetDefaultTimeout() time.Duration {
+	defaultsMu.RLock()
+	defer defaultsMu.RUnlock()
+	return defaultTimeout
+}
@@ -0,0 +1,89 @@
+package geminicli
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestSetDefaultModel tests the process-wide default model
+func TestSetDefaultModel(t *testing.T) {
+	t.Cleanup(func() { SetDefaultModel("") })
+
+	if got := GetDefaultModel(); got != DefaultModel {
+		t.Fatalf("Expected initial default '%s', got '%s'", DefaultModel, got)
+	}
+
+	existing := NewClient()
+	SetDefaultModel("gemini-2.5-pro")
+
+	if got := GetDefaultModel(); got != "gemini-2.5-pro" {
+		t.Errorf("Expected default 'gemini-2.5-pro', got '%s'", got)
+	}
+	if client := NewClient(); client.model != "gemini-2.5-pro" {
+		t.Errorf("Expected new client to use 'gemini-2.5-pro', got '%s'", client.model)
+	}
+	if client := NewClient(WithModel("custom")); client.model != "custom" {
+		t.Errorf("Expected explicit model to win, got '%s'", client.model)
+	}
+	if existing.model != DefaultModel {
+		t.Errorf("Expected existing client to keep '%s', got '%s'", DefaultModel, existing.model)
+	}
+
+	SetDefaultModel("")
+	if got := GetDefaultModel(); got != DefaultModel {
+		t.Errorf("Expected empty model to restore '%s', got '%s'", DefaultModel, got)
+	}
+}
+
+// TestSetDefaultModelConcurrent tests that the default model can be changed
+// while clients are being created
+func TestSetDefaultModelConcurrent(t *testing.T) {
+	t.Cleanup(func() { SetDefaultModel("") })
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			SetDefaultModel("gemini-2.5-pro")
+		}()
+		go func() {
+			defer wg.Done()
+			NewClient()
+		}()
+	}
+	wg.Wait()
+}
+
+// TestSetDefaultTimeout tests the process-wide default timeout
+func TestSetDefaultTimeout(t *testing.T) {
+	t.Cleanup(func() { SetDefaultTimeout(DefaultTimeout) })
+
+	if got := GetDefaultTimeout(); got != DefaultTimeout {
+		t.Fatalf("Expected initial default %v, got %v", DefaultTimeout, got)
+	}
+
+	existing := NewClient()
+	SetDefaultTimeout(5 * time.Minute)
+
+	if got := GetDefaultTimeout(); got != 5*time.Minute {
+		t.Errorf("Expected default 5m, got %v", got)
+	}
+	if client := NewClient(); client.timeout != 5*time.Minute {
+		t.Errorf("Expected new client to use 5m, got %v", client.timeout)
+	}
+	if client := NewClientWithConfig(Config{Timeout: time.Second}); client.timeout != time.Second {
+		t.Errorf("Expected explicit timeout to win, got %v", client.timeout)
+	}
+	if existing.timeout != DefaultTimeout {
+		t.Errorf("Expected existing client to keep %v, got %v", DefaultTimeout, existing.timeout)
+	}
+
+	for _, timeout := range []time.Duration{0, -time.Second} {
+		SetDefaultTimeout(timeout)
+		if got := GetDefaultTimeout(); got != 5*time.Minute {
+			t.Errorf("Expected %v to be ignored, got default %v", timeout, got)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package geminicli
+
+import (
+	"regexp"
+	"strings"
+)
+
+// warningPattern matches filtered lines worth surfacing as diagnostics, such
+// as warnings and deprecation notices
+var warningPattern = regexp.MustCompile(`(?i)\b(?:warn(?:ing)?|deprecat\w*|caution|notice)\b`)
+
+// debugLinePattern matches the diagnostic lines gemini prints with --debug
+var debugLinePattern = regexp.MustCompile(`^\[DEBUG\]`)
+
+// ExecuteWithDiagnostics executes a Gemini command like Execute and also
+// returns the warning-like lines, such as deprecation notices, that output
+// filtering removed from the response. Diagnostics are returned alongside
+// partial output when the call fails.
+func (c *Client) ExecuteWithDiagnostics(prompt string) (string, []string, error) {
+	result, err := c.ExecuteResult(prompt)
+	if result == nil {
+		return "", nil, err
+	}
+	return result.Text, c.diagnostics(result.RawOutput), err
+}
+
+// diagnostics returns the trimmed output lines that shouldFilterLine drops
+// and that look like warnings
+func (c *Client) diagnostics(output []byte) []string {
+	var diags []string
+	for _, line := range strings.Split(string(output), "\n") {
+		if c.stripANSI {
+			line = StripANSI(line)
+		}
+		trimmed := strings.TrimSpace(line)
+		if trimmed != "" && c.shouldFilterLine(line) && warningPattern.MatchString(trimmed) {
+			diags = append(diags, trimmed)
+		}
+	}
+	return diags
+}
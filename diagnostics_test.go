@@ -0,0 +1,86 @@
+package geminicli
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// TestExecuteWithDiagnostics tests collecting warning-like filtered lines
+func TestExecuteWithDiagnostics(t *testing.T) {
+	tests := []struct {
+		name          string
+		output        string
+		config        Config
+		expectedText  string
+		expectedDiags []string
+		description   string
+	}{
+		{
+			name:         "NoWarnings",
+			output:       "Loaded cached credentials.\nGo is a language.",
+			expectedText: "Go is a language.",
+			description:  "Filtered system messages that are not warnings should not be reported",
+		},
+		{
+			name:          "FilteredWarnings",
+			output:        "[WARN] Skipping unreadable directory\nDeprecationWarning: --foo is deprecated\nGo is a language.",
+			config:        Config{FilterPatterns: []string{"[WARN]", "DeprecationWarning"}},
+			expectedText:  "Go is a language.",
+			expectedDiags: []string{"[WARN] Skipping unreadable directory", "DeprecationWarning: --foo is deprecated"},
+			description:   "Warnings removed by filter patterns should be reported in order",
+		},
+		{
+			name:          "RegexpFiltered",
+			output:        "  Notice: model will be retired soon  \nGo is a language.",
+			config:        Config{FilterRegexps: []*regexp.Regexp{regexp.MustCompile(`^Notice:`)}},
+			expectedText:  "Go is a language.",
+			expectedDiags: []string{"Notice: model will be retired soon"},
+			description:   "Lines removed by filter regexps should be trimmed and reported",
+		},
+		{
+			name:         "UnfilteredWarning",
+			output:       "Warning: this code has a race condition.",
+			expectedText: "Warning: this code has a race condition.",
+			description:  "Warnings that remain in the response should not be duplicated as diagnostics",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.Runner = &fakeRunner{run: func(ctx context.Context, inv Invocation) ([]byte, []byte, error) {
+				return []byte(tt.output), nil, nil
+			}}
+			client := NewClientWithConfig(tt.config)
+
+			text, diags, err := client.ExecuteWithDiagnostics("test prompt")
+			if err != nil {
+				t.Fatalf("%s: unexpected error: %v", tt.description, err)
+			}
+			if text != tt.expectedText {
+				t.Errorf("%s: expected text %q, got %q", tt.description, tt.expectedText, text)
+			}
+			if strings.Join(diags, "|") != strings.Join(tt.expectedDiags, "|") {
+				t.Errorf("%s: expected diagnostics %q, got %q", tt.description, tt.expectedDiags, diags)
+			}
+		})
+	}
+}
+
+// TestExecuteWithDiagnosticsError tests that failed calls return no diagnostics
+func TestExecuteWithDiagnosticsError(t *testing.T) {
+	runner := &fakeRunner{run: func(ctx context.Context, inv Invocation) ([]byte, []byte, error) {
+		return []byte("Warning: oops"), nil, errors.New("exit status 1")
+	}}
+	client := NewClientWithConfig(Config{FilterPatterns: []string{"Warning"}, Runner: runner})
+
+	text, diags, err := client.ExecuteWithDiagnostics("test prompt")
+	if err == nil {
+		t.Fatal("Expected an error, got none")
+	}
+	if text != "" || diags != nil {
+		t.Errorf("Expected no text or diagnostics, got %q and %q", text, diags)
+	}
+}
@@ -0,0 +1,226 @@
+package geminicli
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"unicode/utf8"
+)
+
+// Sentinel errors that can be matched with errors.Is
+var (
+	// ErrAuthentication indicates that gemini rejected the configured credentials
+	ErrAuthentication = errors.New(ErrAuthFailed)
+
+	// ErrRateLimited indicates that gemini hit a rate limit or exhausted its quota
+	ErrRateLimited = errors.New("rate limit exceeded")
+
+	// ErrModelNotFound indicates that gemini does not know the requested
+	// model, typically because of a typo in its name
+	ErrModelNotFound = errors.New("model not found")
+
+	// ErrModelUnavailable indicates that the requested model is overloaded or
+	// not available
+	ErrModelUnavailable = errors.New("model unavailable")
+
+	// ErrPromptTooLong indicates that a prompt exceeded Config.MaxPromptLength
+	ErrPromptTooLong = errors.New("prompt too long")
+
+	// ErrGeminiNotFound indicates that the gemini binary is not on PATH
+	ErrGeminiNotFound = errors.New(ErrCommandNotFound)
+
+	// ErrGeminiNotRunnable indicates that the gemini binary was found but
+	// could not be executed successfully, e.g. a broken install or a binary
+	// built for another architecture
+	ErrGeminiNotRunnable = errors.New("gemini found but failed to execute")
+
+	// ErrVersionTooOld indicates that the installed gemini is older than
+	// Config.MinVersion
+	ErrVersionTooOld = errors.New("gemini version too old")
+
+	// ErrNoOutput indicates that gemini's response was empty once system
+	// messages were filtered out
+	ErrNoOutput = errors.New(ErrEmptyOutput)
+
+	// ErrNoJSON indicates that ExtractJSON found no valid JSON in a response
+	ErrNoJSON = errors.New("no valid JSON found in response")
+
+	// ErrTokenCountUnavailable indicates that gemini's output carried no
+	// token statistics
+	ErrTokenCountUnavailable = errors.New("token count unavailable")
+
+	// ErrInvalidConfig indicates that NewClientWithConfigValidated rejected
+	// a Config
+	ErrInvalidConfig = errors.New("invalid configuration")
+
+	// ErrInvalidTemperature indicates a temperature outside
+	// [MinTemperature, MaxTemperature]
+	ErrInvalidTemperature = errors.New("invalid temperature")
+
+	// ErrInvalidMaxOutputTokens indicates a non-positive MaxOutputTokens
+	ErrInvalidMaxOutputTokens = errors.New("invalid max output tokens")
+
+	// ErrStartupTimeout indicates that gemini produced no output within
+	// Config.StartupTimeout and was killed
+	ErrStartupTimeout = errors.New("no output before startup timeout")
+
+	// ErrFileReferenceNotFound indicates that an @path reference in a prompt
+	// points to a file that does not exist
+	ErrFileReferenceNotFound = errors.New("file reference not found")
+
+	// ErrWorkingDirNotFound indicates that the directory gemini should run in
+	// does not exist or is not a directory
+	ErrWorkingDirNotFound = errors.New("working directory not found")
+
+	// ErrNoWorkingDir indicates that no WorkingDirectory was set and neither
+	// the current directory, the home directory nor FallbackDirectory
+	// could be used
+	ErrNoWorkingDir = errors.New("no working directory available")
+
+	// ErrOutputTooLarge indicates that gemini wrote more than
+	// Config.MaxOutputBytes and was killed
+	ErrOutputTooLarge = errors.New("output too large")
+
+	// ErrUnsupported indicates that the installed gemini CLI does not
+	// support the requested operation
+	ErrUnsupported = errors.New("not supported by the installed gemini")
+
+	// ErrRetryBudgetExhausted indicates that a failed attempt was not
+	// retried because Config.RetryBudget was spent
+	ErrRetryBudgetExhausted = errors.New("retry budget exhausted")
+
+	// ErrCircuitOpen indicates that the circuit breaker rejected a request
+	// without running gemini
+	ErrCircuitOpen = errors.New("circuit breaker open")
+)
+
+// AuthError is returned when gemini output indicates an authentication
+// failure. It carries the raw process output and unwraps to ErrAuthentication.
+type AuthError struct {
+	ExitCode int    // Process exit code, or -1 if it could not be determined
+	Stdout   string // Raw standard output of the failed command
+	Stderr   string // Raw standard error of the failed command
+}
+
+// Error implements the error interface
+func (e *AuthError) Error() string {
+	return ErrAuthFailed
+}
+
+// Unwrap allows errors.Is(err, ErrAuthentication)
+func (e *AuthError) Unwrap() error {
+	return ErrAuthentication
+}
+
+// OutputTooLargeError is returned when gemini's output exceeds
+// Config.MaxOutputBytes. It carries the output read up to the limit and
+// unwraps to ErrOutputTooLarge.
+type OutputTooLargeError struct {
+	Limit  int    // Configured maximum output size in bytes
+	Output string // Output truncated to Limit bytes
+}
+
+// Error implements the error interface
+func (e *OutputTooLargeError) Error() string {
+	return fmt.Sprintf("%s: exceeded limit of %d bytes", ErrOutputTooLarge, e.Limit)
+}
+
+// Unwrap allows errors.Is(err, ErrOutputTooLarge)
+func (e *OutputTooLargeError) Unwrap() error {
+	return ErrOutputTooLarge
+}
+
+// CommandError is returned when the gemini process runs but exits
+// unsuccessfully. Use errors.As to inspect the captured output.
+type CommandError struct {
+	ExitCode int      // Process exit code, or -1 if it could not be determined
+	Stdout   string   // Raw standard output of the failed command
+	Stderr   string   // Raw standard error of the failed command
+	Err      error    // Underlying error reported by the process
+	Command  []string // Exact argv that was run, including the resolved prompt
+	Prompt   string   // Prompt sent, truncated to MaxErrorPromptLength; set only with IncludePromptInErrors
+}
+
+// newCommandError builds a CommandError from a failed process
+func newCommandError(err error, stdout, stderr []byte, command []string) *CommandError {
+	return &CommandError{
+		ExitCode: processExitCode(err),
+		Stdout:   string(stdout),
+		Stderr:   string(stderr),
+		Err:      err,
+		Command:  command,
+	}
+}
+
+// Error implements the error interface with a human-readable summary of the
+// failure and its output
+func (e *CommandError) Error() string {
+	errorMsg := fmt.Sprintf("command failed: %v", e.Err)
+	if stderr := strings.TrimSpace(e.Stderr); stderr != "" {
+		errorMsg += fmt.Sprintf(" | stderr: %s", stderr)
+	}
+	if stdout := strings.TrimSpace(e.Stdout); stdout != "" {
+		errorMsg += fmt.Sprintf(" | stdout: %s", stdout)
+	}
+	if e.Prompt != "" {
+		errorMsg += fmt.Sprintf(" | prompt: %q", e.Prompt)
+	}
+	return errorMsg
+}
+
+// MaxErrorPromptLength is the number of bytes of the prompt kept in
+// CommandError.Prompt with IncludePromptInErrors
+const MaxErrorPromptLength = 200
+
+// attachPrompt records prompt in the *CommandError carried by err, if any,
+// when IncludePromptInErrors is set. Prompts longer than
+// MaxErrorPromptLength are cut at a character boundary and marked with "...".
+func (c *Client) attachPrompt(err error, prompt string) {
+	var cmdErr *CommandError
+	if !c.includePromptInErrors || !errors.As(err, &cmdErr) {
+		return
+	}
+	if len(prompt) > MaxErrorPromptLength {
+		cut := MaxErrorPromptLength
+		for cut > 0 && !utf8.RuneStart(prompt[cut]) {
+			cut--
+		}
+		prompt = prompt[:cut] + "..."
+	}
+	cmdErr.Prompt = prompt
+}
+
+// Unwrap returns the underlying process error
+func (e *CommandError) Unwrap() error {
+	return e.Err
+}
+
+// processExitCode extracts the exit status from a process error. It is -1
+// when the process was killed by a signal or never reported a status.
+func processExitCode(err error) int {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
+
+// ExitCode returns the gemini exit code carried by an error returned from
+// the client: 0 for nil, the process status for *CommandError and *AuthError,
+// and -1 for anything else, including timeouts, cancellations and processes
+// killed by a signal
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var cmdErr *CommandError
+	if errors.As(err, &cmdErr) {
+		return cmdErr.ExitCode
+	}
+	var authErr *AuthError
+	if errors.As(err, &authErr) {
+		return authErr.ExitCode
+	}
+	return -1
+}
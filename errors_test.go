@@ -0,0 +1,349 @@
+package geminicli
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestAuthenticationError tests typed authentication failures
+func TestAuthenticationError(t *testing.T) {
+	installFakeGemini(t, "echo 'Error: invalid API key' >&2; exit 1")
+
+	client := NewClient()
+	_, err := client.Execute("test prompt")
+
+	if !errors.Is(err, ErrAuthentication) {
+		t.Fatalf("Expected ErrAuthentication, got: %v", err)
+	}
+
+	var authErr *AuthError
+	if !errors.As(err, &authErr) {
+		t.Fatalf("Expected *AuthError, got: %T", err)
+	}
+	if !strings.Contains(authErr.Stderr, "invalid API key") {
+		t.Errorf("Expected raw stderr to be preserved, got '%s'", authErr.Stderr)
+	}
+	if !strings.Contains(err.Error(), ErrAuthFailed) {
+		t.Errorf("Expected error message to contain '%s', got '%s'", ErrAuthFailed, err.Error())
+	}
+}
+
+// TestCommandError tests structured command failure details
+func TestCommandError(t *testing.T) {
+	installFakeGemini(t, "echo 'partial answer'; echo 'backend exploded' >&2; exit 3")
+
+	client := NewClientWithConfig(Config{RetryCount: -1})
+	_, err := client.Execute("test prompt")
+
+	var cmdErr *CommandError
+	if !errors.As(err, &cmdErr) {
+		t.Fatalf("Expected *CommandError, got: %v", err)
+	}
+
+	if cmdErr.ExitCode != 3 {
+		t.Errorf("Expected exit code 3, got %d", cmdErr.ExitCode)
+	}
+	if strings.TrimSpace(cmdErr.Stderr) != "backend exploded" {
+		t.Errorf("Expected stderr 'backend exploded', got '%s'", cmdErr.Stderr)
+	}
+	if strings.TrimSpace(cmdErr.Stdout) != "partial answer" {
+		t.Errorf("Expected stdout 'partial answer', got '%s'", cmdErr.Stdout)
+	}
+
+	expected := "command failed: exit status 3 | stderr: backend exploded | stdout: partial answer"
+	if cmdErr.Error() != expected {
+		t.Errorf("Expected message '%s', got '%s'", expected, cmdErr.Error())
+	}
+	if !strings.Contains(err.Error(), ErrCommandFailed) {
+		t.Errorf("Expected wrapped error to contain '%s', got '%s'", ErrCommandFailed, err.Error())
+	}
+}
+
+// TestDetectRateLimitError tests rate-limit and quota error detection
+func TestDetectRateLimitError(t *testing.T) {
+	tests := []struct {
+		name        string
+		output      []byte
+		expectLimit bool
+		description string
+	}{
+		{
+			name:        "NormalOutput",
+			output:      []byte("Normal Gemini response"),
+			expectLimit: false,
+			description: "Should not detect rate limit in normal output",
+		},
+		{
+			name:        "HTTP429",
+			output:      []byte("Error: request failed with status 429"),
+			expectLimit: true,
+			description: "Should detect HTTP 429 responses",
+		},
+		{
+			name:        "QuotaExceeded",
+			output:      []byte("Quota exceeded for quota metric 'Generate Content API requests'"),
+			expectLimit: true,
+			description: "Should detect quota exhaustion",
+		},
+		{
+			name:        "ResourceExhausted",
+			output:      []byte(`{"error":{"status":"RESOURCE_EXHAUSTED"}}`),
+			expectLimit: true,
+			description: "Should detect RESOURCE_EXHAUSTED status",
+		},
+		{
+			name:        "RateLimitExceeded",
+			output:      []byte("rate limit exceeded, retry later"),
+			expectLimit: true,
+			description: "Should detect rate limit messages",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := DetectRateLimitError(tt.output); result != tt.expectLimit {
+				t.Errorf("Expected %v, got %v for test case '%s'", tt.expectLimit, result, tt.name)
+			}
+		})
+	}
+}
+
+// TestRateLimitedError tests that quota failures are matchable with errors.Is
+func TestRateLimitedError(t *testing.T) {
+	installFakeGemini(t, "echo 'RESOURCE_EXHAUSTED: quota exceeded' >&2; exit 1")
+
+	client := NewClientWithConfig(Config{RetryCount: -1})
+	_, err := client.Execute("test prompt")
+
+	if !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("Expected ErrRateLimited, got: %v", err)
+	}
+	if errors.Is(err, ErrAuthentication) {
+		t.Error("Rate limit error should not match ErrAuthentication")
+	}
+
+	var cmdErr *CommandError
+	if !errors.As(err, &cmdErr) || cmdErr.ExitCode != 1 {
+		t.Errorf("Expected wrapped *CommandError with exit code 1, got: %v", err)
+	}
+}
+
+// TestDetectModelError tests detection of unknown model names
+func TestDetectModelError(t *testing.T) {
+	tests := []struct {
+		name        string
+		output      []byte
+		expectModel bool
+		description string
+	}{
+		{
+			name:        "NormalOutput",
+			output:      []byte("Normal Gemini response"),
+			expectModel: false,
+			description: "Should not detect a model error in normal output",
+		},
+		{
+			name:        "UnknownModel",
+			output:      []byte("Error: Unknown model 'gemini-2.5-prp'"),
+			expectModel: true,
+			description: "Should detect unknown model messages",
+		},
+		{
+			name:        "APINotFound",
+			output:      []byte("[404 Not Found] models/gemini-9 is not found for API version v1beta"),
+			expectModel: true,
+			description: "Should detect the API's not-found message",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := DetectModelError(tt.output); result != tt.expectModel {
+				t.Errorf("%s: expected %v, got %v", tt.description, tt.expectModel, result)
+			}
+		})
+	}
+}
+
+// TestModelNotFoundError tests that unknown models are matchable and not retried
+func TestModelNotFoundError(t *testing.T) {
+	counterFile := filepath.Join(t.TempDir(), "count")
+	installFakeGemini(t, countingScript(counterFile, 9, "Error: invalid model gemini-2.5-prp"))
+
+	client := NewClientWithConfig(Config{Model: "gemini-2.5-prp", RetryBackoff: time.Millisecond})
+	_, err := client.Execute("test prompt")
+
+	if !errors.Is(err, ErrModelNotFound) {
+		t.Fatalf("Expected ErrModelNotFound, got: %v", err)
+	}
+	var cmdErr *CommandError
+	if !errors.As(err, &cmdErr) || !strings.Contains(cmdErr.Stderr, "gemini-2.5-prp") {
+		t.Errorf("Expected wrapped *CommandError with the raw stderr, got: %v", err)
+	}
+	if calls := readCounter(t, counterFile); calls != "1" {
+		t.Errorf("Expected model errors not to be retried, got %s invocations", calls)
+	}
+}
+
+// TestCommandErrorCommand tests that the resolved argv is exposed on success and failure
+func TestCommandErrorCommand(t *testing.T) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get current directory: %v", err)
+	}
+	expectedPrompt := "Review " + filepath.Join(cwd, "main.go")
+
+	newClient := func(runErr error) *Client {
+		runner := &fakeRunner{run: func(ctx context.Context, inv Invocation) ([]byte, []byte, error) {
+			return []byte("output"), nil, runErr
+		}}
+		return NewClientWithConfig(Config{
+			WorkingDirectory: "/tmp",
+			ResolvePaths:     true,
+			RetryCount:       -1,
+			Runner:           runner,
+		})
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		result, err := newClient(nil).ExecuteResult("Review ./main.go")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if prompt := result.Command[len(result.Command)-1]; prompt != expectedPrompt {
+			t.Errorf("Expected resolved prompt '%s' in Result.Command, got '%s'", expectedPrompt, prompt)
+		}
+	})
+
+	t.Run("Failure", func(t *testing.T) {
+		_, err := newClient(errors.New("exit status 1")).Execute("Review ./main.go")
+
+		var cmdErr *CommandError
+		if !errors.As(err, &cmdErr) {
+			t.Fatalf("Expected *CommandError, got %T: %v", err, err)
+		}
+		if cmdErr.Command[0] != GeminiCommand {
+			t.Errorf("Expected command to start with '%s', got %v", GeminiCommand, cmdErr.Command)
+		}
+		if prompt := cmdErr.Command[len(cmdErr.Command)-1]; prompt != expectedPrompt {
+			t.Errorf("Expected resolved prompt '%s' in CommandError.Command, got '%s'", expectedPrompt, prompt)
+		}
+	})
+}
+
+// TestExitCode tests exit code extraction from client errors
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name        string
+		script      string
+		timeout     time.Duration
+		expected    int
+		description string
+	}{
+		{
+			name:        "Success",
+			script:      "echo ok",
+			expected:    0,
+			description: "A successful call should report exit code 0",
+		},
+		{
+			name:        "GeneralError",
+			script:      "echo 'backend exploded' >&2; exit 42",
+			expected:    42,
+			description: "The process exit status should be reported",
+		},
+		{
+			name:        "AuthError",
+			script:      "echo 'Error: invalid API key' >&2; exit 41",
+			expected:    41,
+			description: "Authentication failures should carry the exit status",
+		},
+		{
+			name:        "Signal",
+			script:      "kill -KILL $$",
+			expected:    -1,
+			description: "A process killed by a signal should report -1",
+		},
+		{
+			name:        "Timeout",
+			script:      "sleep 5",
+			timeout:     50 * time.Millisecond,
+			expected:    -1,
+			description: "A timed-out process should report -1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			installFakeGemini(t, tt.script)
+
+			client := NewClientWithConfig(Config{RetryCount: -1, Timeout: tt.timeout, GracePeriod: 10 * time.Millisecond})
+			_, err := client.Execute("test prompt")
+
+			if got := ExitCode(err); got != tt.expected {
+				t.Errorf("%s: expected %d, got %d (err: %v)", tt.description, tt.expected, got, err)
+			}
+		})
+	}
+}
+
+// TestIncludePromptInErrors tests recording the prompt in CommandError
+func TestIncludePromptInErrors(t *testing.T) {
+	installFakeGemini(t, "echo 'backend exploded' >&2; exit 3")
+
+	long := strings.Repeat("é", MaxErrorPromptLength)
+	tests := []struct {
+		name        string
+		config      Config
+		prompt      string
+		expected    string
+		description string
+	}{
+		{
+			name:        "default",
+			config:      Config{RetryCount: -1},
+			prompt:      "secret prompt",
+			expected:    "",
+			description: "Prompt should not be recorded by default",
+		},
+		{
+			name:        "enabled",
+			config:      Config{RetryCount: -1, IncludePromptInErrors: true},
+			prompt:      "secret prompt",
+			expected:    "secret prompt",
+			description: "Prompt should be recorded when enabled",
+		},
+		{
+			name:        "truncated",
+			config:      Config{RetryCount: -1, IncludePromptInErrors: true},
+			prompt:      long,
+			expected:    long[:MaxErrorPromptLength] + "...",
+			description: "Long prompt should be cut at a character boundary",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewClientWithConfig(tt.config).Execute(tt.prompt)
+
+			var cmdErr *CommandError
+			if !errors.As(err, &cmdErr) {
+				t.Fatalf("Expected *CommandError, got: %v", err)
+			}
+			if cmdErr.Prompt != tt.expected {
+				t.Errorf("%s: expected prompt %q, got %q", tt.description, tt.expected, cmdErr.Prompt)
+			}
+			if tt.expected == "" && strings.Contains(err.Error(), tt.prompt) {
+				t.Errorf("%s: error message leaks prompt: %v", tt.description, err)
+			}
+			if tt.expected != "" && !strings.Contains(err.Error(), "prompt: ") {
+				t.Errorf("%s: expected prompt in error message, got: %v", tt.description, err)
+			}
+		})
+	}
+}
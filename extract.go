@@ -0,0 +1,113 @@
+package geminicli
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+// CodeBlock is a fenced code block found in a model response
+type CodeBlock struct {
+	Language string // Info string language, e.g. "go"; empty when not specified
+	Content  string // Code inside the fences, with the fence indentation removed
+}
+
+// ExtractCodeBlocks returns every triple-backtick fenced code block in a
+// model response, in order. Fences may be indented, e.g. inside list items;
+// the fence's indentation is removed from each content line. An unclosed
+// fence runs to the end of the response.
+func ExtractCodeBlocks(response string) []CodeBlock {
+	var blocks []CodeBlock
+	var current *CodeBlock
+	var content []string
+	var indent, fenceLen int
+
+	for _, line := range strings.Split(strings.ReplaceAll(response, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimLeft(line, " \t")
+
+		if current == nil {
+			if n := fenceLength(trimmed); n > 0 {
+				current = &CodeBlock{}
+				if fields := strings.Fields(trimmed[n:]); len(fields) > 0 {
+					current.Language = fields[0]
+				}
+				indent = len(line) - len(trimmed)
+				fenceLen = n
+				content = nil
+			}
+			continue
+		}
+
+		if n := fenceLength(trimmed); n >= fenceLen && strings.TrimSpace(trimmed[n:]) == "" {
+			current.Content = strings.Join(content, "\n")
+			blocks = append(blocks, *current)
+			current = nil
+			continue
+		}
+		content = append(content, trimIndent(line, indent))
+	}
+
+	if current != nil {
+		current.Content = strings.Join(content, "\n")
+		blocks = append(blocks, *current)
+	}
+	return blocks
+}
+
+// fenceLength returns the number of leading backticks in line when they form
+// a code fence (three or more), or 0 otherwise
+func fenceLength(line string) int {
+	n := 0
+	for n < len(line) && line[n] == '`' {
+		n++
+	}
+	if n < 3 || strings.Contains(line[n:], "`") {
+		return 0
+	}
+	return n
+}
+
+// trimIndent removes up to width leading spaces or tabs from line
+func trimIndent(line string, width int) string {
+	i := 0
+	for i < width && i < len(line) && (line[i] == ' ' || line[i] == '\t') {
+		i++
+	}
+	return line[i:]
+}
+
+// ExtractJSON returns the first valid JSON object or array in a model
+// response. Fenced code blocks are searched first, in order, followed by the
+// whole response, so both "```json" fences and bare JSON embedded in prose are
+// found. It returns ErrNoJSON if nothing parses.
+func ExtractJSON(response string) ([]byte, error) {
+	var candidates []string
+	for _, block := range ExtractCodeBlocks(response) {
+		candidates = append(candidates, block.Content)
+	}
+	candidates = append(candidates, response)
+
+	for _, candidate := range candidates {
+		if value := firstJSONValue(candidate); value != nil {
+			return value, nil
+		}
+	}
+	return nil, ErrNoJSON
+}
+
+// firstJSONValue scans s for the first '{' or '[' that starts a complete,
+// valid JSON value and returns that value
+func firstJSONValue(s string) []byte {
+	for i := 0; i < len(s); i++ {
+		if s[i] != '{' && s[i] != '[' {
+			continue
+		}
+
+		var value json.RawMessage
+		decoder := json.NewDecoder(strings.NewReader(s[i:]))
+		if err := decoder.Decode(&value); err == nil {
+			return bytes.TrimSpace(value)
+		}
+	}
+	return nil
+}
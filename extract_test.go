@@ -0,0 +1,156 @@
+package geminicli
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestExtractJSON tests extracting JSON from model responses
+func TestExtractJSON(t *testing.T) {
+	tests := []struct {
+		name        string
+		response    string
+		expected    string
+		expectError bool
+		description string
+	}{
+		{
+			name:        "BareObject",
+			response:    `{"name": "gemini"}`,
+			expected:    `{"name": "gemini"}`,
+			description: "Plain JSON should be returned as is",
+		},
+		{
+			name:        "JSONFence",
+			response:    "Here you go:\n```json\n{\"items\": [1, 2, 3]}\n```\nLet me know!",
+			expected:    `{"items": [1, 2, 3]}`,
+			description: "JSON inside a fenced block should be extracted",
+		},
+		{
+			name:        "FenceWithoutLanguage",
+			response:    "```\n[\"a\", \"b\"]\n```",
+			expected:    `["a", "b"]`,
+			description: "Arrays in unlabeled fences should be extracted",
+		},
+		{
+			name:        "EmbeddedInProse",
+			response:    `The answer is {"ok": true, "note": "braces } in strings"} as requested.`,
+			expected:    `{"ok": true, "note": "braces } in strings"}`,
+			description: "Brace matching should respect JSON strings",
+		},
+		{
+			name:        "SkipsInvalidCandidates",
+			response:    "Use {placeholder} syntax, result: {\"value\": 42}",
+			expected:    `{"value": 42}`,
+			description: "Brace-delimited text that is not JSON should be skipped",
+		},
+		{
+			name:        "PrefersFencedBlock",
+			response:    "See note [1] below\n```json\n{\"fenced\": true}\n```",
+			expected:    `{"fenced": true}`,
+			description: "Fenced blocks should win over JSON-looking prose",
+		},
+		{
+			name:        "NoJSON",
+			response:    "I cannot help with that.",
+			expectError: true,
+			description: "Responses without JSON should return ErrNoJSON",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ExtractJSON(tt.response)
+
+			if tt.expectError {
+				if !errors.Is(err, ErrNoJSON) {
+					t.Errorf("%s: expected ErrNoJSON, got %v", tt.description, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("%s: unexpected error: %v", tt.description, err)
+			}
+			if string(result) != tt.expected {
+				t.Errorf("%s: expected '%s', got '%s'", tt.description, tt.expected, string(result))
+			}
+		})
+	}
+}
+
+// TestExtractCodeBlocks tests parsing fenced code blocks from model responses
+func TestExtractCodeBlocks(t *testing.T) {
+	tests := []struct {
+		name        string
+		response    string
+		expected    []CodeBlock
+		description string
+	}{
+		{
+			name:        "SingleBlock",
+			response:    "Here is the code:\n```go\nfmt.Println(\"hi\")\n```\nDone.",
+			expected:    []CodeBlock{{Language: "go", Content: "fmt.Println(\"hi\")"}},
+			description: "Language and content should be extracted",
+		},
+		{
+			name:        "NoLanguage",
+			response:    "```\nplain text\n```",
+			expected:    []CodeBlock{{Language: "", Content: "plain text"}},
+			description: "Fences without an info string should have an empty language",
+		},
+		{
+			name:     "MultipleBlocks",
+			response: "```python\nprint(1)\n```\nand\n```sh\necho 2\n```",
+			expected: []CodeBlock{
+				{Language: "python", Content: "print(1)"},
+				{Language: "sh", Content: "echo 2"},
+			},
+			description: "All blocks should be returned in order",
+		},
+		{
+			name:        "IndentedFence",
+			response:    "1. Run this:\n   ```bash\n   make build\n     --verbose\n   ```",
+			expected:    []CodeBlock{{Language: "bash", Content: "make build\n  --verbose"}},
+			description: "Fence indentation should be removed while nested indentation is kept",
+		},
+		{
+			name:        "LongerFenceContainsBackticks",
+			response:    "````markdown\n```go\nx := 1\n```\n````",
+			expected:    []CodeBlock{{Language: "markdown", Content: "```go\nx := 1\n```"}},
+			description: "A shorter fence inside a longer one should be content",
+		},
+		{
+			name:        "InfoStringAttributes",
+			response:    "```js title=\"app.js\"\nrun()\n```",
+			expected:    []CodeBlock{{Language: "js", Content: "run()"}},
+			description: "Only the first word of the info string should be the language",
+		},
+		{
+			name:        "Unclosed",
+			response:    "```go\npackage main",
+			expected:    []CodeBlock{{Language: "go", Content: "package main"}},
+			description: "An unclosed fence should run to the end of the response",
+		},
+		{
+			name:        "NoBlocks",
+			response:    "Just prose with `inline code`.",
+			expected:    nil,
+			description: "Inline code should not be treated as a block",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blocks := ExtractCodeBlocks(tt.response)
+
+			if len(blocks) != len(tt.expected) {
+				t.Fatalf("%s: expected %d blocks, got %d: %+v", tt.description, len(tt.expected), len(blocks), blocks)
+			}
+			for i, block := range blocks {
+				if block != tt.expected[i] {
+					t.Errorf("%s: block %d expected %+v, got %+v", tt.description, i, tt.expected[i], block)
+				}
+			}
+		})
+	}
+}
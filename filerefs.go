@@ -0,0 +1,66 @@
+package geminicli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// fileReferencePattern matches gemini @path references at the start of the
+// prompt or after whitespace, so e-mail addresses are not mistaken for them.
+// Spaces inside a path are escaped with a backslash, as gemini expects.
+var fileReferencePattern = regexp.MustCompile(`(?:^|\s)@((?:\\ |[^\s])+)`)
+
+// FileReferences returns the paths referenced with @path in prompt, in
+// order, with escaped spaces unescaped and trailing punctuation removed
+func FileReferences(prompt string) []string {
+	var refs []string
+	for _, match := range fileReferencePattern.FindAllStringSubmatch(prompt, -1) {
+		ref := strings.TrimRight(match[1], ".,;:!?)\"'")
+		ref = strings.ReplaceAll(ref, `\ `, " ")
+		if ref != "" {
+			refs = append(refs, ref)
+		}
+	}
+	return refs
+}
+
+// ValidateFileReferences checks that every @path reference in prompt exists,
+// resolving relative paths against the working directory gemini will run
+// in. The returned error matches ErrFileReferenceNotFound and lists every
+// missing path.
+func (c *Client) ValidateFileReferences(prompt string) error {
+	return c.validateFileReferencesIn(prompt, c.workingDirectory)
+}
+
+// validateFileReferencesIn checks @path references against baseDir, or the
+// current directory if baseDir is empty
+func (c *Client) validateFileReferencesIn(prompt, baseDir string) error {
+	refs := FileReferences(prompt)
+	if len(refs) == 0 {
+		return nil
+	}
+
+	if baseDir == "" {
+		baseDir, _ = os.Getwd()
+	}
+
+	var missing []string
+	for _, ref := range refs {
+		path := ref
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(baseDir, filepath.FromSlash(path))
+		}
+		if _, err := os.Stat(path); err != nil {
+			c.logger.DebugWith("File reference not found", "reference", ref, "path", path, "error", err)
+			missing = append(missing, ref)
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: %s", ErrFileReferenceNotFound, strings.Join(missing, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package geminicli
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestFileReferences tests scanning prompts for @path references
+func TestFileReferences(t *testing.T) {
+	tests := []struct {
+		name     string
+		prompt   string
+		expected []string
+	}{
+		{"None", "Explain closures", nil},
+		{"Single", "Review @main.go please", []string{"main.go"}},
+		{"AtStart", "@src/app.ts explain this", []string{"src/app.ts"}},
+		{"Multiple", "Compare @a.go and @b/c.go", []string{"a.go", "b/c.go"}},
+		{"TrailingPunctuation", "Look at @main.go, then @README.md.", []string{"main.go", "README.md"}},
+		{"EscapedSpace", `Read @My\ Documents/notes.txt`, []string{"My Documents/notes.txt"}},
+		{"EmailIgnored", "Mail user@example.com about it", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			refs := FileReferences(tt.prompt)
+			if strings.Join(refs, "|") != strings.Join(tt.expected, "|") {
+				t.Errorf("Expected %v, got %v", tt.expected, refs)
+			}
+		})
+	}
+}
+
+// TestValidateFileReferences tests rejecting prompts that reference missing files
+func TestValidateFileReferences(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "exists.go"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name            string
+		prompt          string
+		expectedMissing []string
+		description     string
+	}{
+		{
+			name:        "AllExist",
+			prompt:      "Review @exists.go and @" + filepath.Join(dir, "exists.go"),
+			description: "Relative and absolute references to existing files should pass",
+		},
+		{
+			name:            "Missing",
+			prompt:          "Review @exists.go, @mian.go and @lib/util.go",
+			expectedMissing: []string{"mian.go", "lib/util.go"},
+			description:     "Every missing reference should be listed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := &fakeRunner{}
+			client := NewClientWithConfig(Config{
+				WorkingDirectory:       dir,
+				ValidateFileReferences: true,
+				Runner:                 runner,
+			})
+
+			_, err := client.Execute(tt.prompt)
+
+			if len(tt.expectedMissing) == 0 {
+				if err != nil {
+					t.Errorf("%s: unexpected error: %v", tt.description, err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrFileReferenceNotFound) {
+				t.Fatalf("%s: expected ErrFileReferenceNotFound, got %v", tt.description, err)
+			}
+			for _, ref := range tt.expectedMissing {
+				if !strings.Contains(err.Error(), ref) {
+					t.Errorf("%s: expected error to list '%s', got %v", tt.description, ref, err)
+				}
+			}
+			if len(runner.invocations()) != 0 {
+				t.Errorf("%s: expected no process to be spawned", tt.description)
+			}
+		})
+	}
+
+	t.Run("DisabledByDefault", func(t *testing.T) {
+		client := NewClientWithConfig(Config{WorkingDirectory: dir, Runner: &fakeRunner{}})
+		if _, err := client.Execute("Review @missing.go"); err != nil {
+			t.Errorf("Expected no validation without ValidateFileReferences, got %v", err)
+		}
+	})
+}
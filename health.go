@@ -0,0 +1,70 @@
+package geminicli
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	// HealthCheckPrompt is the trivial prompt sent by HealthCheck
+	HealthCheckPrompt = "ping"
+
+	// HealthCheckTimeout bounds a single HealthCheck; a shorter deadline on
+	// the caller's context or a shorter client timeout takes precedence
+	HealthCheckTimeout = 15 * time.Second
+)
+
+// HealthCheck sends HealthCheckPrompt to gemini and returns nil only if it
+// answers. Authentication and quota failures are classified as in Execute,
+// so errors.Is(err, ErrAuthentication) and errors.Is(err, ErrRateLimited)
+// work. An unreadable APIKeyFile or, with StrictWorkingDir, a missing
+// working directory fails the check without running gemini. The probe is not
+// retried and does not trigger hooks.
+func (c *Client) HealthCheck(ctx context.Context) error {
+	opts := c.defaultExecOptions(HealthCheckPrompt)
+	if opts.timeout <= 0 || opts.timeout > HealthCheckTimeout {
+		opts.timeout = HealthCheckTimeout
+	}
+	if err := c.checkSetup(opts.dir); err != nil {
+		return fmt.Errorf("%s: %w", ErrHealthCheck, err)
+	}
+
+	if _, err := c.executeOnce(ctx, HealthCheckPrompt, opts); err != nil {
+		c.logger.WarnWith("Gemini health check failed", "error", err)
+		return fmt.Errorf("%s: %w", ErrHealthCheck, err)
+	}
+	return nil
+}
+
+// Warmup sends HealthCheckPrompt to gemini and discards the response, so
+// cached credentials are loaded before the first real call. It is meant to
+// be called once at service startup. Only failures that later calls would
+// hit too are returned: an unreadable APIKeyFile, a missing working
+// directory with StrictWorkingDir, authentication errors, a missing or
+// unstartable gemini binary, and an unknown or unavailable model. Anything
+// else, such as a rate limit or a timeout, is logged and nil is returned.
+func (c *Client) Warmup(ctx context.Context) error {
+	opts := c.defaultExecOptions(HealthCheckPrompt)
+	if opts.timeout <= 0 || opts.timeout > HealthCheckTimeout {
+		opts.timeout = HealthCheckTimeout
+	}
+	if err := c.checkSetup(opts.dir); err != nil {
+		return fmt.Errorf("%s: %w", ErrWarmup, err)
+	}
+
+	_, err := c.executeOnce(ctx, HealthCheckPrompt, opts)
+	if err == nil {
+		c.logger.DebugWith("Gemini warmup completed")
+		return nil
+	}
+	switch c.classify(nil, err) {
+	case KindAuth, KindModelNotFound, KindModelUnavailable:
+		return fmt.Errorf("%s: %w", ErrWarmup, err)
+	}
+	if isStartError(err) {
+		return fmt.Errorf("%s: %w", ErrWarmup, err)
+	}
+	c.logger.WarnWith("Gemini warmup failed, continuing", "error", err)
+	return nil
+}
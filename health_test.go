@@ -0,0 +1,208 @@
+package geminicli
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestHealthCheck tests the readiness probe
+func TestHealthCheck(t *testing.T) {
+	tests := []struct {
+		name        string
+		stdout      string
+		stderr      string
+		runErr      error
+		expectedErr error
+		description string
+	}{
+		{
+			name:        "Healthy",
+			stdout:      "pong",
+			description: "A normal response should be healthy",
+		},
+		{
+			name:        "AuthFailure",
+			stderr:      "Error: invalid API key",
+			runErr:      errors.New("exit status 1"),
+			expectedErr: ErrAuthentication,
+			description: "Authentication failures should be classified",
+		},
+		{
+			name:        "QuotaExceeded",
+			stderr:      "429 RESOURCE_EXHAUSTED",
+			runErr:      errors.New("exit status 1"),
+			expectedErr: ErrRateLimited,
+			description: "Quota errors should be classified",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := &fakeRunner{run: func(ctx context.Context, inv Invocation) ([]byte, []byte, error) {
+				return []byte(tt.stdout), []byte(tt.stderr), tt.runErr
+			}}
+			client := NewClientWithConfig(Config{Runner: runner})
+
+			err := client.HealthCheck(context.Background())
+
+			if tt.expectedErr == nil {
+				if err != nil {
+					t.Errorf("%s: unexpected error: %v", tt.description, err)
+				}
+			} else {
+				if !errors.Is(err, tt.expectedErr) {
+					t.Errorf("%s: expected %v, got %v", tt.description, tt.expectedErr, err)
+				}
+				if !strings.Contains(err.Error(), ErrHealthCheck) {
+					t.Errorf("%s: expected health check error, got %v", tt.description, err)
+				}
+			}
+
+			calls := runner.invocations()
+			if len(calls) != 1 {
+				t.Fatalf("Expected exactly 1 invocation without retries, got %d", len(calls))
+			}
+			if prompt := calls[0].Args[len(calls[0].Args)-1]; prompt != HealthCheckPrompt {
+				t.Errorf("Expected prompt '%s', got '%s'", HealthCheckPrompt, prompt)
+			}
+		})
+	}
+
+	t.Run("CanceledContext", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := NewClient(WithRunner(&fakeRunner{})).HealthCheck(ctx)
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected context.Canceled, got %v", err)
+		}
+	})
+}
+
+// TestWarmup tests that warmup only reports auth and availability errors
+func TestWarmup(t *testing.T) {
+	tests := []struct {
+		name        string
+		stdout      string
+		stderr      string
+		runErr      error
+		expectedErr error
+		description string
+	}{
+		{
+			name:        "Success",
+			stdout:      "pong",
+			description: "The response should be discarded",
+		},
+		{
+			name:        "EmptyOutput",
+			description: "An empty response should not fail warmup",
+		},
+		{
+			name:        "AuthFailure",
+			stderr:      "Error: invalid API key",
+			runErr:      errors.New("exit status 1"),
+			expectedErr: ErrAuthentication,
+			description: "Authentication failures should be returned",
+		},
+		{
+			name:        "ModelUnavailable",
+			stderr:      "503 model is overloaded",
+			runErr:      errors.New("exit status 1"),
+			expectedErr: ErrModelUnavailable,
+			description: "An unavailable model should be returned",
+		},
+		{
+			name:        "BinaryMissing",
+			runErr:      &startError{message: ErrCommandNotFound, err: exec.ErrNotFound},
+			expectedErr: exec.ErrNotFound,
+			description: "A missing binary should be returned",
+		},
+		{
+			name:        "QuotaExceeded",
+			stderr:      "429 RESOURCE_EXHAUSTED",
+			runErr:      errors.New("exit status 1"),
+			description: "Quota errors should be swallowed",
+		},
+		{
+			name:        "GenericFailure",
+			stderr:      "something went wrong",
+			runErr:      errors.New("exit status 1"),
+			description: "Unclassified failures should be swallowed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := &fakeRunner{run: func(ctx context.Context, inv Invocation) ([]byte, []byte, error) {
+				return []byte(tt.stdout), []byte(tt.stderr), tt.runErr
+			}}
+			client := NewClientWithConfig(Config{Runner: runner})
+
+			err := client.Warmup(context.Background())
+
+			if tt.expectedErr == nil {
+				if err != nil {
+					t.Errorf("%s: unexpected error: %v", tt.description, err)
+				}
+			} else {
+				if !errors.Is(err, tt.expectedErr) {
+					t.Errorf("%s: expected %v, got %v", tt.description, tt.expectedErr, err)
+				}
+				if err != nil && !strings.Contains(err.Error(), ErrWarmup) {
+					t.Errorf("%s: expected warmup error, got %v", tt.description, err)
+				}
+			}
+
+			if calls := runner.invocations(); len(calls) != 1 {
+				t.Errorf("Expected exactly 1 invocation without retries, got %d", len(calls))
+			}
+		})
+	}
+}
+
+// TestHealthCheckSetupErrors tests that HealthCheck and Warmup report the
+// configuration errors every Execute would fail with
+func TestHealthCheckSetupErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      Config
+		expectedErr error
+		description string
+	}{
+		{
+			name:        "UnreadableAPIKeyFile",
+			config:      Config{APIKeyFile: filepath.Join(t.TempDir(), "missing")},
+			expectedErr: ErrInvalidConfig,
+			description: "An unreadable APIKeyFile should fail the probe",
+		},
+		{
+			name:        "StrictWorkingDir",
+			config:      Config{WorkingDirectory: filepath.Join(t.TempDir(), "missing"), StrictWorkingDir: true},
+			expectedErr: ErrWorkingDirNotFound,
+			description: "A missing working directory should fail the probe",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := &fakeRunner{}
+			tt.config.Runner = runner
+			client := NewClientWithConfig(tt.config)
+
+			if err := client.HealthCheck(context.Background()); !errors.Is(err, tt.expectedErr) {
+				t.Errorf("%s: HealthCheck expected %v, got %v", tt.description, tt.expectedErr, err)
+			}
+			if err := client.Warmup(context.Background()); !errors.Is(err, tt.expectedErr) {
+				t.Errorf("%s: Warmup expected %v, got %v", tt.description, tt.expectedErr, err)
+			}
+			if calls := len(runner.invocations()); calls != 0 {
+				t.Errorf("%s: expected gemini not to run, got %d invocations", tt.description, calls)
+			}
+		})
+	}
+}
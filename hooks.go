@@ -0,0 +1,24 @@
+package geminicli
+
+import "time"
+
+// CompleteHook is called after an execution finishes, successfully or not.
+// result is usually nil on failure, but a timeout or ErrOutputTooLarge comes
+// with the partial result read before gemini was stopped. Hooks may run
+// concurrently when the client is shared between goroutines.
+type CompleteHook func(prompt string, result *Result, err error, duration time.Duration)
+
+// BeforeExecuteHook receives the prompt before gemini runs and returns the
+// prompt to send instead, e.g. with secrets redacted. Returning an error
+// aborts the execution without spawning a process.
+type BeforeExecuteHook func(prompt string) (string, error)
+
+// AfterExecuteHook receives the filtered output of a successful execution and
+// returns the text handed back to the caller. Returning an error fails the
+// execution.
+type AfterExecuteHook func(output string) (string, error)
+
+// RetryHook is called before the client sleeps ahead of a retry. attempt is
+// the 1-based number of the attempt that just failed with lastErr, and
+// nextDelay is how long the client will wait before trying again.
+type RetryHook func(attempt int, lastErr error, nextDelay time.Duration)
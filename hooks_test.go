@@ -0,0 +1,231 @@
+package geminicli
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// completion records a single OnComplete invocation
+type completion struct {
+	prompt   string
+	result   *Result
+	err      error
+	duration time.Duration
+}
+
+// recordCompletions returns a CompleteHook that appends to the returned slice
+func recordCompletions() (CompleteHook, func() []completion) {
+	var mu sync.Mutex
+	var calls []completion
+	hook := func(prompt string, result *Result, err error, duration time.Duration) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls = append(calls, completion{prompt, result, err, duration})
+	}
+	return hook, func() []completion {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]completion(nil), calls...)
+	}
+}
+
+// TestOnComplete tests that the completion hook fires on every code path
+func TestOnComplete(t *testing.T) {
+	tests := []struct {
+		name          string
+		prompt        string
+		runErr        error
+		expectError   bool
+		expectResult  bool
+		expectedCalls int
+		description   string
+	}{
+		{
+			name:          "Success",
+			prompt:        "hello",
+			expectResult:  true,
+			expectedCalls: 1,
+			description:   "Successful executions should report their result",
+		},
+		{
+			name:          "CommandFailure",
+			prompt:        "hello",
+			runErr:        errors.New("exit status 1"),
+			expectError:   true,
+			expectedCalls: 1,
+			description:   "Failed executions should report their error",
+		},
+		{
+			name:          "EmptyPrompt",
+			prompt:        "",
+			expectError:   true,
+			expectedCalls: 1,
+			description:   "Early validation failures should still fire the hook",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hook, calls := recordCompletions()
+			runner := &fakeRunner{}
+			if tt.runErr != nil {
+				runner.run = func(_ context.Context, _ Invocation) ([]byte, []byte, error) {
+					return nil, []byte("boom"), tt.runErr
+				}
+			}
+			client := NewClientWithConfig(Config{OnComplete: hook, Runner: runner})
+
+			_, err := client.Execute(tt.prompt)
+
+			got := calls()
+			if len(got) != tt.expectedCalls {
+				t.Fatalf("%s: expected %d hook calls, got %d", tt.description, tt.expectedCalls, len(got))
+			}
+			call := got[0]
+			if call.prompt != tt.prompt {
+				t.Errorf("Expected prompt '%s', got '%s'", tt.prompt, call.prompt)
+			}
+			if (call.err != nil) != tt.expectError || call.err != err {
+				t.Errorf("%s: expected hook error to match returned error %v, got %v", tt.description, err, call.err)
+			}
+			if (call.result != nil) != tt.expectResult {
+				t.Errorf("%s: unexpected result %+v", tt.description, call.result)
+			}
+			if call.duration < 0 {
+				t.Errorf("Expected non-negative duration, got %v", call.duration)
+			}
+		})
+	}
+}
+
+// TestExecuteHooks tests the BeforeExecute and AfterExecute middleware hooks
+func TestExecuteHooks(t *testing.T) {
+	t.Run("RewritesPromptAndOutput", func(t *testing.T) {
+		runner := &fakeRunner{}
+		client := NewClientWithConfig(Config{
+			Runner: runner,
+			BeforeExecute: func(prompt string) (string, error) {
+				return strings.ReplaceAll(prompt, "s3cr3t", "[REDACTED]"), nil
+			},
+			AfterExecute: func(output string) (string, error) {
+				return strings.ToUpper(output), nil
+			},
+		})
+
+		result, err := client.Execute("token is s3cr3t")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if result != "FAKE RESPONSE" {
+			t.Errorf("Expected output rewritten by AfterExecute, got '%s'", result)
+		}
+
+		args := runner.invocations()[0].Args
+		if prompt := args[len(args)-1]; prompt != "token is [REDACTED]" {
+			t.Errorf("Expected prompt rewritten by BeforeExecute, got '%s'", prompt)
+		}
+	})
+
+	t.Run("BeforeExecuteErrorAborts", func(t *testing.T) {
+		hookErr := errors.New("prompt rejected")
+		runner := &fakeRunner{}
+		client := NewClientWithConfig(Config{
+			Runner: runner,
+			BeforeExecute: func(prompt string) (string, error) {
+				return "", hookErr
+			},
+		})
+
+		_, err := client.Execute("hello")
+		if !errors.Is(err, hookErr) || !strings.Contains(err.Error(), ErrBeforeExecute) {
+			t.Errorf("Expected wrapped hook error, got %v", err)
+		}
+		if len(runner.invocations()) != 0 {
+			t.Error("Expected no process to be spawned")
+		}
+	})
+
+	t.Run("AfterExecuteErrorFails", func(t *testing.T) {
+		hookErr := errors.New("output rejected")
+		client := NewClientWithConfig(Config{
+			Runner: &fakeRunner{},
+			AfterExecute: func(output string) (string, error) {
+				return "", hookErr
+			},
+		})
+
+		result, err := client.Execute("hello")
+		if !errors.Is(err, hookErr) || !strings.Contains(err.Error(), ErrAfterExecute) {
+			t.Errorf("Expected wrapped hook error, got %v", err)
+		}
+		if result != "" {
+			t.Errorf("Expected empty result, got '%s'", result)
+		}
+	})
+}
+
+// TestOnRetry tests the retry hook
+func TestOnRetry(t *testing.T) {
+	tests := []struct {
+		name             string
+		succeedOn        int
+		stderr           string
+		retryCount       int
+		expectedAttempts []int
+		description      string
+	}{
+		{
+			name:             "RateLimited",
+			succeedOn:        3,
+			stderr:           "429 Too Many Requests",
+			expectedAttempts: []int{1, 2},
+			description:      "The hook should fire before every retry",
+		},
+		{
+			name:        "RetriesDisabled",
+			succeedOn:   2,
+			stderr:      "503 service unavailable",
+			retryCount:  -1,
+			description: "The hook should not fire when retries are disabled",
+		},
+		{
+			name:        "NonRetryable",
+			succeedOn:   9,
+			stderr:      "Error: authentication failed",
+			description: "The hook should not fire for non-retryable errors",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			counterFile := filepath.Join(t.TempDir(), "count")
+			installFakeGemini(t, countingScript(counterFile, tt.succeedOn, tt.stderr))
+
+			var attempts []int
+			client := NewClientWithConfig(Config{
+				RetryCount:   tt.retryCount,
+				RetryBackoff: time.Millisecond,
+				OnRetry: func(attempt int, lastErr error, nextDelay time.Duration) {
+					if lastErr == nil {
+						t.Error("Expected the failed attempt's error")
+					}
+					if expected := time.Duration(1<<(attempt-1)) * time.Millisecond; nextDelay != expected {
+						t.Errorf("Expected delay %v for attempt %d, got %v", expected, attempt, nextDelay)
+					}
+					attempts = append(attempts, attempt)
+				},
+			})
+			client.Execute("test prompt")
+
+			if !slices.Equal(attempts, tt.expectedAttempts) {
+				t.Errorf("%s: expected attempts %v, got %v", tt.description, tt.expectedAttempts, attempts)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package geminicli
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// DefaultIdempotencyWindow is how long a successful result is reused for a
+// repeated IdempotencyKey
+const DefaultIdempotencyWindow = 30 * time.Second
+
+// idempotentCall is a call made under an IdempotencyKey
+type idempotentCall struct {
+	prompt   string
+	done     chan struct{} // Closed once result, err and finished are set
+	result   *Result
+	err      error
+	finished time.Time
+}
+
+// idempotencyKeys holds the latest call for each IdempotencyKey. It is shared
+// by all clients so a retry layer creating its own client is covered too.
+var idempotencyKeys = struct {
+	mu    sync.Mutex
+	calls map[string]*idempotentCall
+}{calls: make(map[string]*idempotentCall)}
+
+// ClearIdempotencyKeys forgets every stored IdempotencyKey, so the next call
+// with any key runs gemini again. Calls still running are not affected.
+func ClearIdempotencyKeys() {
+	idempotencyKeys.mu.Lock()
+	defer idempotencyKeys.mu.Unlock()
+	clear(idempotencyKeys.calls)
+}
+
+// executeIdempotent runs execute unless a call with the client's
+// IdempotencyKey and the same prompt succeeded within the idempotency window
+// or is still running, in which case that call's result is returned instead.
+// Failed calls are not kept, so a later retry runs gemini again.
+func (c *Client) executeIdempotent(ctx context.Context, prompt string, execute func() (*Result, error)) (*Result, error) {
+	if c.idempotencyKey == "" {
+		return execute()
+	}
+
+	idempotencyKeys.mu.Lock()
+	if call, ok := idempotencyKeys.calls[c.idempotencyKey]; ok && call.prompt == prompt {
+		select {
+		case <-call.done:
+			if call.err == nil && c.clock.Now().Sub(call.finished) < c.idempotencyWindow {
+				idempotencyKeys.mu.Unlock()
+				c.logger.InfoWith("Reusing result for repeated idempotency key", "idempotency_key", c.idempotencyKey)
+				return call.result.clone(), nil
+			}
+		default:
+			idempotencyKeys.mu.Unlock()
+			c.logger.InfoWith("Waiting for running call with the same idempotency key", "idempotency_key", c.idempotencyKey)
+			select {
+			case <-call.done:
+				return call.result.clone(), call.err
+			case <-ctx.Done():
+				return nil, fmt.Errorf("%s: %w", ErrCommandFailed, contextErr(ctx))
+			}
+		}
+	}
+	call := &idempotentCall{prompt: prompt, done: make(chan struct{})}
+	idempotencyKeys.calls[c.idempotencyKey] = call
+	idempotencyKeys.mu.Unlock()
+
+	result, err := execute()
+
+	idempotencyKeys.mu.Lock()
+	call.result, call.err, call.finished = result.clone(), err, c.clock.Now()
+	close(call.done)
+	if err != nil && idempotencyKeys.calls[c.idempotencyKey] == call {
+		delete(idempotencyKeys.calls, c.idempotencyKey)
+	}
+	idempotencyKeys.mu.Unlock()
+	return result, err
+}
+
+// clone returns a copy of r that shares no slices with it
+func (r *Result) clone() *Result {
+	if r == nil {
+		return nil
+	}
+	clone := *r
+	clone.RawOutput = slices.Clone(r.RawOutput)
+	clone.Command = slices.Clone(r.Command)
+	return &clone
+}
@@ -0,0 +1,105 @@
+package geminicli
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestIdempotencyKey tests reusing results for a repeated idempotency key
+func TestIdempotencyKey(t *testing.T) {
+	t.Cleanup(ClearIdempotencyKeys)
+	clock := newFakeClock()
+	runner := &fakeRunner{}
+	newClient := func(key string) *Client {
+		return NewClientWithConfig(Config{IdempotencyKey: key, IdempotencyWindow: time.Minute, Clock: clock, Runner: runner})
+	}
+
+	steps := []struct {
+		name          string
+		key           string
+		prompt        string
+		before        func()
+		expectedCalls int
+		description   string
+	}{
+		{"First", "job-1", "hello", nil, 1, "The first call should run gemini"},
+		{"Repeated", "job-1", "hello", nil, 1, "A repeated key should reuse the result"},
+		{"OtherClient", "job-1", "hello", nil, 1, "The key should be shared between clients"},
+		{"OtherPrompt", "job-1", "bye", nil, 2, "A different prompt should run gemini"},
+		{"NoKey", "", "bye", nil, 3, "Calls without a key should always run"},
+		{"Expired", "job-1", "bye", func() { clock.Advance(time.Minute) }, 4, "Results should expire after the window"},
+		{"Cleared", "job-1", "bye", ClearIdempotencyKeys, 5, "Cleared keys should run again"},
+	}
+
+	for _, step := range steps {
+		if step.before != nil {
+			step.before()
+		}
+		result, err := newClient(step.key).Execute(step.prompt)
+		if err != nil || result != "fake response" {
+			t.Fatalf("%s: unexpected result %q (%v)", step.name, result, err)
+		}
+		if calls := len(runner.invocations()); calls != step.expectedCalls {
+			t.Errorf("%s: %s, expected %d gemini runs, got %d", step.name, step.description, step.expectedCalls, calls)
+		}
+	}
+}
+
+// TestIdempotencyKeyFailure tests that failed calls are not reused
+func TestIdempotencyKeyFailure(t *testing.T) {
+	t.Cleanup(ClearIdempotencyKeys)
+	runner := &fakeRunner{run: func(ctx context.Context, inv Invocation) ([]byte, []byte, error) {
+		return nil, []byte("backend exploded"), errors.New("exit status 1")
+	}}
+	client := NewClientWithConfig(Config{IdempotencyKey: t.Name(), RetryCount: -1, Runner: runner})
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.Execute("hello"); err == nil {
+			t.Fatal("Expected error from failing runner")
+		}
+	}
+	if calls := len(runner.invocations()); calls != 2 {
+		t.Errorf("Expected failed calls to be retried, got %d gemini runs", calls)
+	}
+}
+
+// TestIdempotencyKeyConcurrent tests that a duplicate sent while the first
+// call runs waits for its result
+func TestIdempotencyKeyConcurrent(t *testing.T) {
+	t.Cleanup(ClearIdempotencyKeys)
+	started := make(chan struct{})
+	release := make(chan struct{})
+	runner := &fakeRunner{run: func(ctx context.Context, inv Invocation) ([]byte, []byte, error) {
+		close(started)
+		<-release
+		return []byte("only once"), nil, nil
+	}}
+	client := NewClientWithConfig(Config{IdempotencyKey: t.Name(), Runner: runner})
+
+	var wg sync.WaitGroup
+	results := make([]string, 2)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		results[0], _ = client.Execute("hello")
+	}()
+	<-started
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		results[1], _ = client.Execute("hello")
+	}()
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if calls := len(runner.invocations()); calls != 1 {
+		t.Errorf("Expected one gemini run, got %d", calls)
+	}
+	if results[0] != "only once" || results[1] != "only once" {
+		t.Errorf("Expected both calls to get the result, got %q", results)
+	}
+}
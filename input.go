@@ -0,0 +1,53 @@
+package geminicli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// PromptSegmentSeparator separates the segments ExecuteMulti joins into one
+// prompt
+const PromptSegmentSeparator = "\n\n"
+
+// ExecuteReader reads the whole prompt from r and executes it. Combine with
+// Config.PromptViaStdin to keep large inputs off the command line.
+func (c *Client) ExecuteReader(r io.Reader) (string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", ErrReadPrompt, err)
+	}
+	return c.Execute(string(data))
+}
+
+// ExecuteFile reads the prompt from the file at path and executes it. A file
+// that cannot be opened yields a wrapped os error; an empty file yields the
+// usual empty prompt error.
+func (c *Client) ExecuteFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", ErrReadPrompt, err)
+	}
+	defer file.Close()
+
+	return c.ExecuteReader(file)
+}
+
+// ExecuteMulti executes a prompt assembled from segments, joined with
+// PromptSegmentSeparator so each part stays visibly separate. Blank segments
+// are skipped; if nothing is left it fails with the usual empty prompt error.
+func (c *Client) ExecuteMulti(segments []string) (string, error) {
+	return c.Execute(joinSegments(segments))
+}
+
+// joinSegments joins the non-blank segments with PromptSegmentSeparator
+func joinSegments(segments []string) string {
+	var parts []string
+	for _, segment := range segments {
+		if strings.TrimSpace(segment) != "" {
+			parts = append(parts, segment)
+		}
+	}
+	return strings.Join(parts, PromptSegmentSeparator)
+}
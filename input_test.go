@@ -0,0 +1,156 @@
+package geminicli
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// failingReader is an io.Reader that always fails
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) {
+	return 0, errors.New("disk on fire")
+}
+
+// TestExecuteReader tests reading prompts from an io.Reader
+func TestExecuteReader(t *testing.T) {
+	runner := &fakeRunner{}
+	client := NewClient(WithRunner(runner))
+
+	if _, err := client.ExecuteReader(strings.NewReader("from reader")); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	calls := runner.invocations()
+	if len(calls) != 1 {
+		t.Fatalf("Expected 1 invocation, got %d", len(calls))
+	}
+	args := calls[0].Args
+	if args[len(args)-1] != "from reader" {
+		t.Errorf("Expected prompt 'from reader', got '%s'", args[len(args)-1])
+	}
+
+	_, err := client.ExecuteReader(failingReader{})
+	if err == nil || !strings.Contains(err.Error(), ErrReadPrompt) {
+		t.Errorf("Expected read error, got %v", err)
+	}
+}
+
+// TestExecuteFile tests reading prompts from a file
+func TestExecuteFile(t *testing.T) {
+	dir := t.TempDir()
+	promptFile := filepath.Join(dir, "prompt.txt")
+	emptyFile := filepath.Join(dir, "empty.txt")
+	if err := os.WriteFile(promptFile, []byte("from file"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(emptyFile, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		path        string
+		checkError  func(error) bool
+		description string
+	}{
+		{
+			name:        "ReadsFile",
+			path:        promptFile,
+			checkError:  func(err error) bool { return err == nil },
+			description: "File contents should be executed as the prompt",
+		},
+		{
+			name: "EmptyFile",
+			path: emptyFile,
+			checkError: func(err error) bool {
+				return err != nil && err.Error() == ErrEmptyPrompt
+			},
+			description: "Empty file should report an empty prompt",
+		},
+		{
+			name: "MissingFile",
+			path: filepath.Join(dir, "missing.txt"),
+			checkError: func(err error) bool {
+				return errors.Is(err, fs.ErrNotExist) && strings.Contains(err.Error(), ErrReadPrompt)
+			},
+			description: "Missing file should return a wrapped open error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewClient(WithRunner(&fakeRunner{}))
+			_, err := client.ExecuteFile(tt.path)
+			if !tt.checkError(err) {
+				t.Errorf("%s: unexpected error %v", tt.description, err)
+			}
+		})
+	}
+}
+
+// TestExecuteMulti tests executing a prompt assembled from segments
+func TestExecuteMulti(t *testing.T) {
+	tests := []struct {
+		name        string
+		segments    []string
+		expected    string
+		expectError bool
+		description string
+	}{
+		{
+			name:        "JoinsSegments",
+			segments:    []string{"Context: a Go library", "Task: write a README"},
+			expected:    "Context: a Go library\n\nTask: write a README",
+			description: "Segments should be joined with PromptSegmentSeparator",
+		},
+		{
+			name:        "SkipsBlankSegments",
+			segments:    []string{"", "only part", "  \n"},
+			expected:    "only part",
+			description: "Blank segments should be skipped",
+		},
+		{
+			name:        "AllEmpty",
+			segments:    []string{"", " "},
+			expectError: true,
+			description: "Only blank segments should report an empty prompt",
+		},
+		{
+			name:        "NoSegments",
+			expectError: true,
+			description: "No segments should report an empty prompt",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := &fakeRunner{}
+			_, err := NewClient(WithRunner(runner)).ExecuteMulti(tt.segments)
+
+			calls := runner.invocations()
+			if tt.expectError {
+				if err == nil || err.Error() != ErrEmptyPrompt {
+					t.Errorf("%s: expected empty prompt error, got %v", tt.description, err)
+				}
+				if len(calls) != 0 {
+					t.Errorf("%s: expected gemini not to run, got %d invocations", tt.description, len(calls))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("%s: unexpected error: %v", tt.description, err)
+			}
+			if len(calls) != 1 {
+				t.Fatalf("%s: expected 1 invocation, got %d", tt.description, len(calls))
+			}
+			if prompt := calls[0].Args[len(calls[0].Args)-1]; prompt != tt.expected {
+				t.Errorf("%s: expected prompt %q, got %q", tt.description, tt.expected, prompt)
+			}
+		})
+	}
+}
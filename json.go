@@ -0,0 +1,81 @@
+package geminicli
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// JSONResponse is the parsed form of gemini's --output-format json payload
+type JSONResponse struct {
+	Text           string // Model response text
+	PromptTokens   int    // Input tokens summed across all models used
+	ResponseTokens int    // Output (candidate) tokens summed across all models used
+}
+
+// geminiJSONOutput mirrors the subset of gemini's JSON output we consume
+type geminiJSONOutput struct {
+	Response string `json:"response"`
+	Stats    struct {
+		Models map[string]struct {
+			Tokens struct {
+				Prompt     int `json:"prompt"`
+				Candidates int `json:"candidates"`
+			} `json:"tokens"`
+		} `json:"models"`
+	} `json:"stats"`
+	Error *struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// parseGeminiJSONOutput parses the output of gemini run with
+// --output-format json. System messages printed before the payload are
+// skipped; the payload itself is not line-filtered so the JSON stays intact.
+func (c *Client) parseGeminiJSONOutput(output []byte) (*JSONResponse, error) {
+	raw, err := decodeGeminiJSON(output)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &JSONResponse{Text: strings.TrimSpace(raw.Response)}
+	for _, model := range raw.Stats.Models {
+		response.PromptTokens += model.Tokens.Prompt
+		response.ResponseTokens += model.Tokens.Candidates
+	}
+
+	if response.Text == "" {
+		return nil, ErrNoOutput
+	}
+
+	return response, nil
+}
+
+// ParseGeminiJSONOutput parses the output of gemini run with --output-format json
+func ParseGeminiJSONOutput(output []byte) (*JSONResponse, error) {
+	client := NewClient()
+	return client.parseGeminiJSONOutput(output)
+}
+
+// decodeGeminiJSON decodes gemini's JSON payload, skipping any system
+// messages printed before it, and surfaces an error reported inside it
+func decodeGeminiJSON(output []byte) (*geminiJSONOutput, error) {
+	payload := strings.TrimSpace(string(output))
+	if payload == "" {
+		return nil, ErrNoOutput
+	}
+	if start := strings.Index(payload, "{"); start > 0 {
+		payload = payload[start:]
+	}
+
+	var raw geminiJSONOutput
+	if err := json.Unmarshal([]byte(payload), &raw); err != nil {
+		return nil, fmt.Errorf("invalid JSON output: %w", err)
+	}
+
+	if raw.Error != nil && raw.Error.Message != "" {
+		return nil, fmt.Errorf("gemini reported %s: %s", raw.Error.Type, raw.Error.Message)
+	}
+	return &raw, nil
+}
@@ -0,0 +1,105 @@
+package geminicli
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestParseGeminiJSONOutput tests parsing of gemini's JSON output mode
+func TestParseGeminiJSONOutput(t *testing.T) {
+	tests := []struct {
+		name                   string
+		output                 string
+		expectedText           string
+		expectedPromptTokens   int
+		expectedResponseTokens int
+		expectError            bool
+		description            string
+	}{
+		{
+			name:                   "SingleModel",
+			output:                 `{"response":"Hello, world!","stats":{"models":{"gemini-2.5-flash":{"tokens":{"prompt":12,"candidates":4,"total":16}}}}}`,
+			expectedText:           "Hello, world!",
+			expectedPromptTokens:   12,
+			expectedResponseTokens: 4,
+			description:            "Should parse response text and token counts",
+		},
+		{
+			name:                   "MultipleModelsSummed",
+			output:                 `{"response":"ok","stats":{"models":{"a":{"tokens":{"prompt":10,"candidates":1}},"b":{"tokens":{"prompt":5,"candidates":2}}}}}`,
+			expectedText:           "ok",
+			expectedPromptTokens:   15,
+			expectedResponseTokens: 3,
+			description:            "Should sum token usage across models",
+		},
+		{
+			name:                   "LeadingSystemMessage",
+			output:                 "Loaded cached credentials.\n{\"response\":\"Authenticating is a word\"}",
+			expectedText:           "Authenticating is a word",
+			expectedPromptTokens:   0,
+			expectedResponseTokens: 0,
+			description:            "Should skip boilerplate without filtering the payload",
+		},
+		{
+			name:        "InvalidJSON",
+			output:      "not json at all",
+			expectError: true,
+			description: "Should return error for non-JSON output",
+		},
+		{
+			name:        "ErrorPayload",
+			output:      `{"response":"","error":{"type":"ApiError","message":"quota exceeded"}}`,
+			expectError: true,
+			description: "Should surface errors reported in the payload",
+		},
+		{
+			name:        "EmptyOutput",
+			output:      "",
+			expectError: true,
+			description: "Should return error for empty output",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response, err := ParseGeminiJSONOutput([]byte(tt.output))
+
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected error for test case '%s', but got none", tt.name)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error for test case '%s': %v", tt.name, err)
+			}
+			if response.Text != tt.expectedText {
+				t.Errorf("Expected text '%s', got '%s'", tt.expectedText, response.Text)
+			}
+			if response.PromptTokens != tt.expectedPromptTokens {
+				t.Errorf("Expected %d prompt tokens, got %d", tt.expectedPromptTokens, response.PromptTokens)
+			}
+			if response.ResponseTokens != tt.expectedResponseTokens {
+				t.Errorf("Expected %d response tokens, got %d", tt.expectedResponseTokens, response.ResponseTokens)
+			}
+		})
+	}
+}
+
+// TestExecuteResultJSONOutput tests JSON output mode end to end
+func TestExecuteResultJSONOutput(t *testing.T) {
+	installFakeGemini(t, `echo "$@" >&2; echo '{"response":"Hi","stats":{"models":{"m":{"tokens":{"prompt":7,"candidates":2}}}}}'`)
+
+	client := NewClientWithConfig(Config{OutputFormat: OutputFormatJSON})
+	result, err := client.ExecuteResult("test prompt")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if result.Text != "Hi" || result.PromptTokens != 7 || result.ResponseTokens != 2 {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+	if !strings.Contains(strings.Join(result.Command, " "), "--output-format json") {
+		t.Errorf("Expected --output-format json in command, got %v", result.Command)
+	}
+}
@@ -1,5 +1,10 @@
 package geminicli
 
+import (
+	"crypto/rand"
+	"fmt"
+)
+
 // Logger represents the interface for logging operations
 type Logger interface {
 	DebugWith(msg string, keysAndValues ...interface{})
@@ -20,3 +25,111 @@ func (l NoOpLogger) ErrorWith(msg string, keysAndValues ...interface{}) {}
 func NewNoOpLogger() Logger {
 	return &NoOpLogger{}
 }
+
+// Level is the severity of a log message
+type Level int
+
+// Log levels, from most to least verbose
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+// String returns the lower-case name of the level
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "debug"
+	case LevelInfo:
+		return "info"
+	case LevelWarn:
+		return "warn"
+	case LevelError:
+		return "error"
+	}
+	return fmt.Sprintf("Level(%d)", int(l))
+}
+
+// LevelLogger forwards messages at or above a minimum level to a base logger
+// and drops the rest
+type LevelLogger struct {
+	base Logger
+	min  Level
+}
+
+// NewLevelLogger creates a logger that only forwards messages at min or above
+// to base; a nil base discards everything
+func NewLevelLogger(base Logger, min Level) Logger {
+	if base == nil {
+		base = NewNoOpLogger()
+	}
+	return &LevelLogger{base: base, min: min}
+}
+
+func (l *LevelLogger) DebugWith(msg string, keysAndValues ...interface{}) {
+	if l.min <= LevelDebug {
+		l.base.DebugWith(msg, keysAndValues...)
+	}
+}
+
+func (l *LevelLogger) InfoWith(msg string, keysAndValues ...interface{}) {
+	if l.min <= LevelInfo {
+		l.base.InfoWith(msg, keysAndValues...)
+	}
+}
+
+func (l *LevelLogger) WarnWith(msg string, keysAndValues ...interface{}) {
+	if l.min <= LevelWarn {
+		l.base.WarnWith(msg, keysAndValues...)
+	}
+}
+
+func (l *LevelLogger) ErrorWith(msg string, keysAndValues ...interface{}) {
+	if l.min <= LevelError {
+		l.base.ErrorWith(msg, keysAndValues...)
+	}
+}
+
+// fieldLogger prepends fixed key-value pairs, such as the request id, to
+// every message before forwarding it to a base logger
+type fieldLogger struct {
+	base   Logger
+	fields []interface{}
+}
+
+// withFields returns a logger that adds keysAndValues to every message
+func withFields(base Logger, keysAndValues ...interface{}) Logger {
+	return &fieldLogger{base: base, fields: keysAndValues}
+}
+
+func (l *fieldLogger) DebugWith(msg string, keysAndValues ...interface{}) {
+	l.base.DebugWith(msg, l.with(keysAndValues)...)
+}
+
+func (l *fieldLogger) InfoWith(msg string, keysAndValues ...interface{}) {
+	l.base.InfoWith(msg, l.with(keysAndValues)...)
+}
+
+func (l *fieldLogger) WarnWith(msg string, keysAndValues ...interface{}) {
+	l.base.WarnWith(msg, l.with(keysAndValues)...)
+}
+
+func (l *fieldLogger) ErrorWith(msg string, keysAndValues ...interface{}) {
+	l.base.ErrorWith(msg, l.with(keysAndValues)...)
+}
+
+// with returns the fixed fields followed by keysAndValues
+func (l *fieldLogger) with(keysAndValues []interface{}) []interface{} {
+	return append(append(make([]interface{}, 0, len(l.fields)+len(keysAndValues)), l.fields...), keysAndValues...)
+}
+
+// newRequestID returns a random RFC 4122 version 4 UUID
+func newRequestID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40 // Version 4
+	b[8] = b[8]&0x3f | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
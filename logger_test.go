@@ -0,0 +1,261 @@
+package geminicli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestLevelLogger tests filtering of log messages by minimum level
+func TestLevelLogger(t *testing.T) {
+	tests := []struct {
+		name     string
+		min      Level
+		expected []string
+	}{
+		{"Debug", LevelDebug, []string{"debug", "info", "warn", "error"}},
+		{"Info", LevelInfo, []string{"info", "warn", "error"}},
+		{"Warn", LevelWarn, []string{"warn", "error"}},
+		{"Error", LevelError, []string{"error"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base := NewRecordingLogger()
+			logger := NewLevelLogger(base, tt.min)
+
+			logger.DebugWith("debug message")
+			logger.InfoWith("info message")
+			logger.WarnWith("warn message")
+			logger.ErrorWith("error message")
+
+			var levels []string
+			for _, e := range base.Entries() {
+				levels = append(levels, e.Level.String())
+			}
+			if strings.Join(levels, " ") != strings.Join(tt.expected, " ") {
+				t.Errorf("Expected %v, got %v", tt.expected, levels)
+			}
+		})
+	}
+
+	t.Run("NilBase", func(t *testing.T) {
+		logger := NewLevelLogger(nil, LevelDebug)
+		logger.ErrorWith("should not panic")
+	})
+}
+
+// TestRequestID tests that every client log message carries the request id
+func TestRequestID(t *testing.T) {
+	t.Run("Configured", func(t *testing.T) {
+		logger := NewRecordingLogger()
+		client := NewClientWithConfig(Config{RequestID: "req-123", Logger: logger, Runner: &fakeRunner{}})
+		if _, err := client.Execute("test prompt"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if len(logger.Entries()) == 0 {
+			t.Fatal("Expected log messages")
+		}
+		for _, e := range logger.Entries() {
+			if id := e.Value("request_id"); id != "req-123" {
+				t.Errorf("Expected request_id req-123 on %q, got %v", e.Msg, id)
+			}
+		}
+		if client.RequestID() != "req-123" {
+			t.Errorf("Expected RequestID() to return req-123, got %s", client.RequestID())
+		}
+	})
+
+	t.Run("Generated", func(t *testing.T) {
+		uuid := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+		first, second := NewClient().RequestID(), NewClient().RequestID()
+		if !uuid.MatchString(first) {
+			t.Errorf("Expected a version 4 UUID, got %q", first)
+		}
+		if first == second {
+			t.Errorf("Expected distinct ids per client, got %q twice", first)
+		}
+	})
+}
+
+// TestLogOutputLines tests logging each non-filtered output line
+func TestLogOutputLines(t *testing.T) {
+	installFakeGemini(t, `echo "Loaded cached credentials."; echo "first line"; printf "second line"`)
+
+	tests := []struct {
+		name     string
+		enabled  bool
+		expected []string
+	}{
+		{"Disabled", false, nil},
+		{"Enabled", true, []string{"first line", "second line"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := NewRecordingLogger()
+			client := NewClientWithConfig(Config{LogOutputLines: tt.enabled, RequestID: "req-1", Logger: logger})
+			if _, err := client.Execute("test prompt"); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			var lines []string
+			for _, e := range logger.Entries() {
+				if e.Msg != "Gemini output line" {
+					continue
+				}
+				if e.Level != LevelInfo || e.Value("request_id") != "req-1" {
+					t.Errorf("Expected info level with request id, got %+v", e)
+				}
+				lines = append(lines, e.Value("line").(string))
+			}
+			if strings.Join(lines, "|") != strings.Join(tt.expected, "|") {
+				t.Errorf("Expected logged lines %q, got %q", tt.expected, lines)
+			}
+		})
+	}
+}
+
+// TestLogStderr tests forwarding stderr lines to the logger
+func TestLogStderr(t *testing.T) {
+	tests := []struct {
+		name     string
+		enabled  bool
+		script   string
+		expected []string
+	}{
+		{"Disabled", false, `echo "warning: slow" >&2; echo ok`, nil},
+		{"Success", true, `echo "warning: slow" >&2; echo >&2; echo "retrying" >&2; echo ok`, []string{"warning: slow", "retrying"}},
+		{"Failure", true, `echo "backend exploded" >&2; exit 1`, []string{"backend exploded"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			installFakeGemini(t, tt.script)
+			logger := NewRecordingLogger()
+			client := NewClientWithConfig(Config{LogStderr: tt.enabled, RetryCount: -1, Logger: logger})
+			client.Execute("test prompt")
+
+			var lines []string
+			for _, e := range logger.Entries() {
+				if e.Msg != "Gemini stderr line" {
+					continue
+				}
+				if e.Level != LevelInfo {
+					t.Errorf("Expected info level, got %v", e.Level)
+				}
+				lines = append(lines, e.Value("stderr").(string))
+			}
+			if strings.Join(lines, "|") != strings.Join(tt.expected, "|") {
+				t.Errorf("Expected logged stderr %q, got %q", tt.expected, lines)
+			}
+		})
+	}
+}
+
+// TestDebug tests passing --debug and keeping its lines out of responses
+func TestDebug(t *testing.T) {
+	installFakeGemini(t, `echo "[DEBUG] [MemoryDiscovery] loading GEMINI.md"; echo "[DEBUG] trace" >&2; echo "answer"; echo "[DEBUG] done"`)
+
+	tests := []struct {
+		name           string
+		config         Config
+		expectedText   string
+		expectedLogged []string
+	}{
+		{"Disabled", Config{}, "[DEBUG] [MemoryDiscovery] loading GEMINI.md\nanswer\n[DEBUG] done", nil},
+		{"Debug", Config{Debug: true}, "answer", nil},
+		{"DebugWithLogStderr", Config{Debug: true, LogStderr: true}, "answer", []string{"[DEBUG] trace", "[DEBUG] [MemoryDiscovery] loading GEMINI.md", "[DEBUG] done"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := NewRecordingLogger()
+			tt.config.Logger = logger
+			client := NewClientWithConfig(tt.config)
+
+			command := strings.Join(client.buildGeminiCommandWithModel("test prompt"), " ")
+			if strings.Contains(command, GeminiDebugFlag) != tt.config.Debug {
+				t.Errorf("Unexpected --debug presence in %q", command)
+			}
+
+			text, err := client.Execute("test prompt")
+			if err != nil || text != tt.expectedText {
+				t.Errorf("Expected %q, got %q (%v)", tt.expectedText, text, err)
+			}
+
+			var logged []string
+			for _, e := range logger.Entries() {
+				switch e.Msg {
+				case "Gemini stderr line":
+					logged = append(logged, e.Value("stderr").(string))
+				case "Gemini debug line":
+					logged = append(logged, e.Value("debug").(string))
+				}
+			}
+			if strings.Join(logged, "|") != strings.Join(tt.expectedLogged, "|") {
+				t.Errorf("Expected logged lines %q, got %q", tt.expectedLogged, logged)
+			}
+		})
+	}
+}
+
+// fakeT records failures reported through TestingT
+type fakeT struct {
+	failures []string
+}
+
+func (f *fakeT) Helper() {}
+func (f *fakeT) Errorf(format string, args ...interface{}) {
+	f.failures = append(f.failures, fmt.Sprintf(format, args...))
+}
+
+// TestRecordingLogger tests capturing and asserting on log messages
+func TestRecordingLogger(t *testing.T) {
+	logger := NewRecordingLogger()
+	logger.InfoWith("Executing Gemini command", "model", "gemini-2.5-pro")
+	logger.WarnWith("Retrying Gemini command", "attempt", 1)
+
+	entries := logger.Entries()
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(entries))
+	}
+	if entries[0].Level != LevelInfo || entries[0].Msg != "Executing Gemini command" || entries[0].Value("model") != "gemini-2.5-pro" {
+		t.Errorf("Unexpected first entry: %+v", entries[0])
+	}
+	if entries[1].Value("missing") != nil {
+		t.Errorf("Expected nil for a missing key, got %v", entries[1].Value("missing"))
+	}
+
+	ft := &fakeT{}
+	if !logger.AssertLogged(ft, LevelWarn, "Retrying Gemini command") || len(ft.failures) != 0 {
+		t.Errorf("Expected the warning to be found, got failures %v", ft.failures)
+	}
+	if logger.AssertLogged(ft, LevelError, "Retrying Gemini command") || len(ft.failures) != 1 {
+		t.Errorf("Expected a failure for the wrong level, got %v", ft.failures)
+	}
+
+	logger.Reset()
+	if len(logger.Entries()) != 0 {
+		t.Errorf("Expected no entries after Reset, got %d", len(logger.Entries()))
+	}
+}
+
+// TestClientLogging tests the client's own log output through a
+// RecordingLogger
+func TestClientLogging(t *testing.T) {
+	logger := NewRecordingLogger()
+	runner := &fakeRunner{run: func(ctx context.Context, inv Invocation) ([]byte, []byte, error) {
+		return nil, []byte("429 Too Many Requests"), errors.New("exit status 1")
+	}}
+	client := NewClientWithConfig(Config{Logger: logger, RetryCount: 1, RetryBackoff: time.Millisecond, Runner: runner})
+	client.Execute("test prompt")
+
+	logger.AssertLogged(t, LevelWarn, "Retrying Gemini command")
+	logger.AssertLogged(t, LevelError, "Gemini command execution failed")
+}
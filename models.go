@@ -0,0 +1,111 @@
+package geminicli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultModelCacheTTL is how long ListModels reuses a successful result
+const DefaultModelCacheTTL = time.Hour
+
+// modelNamePattern matches model names such as "gemini-2.5-pro", optionally
+// prefixed with "models/" as in the Gemini API
+var modelNamePattern = regexp.MustCompile(`^(?:models/)?([a-z0-9][a-z0-9._]*(?:-[a-z0-9._]+)+)$`)
+
+// modelCache holds the last ListModels result
+type modelCache struct {
+	mu      sync.Mutex
+	models  []string
+	fetched time.Time
+}
+
+// ListModels runs the configured model-listing command and returns the
+// model names it prints, one per line. Results are cached for ModelCacheTTL.
+// The stock gemini CLI has no listing command, so without
+// Config.ListModelsArgs, or when the installed CLI rejects the command or
+// prints no model names, it fails with ErrUnsupported; callers can then fall
+// back to a fixed list of choices.
+func (c *Client) ListModels(ctx context.Context) ([]string, error) {
+	if len(c.listModelsArgs) == 0 {
+		return nil, fmt.Errorf("%w: no model listing command configured", ErrUnsupported)
+	}
+
+	c.models.mu.Lock()
+	defer c.models.mu.Unlock()
+
+	if c.models.models != nil && c.clock.Now().Sub(c.models.fetched) < c.modelCacheTTL {
+		return slices.Clone(c.models.models), nil
+	}
+
+	dir, err := c.invocationDir(c.workingDirectory)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := c.withTimeout(ctx, c.timeout)
+	defer cancel()
+
+	inv := Invocation{
+		Name: c.binaryPath,
+		Args: slices.Clone(c.listModelsArgs),
+		Dir:  dir,
+		Env:  c.environment(),
+	}
+	c.logger.DebugWith("Listing Gemini models", "command", inv.Name, "args", inv.Args)
+
+	output, err := c.runCommandWithTimeout(ctx, inv, c.timeout)
+	if err != nil {
+		var cmdErr *CommandError
+		if errors.As(err, &cmdErr) && c.containsAnyKeyword(cmdErr.Stdout+cmdErr.Stderr, unsupportedCommandKeywords()) {
+			return nil, fmt.Errorf("%w: %w", ErrUnsupported, err)
+		}
+		return nil, fmt.Errorf("%s: %w", ErrCommandFailed, err)
+	}
+
+	models := parseModelList(c.filterGeminiOutput(string(output)))
+	if len(models) == 0 {
+		return nil, fmt.Errorf("%w: no model names in output", ErrUnsupported)
+	}
+
+	if c.modelCacheTTL > 0 {
+		c.models.models = models
+		c.models.fetched = c.clock.Now()
+	}
+	return slices.Clone(models), nil
+}
+
+// unsupportedCommandKeywords returns messages a CLI prints when it does not
+// know a subcommand
+func unsupportedCommandKeywords() []string {
+	return []string{
+		"unknown command",
+		"unknown argument",
+		"unknown arguments",
+		"invalid command",
+		"not a valid command",
+	}
+}
+
+// parseModelList extracts model names from listing output. The first field
+// of each line is used, list bullets are ignored, and headers or prose that
+// do not look like model names are skipped. Duplicates are removed.
+func parseModelList(output string) []string {
+	var models []string
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(strings.TrimLeft(strings.TrimSpace(line), "-*• "))
+		if len(fields) == 0 {
+			continue
+		}
+		match := modelNamePattern.FindStringSubmatch(strings.TrimRight(fields[0], ",:"))
+		if match != nil && !slices.Contains(models, match[1]) {
+			models = append(models, match[1])
+		}
+	}
+	return models
+}
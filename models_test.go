@@ -0,0 +1,173 @@
+package geminicli
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestParseModelList tests extracting model names from listing output
+func TestParseModelList(t *testing.T) {
+	tests := []struct {
+		name     string
+		output   string
+		expected []string
+	}{
+		{"OnePerLine", "gemini-2.5-pro\ngemini-2.5-flash", []string{"gemini-2.5-pro", "gemini-2.5-flash"}},
+		{"WithHeaderAndBullets", "Available models:\n- gemini-2.5-pro (default)\n* gemini-2.0-flash", []string{"gemini-2.5-pro", "gemini-2.0-flash"}},
+		{"APIPrefix", "models/gemini-2.5-pro", []string{"gemini-2.5-pro"}},
+		{"Table", "NAME              CONTEXT\ngemini-2.5-pro    1M\ngemini-2.5-pro    1M", []string{"gemini-2.5-pro"}},
+		{"Prose", "I can help you with that.", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			models := parseModelList(tt.output)
+			if strings.Join(models, " ") != strings.Join(tt.expected, " ") {
+				t.Errorf("Expected %v, got %v", tt.expected, models)
+			}
+		})
+	}
+}
+
+// TestListModels tests running the listing command and classifying failures
+func TestListModels(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		stdout      string
+		stderr      string
+		runErr      error
+		expected    []string
+		expectedErr error
+		description string
+	}{
+		{
+			name:        "NotConfigured",
+			expectedErr: ErrUnsupported,
+			description: "Without ListModelsArgs gemini should not be run",
+		},
+		{
+			name:        "Listed",
+			args:        []string{"models", "list"},
+			stdout:      "Loaded cached credentials.\ngemini-2.5-pro\ngemini-2.5-flash\n",
+			expected:    []string{"gemini-2.5-pro", "gemini-2.5-flash"},
+			description: "Model names should be parsed after output filtering",
+		},
+		{
+			name:        "UnknownCommand",
+			args:        []string{"models", "list"},
+			stderr:      "Unknown arguments: models, list",
+			runErr:      errors.New("exit status 1"),
+			expectedErr: ErrUnsupported,
+			description: "A CLI rejecting the subcommand should report ErrUnsupported",
+		},
+		{
+			name:        "NoModels",
+			args:        []string{"models", "list"},
+			stdout:      "I'm not sure what you mean.",
+			expectedErr: ErrUnsupported,
+			description: "Output without model names should report ErrUnsupported",
+		},
+		{
+			name:        "OtherFailure",
+			args:        []string{"models", "list"},
+			stderr:      "Error: invalid API key",
+			runErr:      errors.New("exit status 1"),
+			expectedErr: ErrAuthentication,
+			description: "Other failures should keep their classification",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := &fakeRunner{run: func(ctx context.Context, inv Invocation) ([]byte, []byte, error) {
+				return []byte(tt.stdout), []byte(tt.stderr), tt.runErr
+			}}
+			client := NewClientWithConfig(Config{ListModelsArgs: tt.args, Runner: runner})
+
+			models, err := client.ListModels(context.Background())
+			if tt.expectedErr != nil {
+				if !errors.Is(err, tt.expectedErr) {
+					t.Errorf("%s: expected %v, got %v", tt.description, tt.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("%s: unexpected error: %v", tt.description, err)
+			}
+			if strings.Join(models, " ") != strings.Join(tt.expected, " ") {
+				t.Errorf("%s: expected %v, got %v", tt.description, tt.expected, models)
+			}
+			if calls := runner.invocations(); len(calls) != 1 || strings.Join(calls[0].Args, " ") != "models list" {
+				t.Errorf("%s: expected one run of 'models list', got %v", tt.description, calls)
+			}
+		})
+	}
+}
+
+// TestListModelsCache tests reusing ListModels results for the TTL
+func TestListModelsCache(t *testing.T) {
+	tests := []struct {
+		name          string
+		ttl           time.Duration
+		advance       time.Duration
+		expectedCalls int
+		description   string
+	}{
+		{
+			name:          "Cached",
+			advance:       time.Minute,
+			expectedCalls: 1,
+			description:   "Results should be reused within DefaultModelCacheTTL",
+		},
+		{
+			name:          "Expired",
+			ttl:           time.Minute,
+			advance:       time.Minute,
+			expectedCalls: 2,
+			description:   "Results should be refreshed once the TTL has passed",
+		},
+		{
+			name:          "Disabled",
+			ttl:           -1,
+			expectedCalls: 2,
+			description:   "A negative TTL should disable caching",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := newFakeClock()
+			runner := &fakeRunner{run: func(ctx context.Context, inv Invocation) ([]byte, []byte, error) {
+				return []byte("gemini-2.5-pro\n"), nil, nil
+			}}
+			client := NewClientWithConfig(Config{
+				ListModelsArgs: []string{"models", "list"},
+				ModelCacheTTL:  tt.ttl,
+				Clock:          clock,
+				Runner:         runner,
+			})
+
+			first, err := client.ListModels(context.Background())
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			first[0] = "modified"
+
+			clock.Advance(tt.advance)
+			second, err := client.ListModels(context.Background())
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if second[0] != "gemini-2.5-pro" {
+				t.Errorf("%s: callers should not be able to modify the cache, got %v", tt.description, second)
+			}
+			if calls := len(runner.invocations()); calls != tt.expectedCalls {
+				t.Errorf("%s: expected %d runs, got %d", tt.description, tt.expectedCalls, calls)
+			}
+		})
+	}
+}
@@ -0,0 +1,874 @@
+package geminicli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Option configures a Client created by NewClient
+type Option func(*Client)
+
+// WithLogger sets the logger; a nil logger keeps the default NoOpLogger
+func WithLogger(logger Logger) Option {
+	return func(c *Client) {
+		if logger != nil {
+			c.logger = logger
+		}
+	}
+}
+
+// WithTimeout sets the command timeout; non-positive values keep the default
+// timeout returned by GetDefaultTimeout
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		if timeout > 0 {
+			c.timeout = timeout
+		}
+	}
+}
+
+// WithModel sets the model name; an empty name keeps the default model
+// returned by GetDefaultModel
+func WithModel(model string) Option {
+	return func(c *Client) {
+		if model != "" {
+			c.model = model
+		}
+	}
+}
+
+// WithWorkingDirectory sets the working directory for command execution
+func WithWorkingDirectory(dir string) Option {
+	return func(c *Client) {
+		c.workingDirectory = dir
+	}
+}
+
+// WithRetryCount sets the number of retries for transient failures; zero or
+// negative disables retries
+func WithRetryCount(count int) Option {
+	return func(c *Client) {
+		if count < 0 {
+			count = 0
+		}
+		c.retryCount = count
+	}
+}
+
+// WithRetryBackoff sets the base delay for exponential backoff between
+// retries; non-positive values are ignored
+func WithRetryBackoff(backoff time.Duration) Option {
+	return func(c *Client) {
+		if backoff > 0 {
+			c.retryBackoff = backoff
+		}
+	}
+}
+
+// WithBackoff sets the delay policy between retries, replacing the default
+// exponential backoff from WithRetryBackoff; a nil policy is ignored
+func WithBackoff(policy BackoffPolicy) Option {
+	return func(c *Client) {
+		if policy != nil {
+			c.backoff = policy
+		}
+	}
+}
+
+// WithPromptViaStdin sends prompts longer than threshold bytes on stdin
+// instead of with the prompt flag; a threshold of 0 sends every prompt on stdin
+func WithPromptViaStdin(threshold int) Option {
+	return func(c *Client) {
+		c.promptViaStdin = true
+		if threshold > 0 {
+			c.stdinThreshold = threshold
+		}
+	}
+}
+
+// WithFilterPatterns adds output filter patterns on top of the defaults
+// returned by DefaultFilterPatterns
+func WithFilterPatterns(patterns ...string) Option {
+	return func(c *Client) {
+		c.filterPatterns = append(c.filterPatterns, patterns...)
+	}
+}
+
+// WithFilterRegexps adds regular expressions for system messages with
+// variable content. Each output line, trimmed, is matched after the
+// substring patterns; nil entries are ignored.
+func WithFilterRegexps(regexps ...*regexp.Regexp) Option {
+	return func(c *Client) {
+		for _, re := range regexps {
+			if re != nil {
+				c.filterRegexps = append(c.filterRegexps, re)
+			}
+		}
+	}
+}
+
+// WithAuthErrorKeywords adds authentication error keywords on top of the
+// defaults returned by DefaultAuthErrorKeywords. Matching is case-insensitive.
+func WithAuthErrorKeywords(keywords ...string) Option {
+	return func(c *Client) {
+		c.authKeywords = append(c.authKeywords, keywords...)
+	}
+}
+
+// WithGracePeriod sets how long a timed-out process may take to exit after
+// SIGTERM before it is killed; zero or negative kills immediately
+func WithGracePeriod(gracePeriod time.Duration) Option {
+	return func(c *Client) {
+		if gracePeriod < 0 {
+			gracePeriod = 0
+		}
+		c.gracePeriod = gracePeriod
+	}
+}
+
+// WithExtraArgs adds gemini flags that are passed through verbatim, placed
+// after the model flag and before the prompt flag
+func WithExtraArgs(args ...string) Option {
+	return func(c *Client) {
+		c.extraArgs = append(c.extraArgs, args...)
+	}
+}
+
+// WithOutputFormat selects gemini's output format, OutputFormatText (the
+// default) or OutputFormatJSON
+func WithOutputFormat(format string) Option {
+	return func(c *Client) {
+		if format != "" {
+			c.outputFormat = format
+		}
+	}
+}
+
+// WithMaxPromptLength rejects prompts longer than maxBytes with
+// ErrPromptTooLong; zero or negative means unlimited
+func WithMaxPromptLength(maxBytes int) Option {
+	return func(c *Client) {
+		if maxBytes < 0 {
+			maxBytes = 0
+		}
+		c.maxPromptLength = maxBytes
+	}
+}
+
+// WithResolvePaths enables rewriting relative paths in prompts to absolute
+// paths when a working directory is set. Passing extensions replaces the
+// list returned by DefaultPathExtensions.
+func WithResolvePaths(extensions ...string) Option {
+	return func(c *Client) {
+		c.resolvePaths = true
+		if len(extensions) > 0 {
+			c.pathExtensions = append([]string(nil), extensions...)
+		}
+	}
+}
+
+// WithExplicitPathsOnly limits path resolution to paths starting with ./ or
+// ../, leaving bare file names mentioned in prose untouched
+func WithExplicitPathsOnly() Option {
+	return func(c *Client) {
+		c.explicitPathsOnly = true
+	}
+}
+
+// WithMinVersion makes ValidateAvailable fail with ErrVersionTooOld when the
+// installed gemini is older than version
+func WithMinVersion(version string) Option {
+	return func(c *Client) {
+		c.minVersion = version
+	}
+}
+
+// WithOnComplete registers a hook called after every execution with the
+// prompt, result, error and elapsed time; it also fires for rejected prompts
+func WithOnComplete(hook CompleteHook) Option {
+	return func(c *Client) {
+		c.onComplete = hook
+	}
+}
+
+// WithOnRetry registers a hook called before each retry sleep. It is never
+// called for non-retryable errors or when retries are disabled.
+func WithOnRetry(hook RetryHook) Option {
+	return func(c *Client) {
+		c.onRetry = hook
+	}
+}
+
+// WithBeforeExecute registers a hook that can rewrite or reject prompts
+// before gemini runs
+func WithBeforeExecute(hook BeforeExecuteHook) Option {
+	return func(c *Client) {
+		c.beforeExecute = hook
+	}
+}
+
+// WithAfterExecute registers a hook that can rewrite or reject the output of
+// successful executions
+func WithAfterExecute(hook AfterExecuteHook) Option {
+	return func(c *Client) {
+		c.afterExecute = hook
+	}
+}
+
+// WithEnv sets extra environment variables for the gemini process. They are
+// added on top of the inherited environment and win over inherited values.
+func WithEnv(env map[string]string) Option {
+	return func(c *Client) {
+		if len(env) == 0 {
+			return
+		}
+		merged := make(map[string]string, len(c.env)+len(env))
+		for key, value := range c.env {
+			merged[key] = value
+		}
+		for key, value := range env {
+			merged[key] = value
+		}
+		c.env = merged
+	}
+}
+
+// WithConfigPath points gemini at its own settings file through
+// GeminiSettingsPathEnv, so clients do not share the global settings. A
+// relative path is resolved against the current directory; an empty path is
+// ignored.
+func WithConfigPath(path string) Option {
+	return func(c *Client) {
+		if path == "" {
+			return
+		}
+		if abs, err := filepath.Abs(path); err == nil {
+			path = abs
+		}
+		WithEnv(map[string]string{GeminiSettingsPathEnv: path})(c)
+	}
+}
+
+// WithAPIKeyFile reads gemini's API key from path and passes it to every
+// invocation as GEMINI_API_KEY, taking precedence over Env. Surrounding
+// whitespace is trimmed. If the file cannot be read or is empty, executions
+// fail with an ErrInvalidConfig error. The key itself is never logged.
+func WithAPIKeyFile(path string) Option {
+	return func(c *Client) {
+		if path == "" {
+			return
+		}
+		key, err := readAPIKeyFile(path)
+		if err != nil {
+			c.apiKeyErr = err
+			return
+		}
+		c.apiKeyErr = nil
+		c.apiKeyFromFile = true
+		WithEnv(map[string]string{GeminiAPIKeyEnv: key})(c)
+	}
+}
+
+// readAPIKeyFile returns the trimmed contents of an API key file
+func readAPIKeyFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("%w: APIKeyFile: %w", ErrInvalidConfig, err)
+	}
+	key := strings.TrimSpace(string(data))
+	if key == "" {
+		return "", fmt.Errorf("%w: APIKeyFile %s is empty", ErrInvalidConfig, path)
+	}
+	return key, nil
+}
+
+// WithDryRun makes executions return the command line that would run
+// instead of starting gemini
+func WithDryRun() Option {
+	return func(c *Client) {
+		c.dryRun = true
+	}
+}
+
+// WithReturnPartialOnTimeout makes a timed-out execution return the output
+// captured so far together with the timeout error
+func WithReturnPartialOnTimeout() Option {
+	return func(c *Client) {
+		c.returnPartialOnTimeout = true
+	}
+}
+
+// WithBinaryPath sets the name or path of the gemini executable; an empty
+// value keeps GeminiCommand
+func WithBinaryPath(path string) Option {
+	return func(c *Client) {
+		if path != "" {
+			c.binaryPath = path
+		}
+	}
+}
+
+// WithPromptFlag sets the flag that introduces the prompt, for gemini-compatible
+// CLIs that spell it differently; an empty value keeps GeminiPromptFlag
+func WithPromptFlag(flag string) Option {
+	return func(c *Client) {
+		if flag != "" {
+			c.promptFlag = flag
+		}
+	}
+}
+
+// WithModelFlag sets the flag that selects the model, for gemini-compatible
+// CLIs that spell it differently; an empty value keeps GeminiModelFlag
+func WithModelFlag(flag string) Option {
+	return func(c *Client) {
+		if flag != "" {
+			c.modelFlag = flag
+		}
+	}
+}
+
+// WithTemperature sets the sampling temperature passed to gemini. Values
+// outside [MinTemperature, MaxTemperature] make executions fail with
+// ErrInvalidTemperature.
+func WithTemperature(temperature float64) Option {
+	return func(c *Client) {
+		c.temperature = &temperature
+	}
+}
+
+// WithMaxOutputTokens limits the number of response tokens. Non-positive
+// values make executions fail with ErrInvalidMaxOutputTokens.
+func WithMaxOutputTokens(tokens int) Option {
+	return func(c *Client) {
+		c.maxOutputTokens = &tokens
+	}
+}
+
+// WithStartupTimeout kills gemini if it writes no output within timeout,
+// failing with ErrStartupTimeout; zero or negative disables the check
+func WithStartupTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		if timeout < 0 {
+			timeout = 0
+		}
+		c.startupTimeout = timeout
+	}
+}
+
+// WithValidateFileReferences makes executions fail with
+// ErrFileReferenceNotFound when an @path reference in the prompt is missing
+func WithValidateFileReferences() Option {
+	return func(c *Client) {
+		c.validateFileReferences = true
+	}
+}
+
+// WithCircuitBreaker enables a circuit breaker that fails fast with
+// ErrCircuitOpen after config.Threshold consecutive failures
+func WithCircuitBreaker(config CircuitBreakerConfig) Option {
+	return func(c *Client) {
+		c.circuitBreaker = config
+	}
+}
+
+// WithRetryBudget limits the retries the client makes across all of its
+// calls. Once the budget is spent, failed attempts are returned at once
+// wrapped in ErrRetryBudgetExhausted.
+func WithRetryBudget(config RetryBudgetConfig) Option {
+	return func(c *Client) {
+		c.retryBudgetConfig = config
+	}
+}
+
+// WithOmitModelFlag stops the client from passing the model flag, so gemini
+// falls back to the model in its settings.json. ExecuteWithModel still passes
+// the model it is given.
+func WithOmitModelFlag() Option {
+	return func(c *Client) {
+		c.omitModelFlag = true
+	}
+}
+
+// WithModelFallback sets models to try in order when the primary model is
+// rate-limited or unavailable
+func WithModelFallback(models ...string) Option {
+	return func(c *Client) {
+		c.modelFallback = append(c.modelFallback, models...)
+	}
+}
+
+// WithRateLimit limits the client to requestsPerSecond gemini invocations,
+// shared by all goroutines using it. Calls block until they may start.
+func WithRateLimit(requestsPerSecond float64) Option {
+	return func(c *Client) {
+		c.rateLimit = requestsPerSecond
+	}
+}
+
+// WithLimiter paces gemini invocations with limiter, which may be shared
+// between clients; it takes precedence over WithRateLimit
+func WithLimiter(limiter Limiter) Option {
+	return func(c *Client) {
+		if limiter != nil {
+			c.limiter = limiter
+		}
+	}
+}
+
+// WithSessionMaxTurns sets how many past turns a Session prepends to each
+// prompt; a negative value keeps the whole conversation
+func WithSessionMaxTurns(turns int) Option {
+	return func(c *Client) {
+		c.sessionMaxTurns = turns
+	}
+}
+
+// WithPreserveBlankLines keeps blank lines inside the response when
+// filtering system messages, so code and paragraphs keep their spacing.
+// Leading and trailing blank lines are still removed.
+func WithPreserveBlankLines() Option {
+	return func(c *Client) {
+		c.preserveBlankLines = true
+	}
+}
+
+// WithTotalDeadline bounds a whole Execute call, including every retry and
+// backoff, so latency has a hard upper limit. Once it passes, the running
+// attempt is stopped and no further retries are made.
+func WithTotalDeadline(d time.Duration) Option {
+	return func(c *Client) {
+		c.totalDeadline = d
+	}
+}
+
+// WithStripPromptEcho removes the prompt from the start of text responses
+// for gemini configurations that echo it back
+func WithStripPromptEcho() Option {
+	return func(c *Client) {
+		c.stripPromptEcho = true
+	}
+}
+
+// WithAllFiles passes --all-files so gemini includes every file in its
+// working directory as context; combine it with WithWorkingDirectory to
+// choose the tree
+func WithAllFiles() Option {
+	return func(c *Client) {
+		c.allFiles = true
+	}
+}
+
+// WithSandbox runs gemini's tools inside its sandbox; the working directory
+// is mounted into the container
+func WithSandbox() Option {
+	return func(c *Client) {
+		c.sandbox = true
+	}
+}
+
+// WithSandboxImage sets the container image gemini uses for its sandbox; an
+// empty image keeps gemini's default
+func WithSandboxImage(image string) Option {
+	return func(c *Client) {
+		c.sandboxImage = image
+	}
+}
+
+// WithExtensions enables only the named gemini extensions. Names are sorted
+// and deduplicated so the command line does not depend on their order; with
+// no names gemini uses all installed extensions.
+func WithExtensions(names ...string) Option {
+	return func(c *Client) {
+		c.extensions = nil
+		if len(names) > 0 {
+			c.extensions = slices.Compact(slices.Sorted(slices.Values(names)))
+		}
+	}
+}
+
+// WithStripANSI removes ANSI escape sequences, such as colors, from
+// responses
+func WithStripANSI() Option {
+	return func(c *Client) {
+		c.stripANSI = true
+	}
+}
+
+// WithMaxOutputBytes kills gemini once it writes more than maxBytes of
+// output and fails with ErrOutputTooLarge; zero means unlimited
+func WithMaxOutputBytes(maxBytes int) Option {
+	return func(c *Client) {
+		c.maxOutputBytes = maxBytes
+	}
+}
+
+// WithRetryableErrors sets the predicate deciding which failed attempts are
+// retried; nil keeps DefaultRetryableError
+func WithRetryableErrors(retryable func(error) bool) Option {
+	return func(c *Client) {
+		c.retryableErrors = retryable
+	}
+}
+
+// WithClock sets the time source for timeouts, retry backoff and measured
+// durations, e.g. a fake clock in tests; a nil clock keeps the real one
+func WithClock(clock Clock) Option {
+	return func(c *Client) {
+		c.clock = clock
+	}
+}
+
+// WithRequestID sets the id added to every log message as "request_id";
+// empty generates a random UUID
+func WithRequestID(id string) Option {
+	return func(c *Client) {
+		c.requestID = id
+	}
+}
+
+// WithLogOutputLines logs every non-filtered line of gemini's output at
+// Info level as it is printed
+func WithLogOutputLines() Option {
+	return func(c *Client) {
+		c.logOutputLines = true
+	}
+}
+
+// WithListModelsArgs sets the arguments that make gemini list its models,
+// used by ListModels
+func WithListModelsArgs(args ...string) Option {
+	return func(c *Client) {
+		c.listModelsArgs = args
+	}
+}
+
+// WithModelCacheTTL sets how long ListModels reuses its result; negative
+// disables caching and zero keeps DefaultModelCacheTTL
+func WithModelCacheTTL(ttl time.Duration) Option {
+	return func(c *Client) {
+		if ttl != 0 {
+			c.modelCacheTTL = ttl
+		}
+	}
+}
+
+// WithIncludePromptInErrors records the prompt, truncated to
+// MaxErrorPromptLength, in CommandError.Prompt and its message. Prompts may
+// contain sensitive data, so enable it only where such logs are acceptable.
+func WithIncludePromptInErrors() Option {
+	return func(c *Client) {
+		c.includePromptInErrors = true
+	}
+}
+
+// WithRetryOnEmptyOutput retries calls whose output is empty once system
+// messages are filtered out, up to RetryCount times, as gemini usually
+// answers properly on the next attempt
+func WithRetryOnEmptyOutput() Option {
+	return func(c *Client) {
+		c.retryOnEmptyOutput = true
+	}
+}
+
+// WithLogStderr logs every non-blank stderr line of each gemini run at Info
+// level under the "stderr" key, including successful runs
+func WithLogStderr() Option {
+	return func(c *Client) {
+		c.logStderr = true
+	}
+}
+
+// WithIdempotencyKey makes calls with the same prompt reuse the result of a
+// call made under key, by any client, that succeeded within the idempotency
+// window or is still running, instead of running gemini again. An empty key
+// disables it.
+func WithIdempotencyKey(key string) Option {
+	return func(c *Client) {
+		c.idempotencyKey = key
+	}
+}
+
+// WithIdempotencyWindow sets how long a result is reused for a repeated
+// idempotency key; zero keeps DefaultIdempotencyWindow
+func WithIdempotencyWindow(window time.Duration) Option {
+	return func(c *Client) {
+		if window > 0 {
+			c.idempotencyWindow = window
+		}
+	}
+}
+
+// WithDebug passes --debug to gemini. Its "[DEBUG]" lines are removed from
+// responses; combine with WithLogStderr to log them.
+func WithDebug() Option {
+	return func(c *Client) {
+		c.debug = true
+	}
+}
+
+// WithStrictWorkingDir checks before every execution that the working
+// directory exists and is a directory, failing with ErrWorkingDirNotFound
+// instead of a generic start error
+func WithStrictWorkingDir() Option {
+	return func(c *Client) {
+		c.strictWorkingDir = true
+	}
+}
+
+// WithTimeoutMessage replaces the "command timed out after ..." text of
+// timeout errors, e.g. with a localized or more actionable message. The
+// error still matches context.DeadlineExceeded. An empty message keeps the
+// default.
+func WithTimeoutMessage(message string) Option {
+	return func(c *Client) {
+		c.timeoutMessage = message
+	}
+}
+
+// WithFallbackDirectory sets the directory gemini runs in when no working
+// directory is configured and neither the current nor the home directory
+// can be determined
+func WithFallbackDirectory(dir string) Option {
+	return func(c *Client) {
+		c.fallbackDirectory = dir
+	}
+}
+
+// WithRunner replaces the default ExecRunner, e.g. with a fake for tests; a
+// nil runner keeps the default
+func WithRunner(runner CommandRunner) Option {
+	return func(c *Client) {
+		if runner != nil {
+			c.runner = runner
+		}
+	}
+}
+
+// options translates a Config into the equivalent functional options,
+// skipping zero values so they keep their defaults
+func (config Config) options() []Option {
+	opts := []Option{
+		WithLogger(config.Logger),
+		WithTimeout(config.Timeout),
+		WithModel(config.Model),
+		WithWorkingDirectory(config.WorkingDirectory),
+		WithRetryBackoff(config.RetryBackoff),
+		WithBackoff(config.Backoff),
+		WithFilterPatterns(config.FilterPatterns...),
+		WithFilterRegexps(config.FilterRegexps...),
+		WithAuthErrorKeywords(config.AuthErrorKeywords...),
+		WithExtraArgs(config.ExtraArgs...),
+		WithOutputFormat(config.OutputFormat),
+		WithRunner(config.Runner),
+		WithMaxPromptLength(config.MaxPromptLength),
+		WithMinVersion(config.MinVersion),
+		WithOnComplete(config.OnComplete),
+		WithOnRetry(config.OnRetry),
+		WithBeforeExecute(config.BeforeExecute),
+		WithAfterExecute(config.AfterExecute),
+		WithEnv(config.Env),
+		WithConfigPath(config.ConfigPath),
+		WithAPIKeyFile(config.APIKeyFile),
+		WithBinaryPath(config.BinaryPath),
+		WithPromptFlag(config.PromptFlag),
+		WithModelFlag(config.ModelFlag),
+		WithStartupTimeout(config.StartupTimeout),
+		WithCircuitBreaker(config.CircuitBreaker),
+		WithRetryBudget(config.RetryBudget),
+		WithModelFallback(config.ModelFallback...),
+		WithRateLimit(config.RateLimit),
+		WithLimiter(config.Limiter),
+		WithSessionMaxTurns(config.SessionMaxTurns),
+		WithTotalDeadline(config.TotalDeadline),
+		WithSandboxImage(config.SandboxImage),
+		WithMaxOutputBytes(config.MaxOutputBytes),
+		WithRetryableErrors(config.RetryableErrors),
+		WithClock(config.Clock),
+		WithRequestID(config.RequestID),
+		WithListModelsArgs(config.ListModelsArgs...),
+		WithModelCacheTTL(config.ModelCacheTTL),
+		WithExtensions(config.Extensions...),
+		WithIdempotencyKey(config.IdempotencyKey),
+		WithIdempotencyWindow(config.IdempotencyWindow),
+		WithTimeoutMessage(config.TimeoutMessage),
+		WithFallbackDirectory(config.FallbackDirectory),
+	}
+
+	if config.RetryCount != 0 {
+		opts = append(opts, WithRetryCount(config.RetryCount))
+	}
+
+	if config.GracePeriod != 0 {
+		opts = append(opts, WithGracePeriod(config.GracePeriod))
+	}
+
+	if config.ResolvePaths {
+		opts = append(opts, WithResolvePaths(config.PathExtensions...))
+	}
+
+	if config.ExplicitPathsOnly {
+		opts = append(opts, WithExplicitPathsOnly())
+	}
+
+	if config.Temperature != nil {
+		opts = append(opts, WithTemperature(*config.Temperature))
+	}
+
+	if config.MaxOutputTokens != nil {
+		opts = append(opts, WithMaxOutputTokens(*config.MaxOutputTokens))
+	}
+
+	if config.ReturnPartialOnTimeout {
+		opts = append(opts, WithReturnPartialOnTimeout())
+	}
+
+	if config.ValidateFileReferences {
+		opts = append(opts, WithValidateFileReferences())
+	}
+
+	if config.DryRun {
+		opts = append(opts, WithDryRun())
+	}
+
+	if config.OmitModelFlag {
+		opts = append(opts, WithOmitModelFlag())
+	}
+
+	if config.PreserveBlankLines {
+		opts = append(opts, WithPreserveBlankLines())
+	}
+
+	if config.StripPromptEcho {
+		opts = append(opts, WithStripPromptEcho())
+	}
+
+	if config.AllFiles {
+		opts = append(opts, WithAllFiles())
+	}
+
+	if config.Sandbox {
+		opts = append(opts, WithSandbox())
+	}
+
+	if config.StripANSI {
+		opts = append(opts, WithStripANSI())
+	}
+
+	if config.LogOutputLines {
+		opts = append(opts, WithLogOutputLines())
+	}
+
+	if config.IncludePromptInErrors {
+		opts = append(opts, WithIncludePromptInErrors())
+	}
+
+	if config.RetryOnEmptyOutput {
+		opts = append(opts, WithRetryOnEmptyOutput())
+	}
+
+	if config.LogStderr {
+		opts = append(opts, WithLogStderr())
+	}
+
+	if config.Debug {
+		opts = append(opts, WithDebug())
+	}
+
+	if config.StrictWorkingDir {
+		opts = append(opts, WithStrictWorkingDir())
+	}
+
+	if config.PromptViaStdin {
+		opts = append(opts, WithPromptViaStdin(config.StdinThreshold))
+	}
+
+	return opts
+}
+
+// validate reports configuration values that NewClientWithConfig would
+// silently ignore or that would only fail once a command runs
+func (config Config) validate() error {
+	var errs []error
+
+	if config.Timeout < 0 {
+		errs = append(errs, fmt.Errorf("%w: Timeout must not be negative, got %v", ErrInvalidConfig, config.Timeout))
+	}
+
+	if config.MaxOutputBytes < 0 {
+		errs = append(errs, fmt.Errorf("%w: MaxOutputBytes must not be negative, got %d", ErrInvalidConfig, config.MaxOutputBytes))
+	}
+
+	if config.IdempotencyWindow < 0 {
+		errs = append(errs, fmt.Errorf("%w: IdempotencyWindow must not be negative, got %v", ErrInvalidConfig, config.IdempotencyWindow))
+	}
+
+	if config.RetryBudget.Retries < 0 || config.RetryBudget.Window < 0 {
+		errs = append(errs, fmt.Errorf("%w: RetryBudget must not be negative, got %+v", ErrInvalidConfig, config.RetryBudget))
+	}
+
+	if config.TotalDeadline < 0 {
+		errs = append(errs, fmt.Errorf("%w: TotalDeadline must not be negative, got %v", ErrInvalidConfig, config.TotalDeadline))
+	}
+
+	if config.WorkingDirectory != "" {
+		info, err := os.Stat(config.WorkingDirectory)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%w: WorkingDirectory: %w", ErrInvalidConfig, err))
+		} else if !info.IsDir() {
+			errs = append(errs, fmt.Errorf("%w: WorkingDirectory %s is not a directory", ErrInvalidConfig, config.WorkingDirectory))
+		}
+	}
+
+	if config.FallbackDirectory != "" {
+		if err := checkWorkingDir(config.FallbackDirectory); err != nil {
+			errs = append(errs, fmt.Errorf("%w: FallbackDirectory: %w", ErrInvalidConfig, err))
+		}
+	}
+
+	if config.ConfigPath != "" {
+		info, err := os.Stat(config.ConfigPath)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%w: ConfigPath: %w", ErrInvalidConfig, err))
+		} else if info.IsDir() {
+			errs = append(errs, fmt.Errorf("%w: ConfigPath %s is a directory", ErrInvalidConfig, config.ConfigPath))
+		}
+	}
+
+	if config.APIKeyFile != "" {
+		if _, err := readAPIKeyFile(config.APIKeyFile); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if config.BinaryPath != "" && strings.TrimSpace(config.BinaryPath) == "" {
+		errs = append(errs, fmt.Errorf("%w: BinaryPath is set but blank", ErrInvalidConfig))
+	}
+
+	if err := checkSampling(config.Temperature, config.MaxOutputTokens); err != nil {
+		errs = append(errs, fmt.Errorf("%w: %w", ErrInvalidConfig, err))
+	}
+
+	if config.Extensions != nil && len(config.Extensions) == 0 {
+		errs = append(errs, fmt.Errorf("%w: Extensions is set but empty", ErrInvalidConfig))
+	}
+	for i, name := range config.Extensions {
+		if strings.TrimSpace(name) == "" {
+			errs = append(errs, fmt.Errorf("%w: Extensions[%d] is blank", ErrInvalidConfig, i))
+		}
+	}
+
+	for i, re := range config.FilterRegexps {
+		if re == nil {
+			errs = append(errs, fmt.Errorf("%w: FilterRegexps[%d] is nil", ErrInvalidConfig, i))
+		}
+	}
+
+	return errors.Join(errs...)
+}
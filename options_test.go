@@ -0,0 +1,252 @@
+package geminicli
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestNewClientWithOptions tests functional option construction
+func TestNewClientWithOptions(t *testing.T) {
+	t.Run("NoOptionsMatchesDefaults", func(t *testing.T) {
+		client := NewClient()
+
+		if client.timeout != DefaultTimeout {
+			t.Errorf("Expected default timeout %v, got %v", DefaultTimeout, client.timeout)
+		}
+		if client.model != DefaultModel {
+			t.Errorf("Expected default model '%s', got '%s'", DefaultModel, client.model)
+		}
+		if client.workingDirectory != "" {
+			t.Errorf("Expected empty working directory, got '%s'", client.workingDirectory)
+		}
+		if client.logger == nil {
+			t.Error("Expected default logger to be set")
+		}
+	})
+
+	t.Run("OptionsCompose", func(t *testing.T) {
+		logger := NewNoOpLogger()
+		client := NewClient(
+			WithTimeout(time.Minute),
+			WithModel("gemini-2.5-pro"),
+			WithWorkingDirectory("/tmp"),
+			WithLogger(logger),
+			WithRetryCount(0),
+		)
+
+		if client.timeout != time.Minute {
+			t.Errorf("Expected timeout %v, got %v", time.Minute, client.timeout)
+		}
+		if client.model != "gemini-2.5-pro" {
+			t.Errorf("Expected model 'gemini-2.5-pro', got '%s'", client.model)
+		}
+		if client.workingDirectory != "/tmp" {
+			t.Errorf("Expected working directory '/tmp', got '%s'", client.workingDirectory)
+		}
+		if fields, ok := client.logger.(*fieldLogger); !ok || fields.base != logger {
+			t.Error("Expected custom logger to be used")
+		}
+		if client.retryCount != 0 {
+			t.Errorf("Expected retries to be disabled, got %d", client.retryCount)
+		}
+	})
+
+	t.Run("ZeroValuesKeepDefaults", func(t *testing.T) {
+		client := NewClient(WithTimeout(0), WithModel(""), WithLogger(nil))
+
+		if client.timeout != DefaultTimeout {
+			t.Errorf("Expected default timeout %v, got %v", DefaultTimeout, client.timeout)
+		}
+		if client.model != DefaultModel {
+			t.Errorf("Expected default model '%s', got '%s'", DefaultModel, client.model)
+		}
+		if client.logger == nil {
+			t.Error("Expected default logger to be kept")
+		}
+	})
+}
+
+// TestNewClientWithConfigValidated tests configuration validation at construction time
+func TestNewClientWithConfigValidated(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "file.txt")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		config      Config
+		expectError bool
+		description string
+	}{
+		{
+			name:        "ZeroConfig",
+			config:      Config{},
+			expectError: false,
+			description: "An empty Config should be valid",
+		},
+		{
+			name:        "ValidConfig",
+			config:      Config{Timeout: time.Minute, WorkingDirectory: dir, BinaryPath: "/usr/local/bin/gemini", ConfigPath: file},
+			expectError: false,
+			description: "Valid values should be accepted",
+		},
+		{
+			name:        "NegativeTimeout",
+			config:      Config{Timeout: -time.Second},
+			expectError: true,
+			description: "Negative timeout should be rejected",
+		},
+		{
+			name:        "NegativeRetryBudget",
+			config:      Config{RetryBudget: RetryBudgetConfig{Retries: 10, Window: -time.Minute}},
+			expectError: true,
+			description: "A negative retry budget window should be rejected",
+		},
+		{
+			name:        "NilFilterRegexp",
+			config:      Config{FilterRegexps: []*regexp.Regexp{regexp.MustCompile("x"), nil}},
+			expectError: true,
+			description: "Nil filter regexps should be rejected",
+		},
+		{
+			name:        "EmptyExtensions",
+			config:      Config{Extensions: []string{}},
+			expectError: true,
+			description: "A set but empty extension list should be rejected",
+		},
+		{
+			name:        "BlankExtension",
+			config:      Config{Extensions: []string{"github", " "}},
+			expectError: true,
+			description: "Blank extension names should be rejected",
+		},
+		{
+			name:        "MissingWorkingDirectory",
+			config:      Config{WorkingDirectory: filepath.Join(dir, "missing")},
+			expectError: true,
+			description: "Non-existent working directory should be rejected",
+		},
+		{
+			name:        "WorkingDirectoryIsFile",
+			config:      Config{WorkingDirectory: file},
+			expectError: true,
+			description: "A file is not a valid working directory",
+		},
+		{
+			name:        "MissingConfigPath",
+			config:      Config{ConfigPath: filepath.Join(dir, "settings.json")},
+			expectError: true,
+			description: "Non-existent settings file should be rejected",
+		},
+		{
+			name:        "ConfigPathIsDirectory",
+			config:      Config{ConfigPath: dir},
+			expectError: true,
+			description: "A directory is not a valid settings file",
+		},
+		{
+			name:        "BlankBinaryPath",
+			config:      Config{BinaryPath: "   "},
+			expectError: true,
+			description: "Blank binary path should be rejected",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := NewClientWithConfigValidated(tt.config)
+
+			if tt.expectError {
+				if !errors.Is(err, ErrInvalidConfig) {
+					t.Errorf("%s: expected ErrInvalidConfig, got %v", tt.description, err)
+				}
+				if client != nil {
+					t.Errorf("%s: expected nil client", tt.description)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("%s: unexpected error: %v", tt.description, err)
+			}
+			if client == nil {
+				t.Fatalf("%s: expected a client", tt.description)
+			}
+		})
+	}
+
+	t.Run("ReportsAllProblems", func(t *testing.T) {
+		_, err := NewClientWithConfigValidated(Config{
+			Timeout:          -time.Second,
+			WorkingDirectory: filepath.Join(dir, "missing"),
+		})
+		if !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("Expected wrapped fs.ErrNotExist, got %v", err)
+		}
+		if err == nil || !strings.Contains(err.Error(), "Timeout") {
+			t.Errorf("Expected timeout problem to be reported too, got %v", err)
+		}
+	})
+}
+
+// TestBinaryPath tests overriding the gemini executable
+func TestBinaryPath(t *testing.T) {
+	runner := &fakeRunner{}
+	client := NewClientWithConfig(Config{BinaryPath: "/opt/gemini/bin/gemini", Runner: runner})
+
+	if _, err := client.Execute("hello"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if name := runner.invocations()[0].Name; name != "/opt/gemini/bin/gemini" {
+		t.Errorf("Expected binary '/opt/gemini/bin/gemini', got '%s'", name)
+	}
+}
+
+// TestFlagNames tests overriding the prompt and model flag names
+func TestFlagNames(t *testing.T) {
+	tests := []struct {
+		name         string
+		config       Config
+		expectedArgs string
+		description  string
+	}{
+		{
+			name:         "Default",
+			config:       Config{},
+			expectedArgs: "-m gemini-2.5-pro -p hello",
+			description:  "The short flags should be used by default",
+		},
+		{
+			name:         "LongFlags",
+			config:       Config{PromptFlag: "--prompt", ModelFlag: "--model"},
+			expectedArgs: "--model gemini-2.5-pro --prompt hello",
+			description:  "Configured flag names should replace the defaults",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := &fakeRunner{}
+			tt.config.Model = "gemini-2.5-pro"
+			tt.config.Runner = runner
+			client := NewClientWithConfig(tt.config)
+
+			if _, err := client.Execute("hello"); err != nil {
+				t.Fatalf("%s: unexpected error: %v", tt.description, err)
+			}
+			if args := strings.Join(runner.invocations()[0].Args, " "); args != tt.expectedArgs {
+				t.Errorf("%s: expected args '%s', got '%s'", tt.description, tt.expectedArgs, args)
+			}
+			if cmd := client.buildGeminiCommandWithModel("hello"); strings.Join(cmd[1:], " ") != tt.expectedArgs {
+				t.Errorf("%s: expected built command args '%s', got %v", tt.description, tt.expectedArgs, cmd)
+			}
+		})
+	}
+}
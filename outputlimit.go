@@ -0,0 +1,71 @@
+package geminicli
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+)
+
+// outputLimit sits in front of a process's stdout and cancels the run once
+// more than limit bytes have been written, so a runaway response cannot
+// exhaust memory
+type outputLimit struct {
+	next   io.Writer
+	limit  int
+	cancel context.CancelCauseFunc
+
+	mu      sync.Mutex
+	written int
+}
+
+// limitOutput wraps inv.Stdout with an outputLimit when MaxOutputBytes is
+// set, deriving a context that is cancelled with ErrOutputTooLarge once the
+// limit is exceeded. The returned stop function must be called once the
+// process has exited.
+func (c *Client) limitOutput(ctx context.Context, inv *Invocation) (context.Context, func()) {
+	if c.maxOutputBytes <= 0 {
+		return ctx, func() {}
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	inv.Stdout = &outputLimit{next: inv.Stdout, limit: c.maxOutputBytes, cancel: cancel}
+	return ctx, func() { cancel(nil) }
+}
+
+// Write forwards p to the wrapped writer up to the limit. Once the limit is
+// exceeded the run is cancelled and an error is returned so the process's
+// output is no longer consumed.
+func (w *outputLimit) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	remaining := w.limit - w.written
+	w.written += len(p)
+	w.mu.Unlock()
+
+	if remaining <= 0 {
+		w.cancel(ErrOutputTooLarge)
+		return 0, ErrOutputTooLarge
+	}
+	if len(p) > remaining {
+		if w.next != nil {
+			w.next.Write(p[:remaining])
+		}
+		w.cancel(ErrOutputTooLarge)
+		return remaining, ErrOutputTooLarge
+	}
+
+	if w.next == nil {
+		return len(p), nil
+	}
+	return w.next.Write(p)
+}
+
+// outputTooLarge reports whether a run produced more output than the
+// configured limit, either because the process was cancelled by an
+// outputLimit or because the runner returned the full output regardless
+func (c *Client) outputTooLarge(ctx context.Context, stdout []byte) bool {
+	if c.maxOutputBytes <= 0 {
+		return false
+	}
+	return len(stdout) > c.maxOutputBytes || errors.Is(context.Cause(ctx), ErrOutputTooLarge)
+}
@@ -0,0 +1,100 @@
+package geminicli
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestMaxOutputBytes tests failing runs whose output exceeds MaxOutputBytes
+func TestMaxOutputBytes(t *testing.T) {
+	tests := []struct {
+		name        string
+		output      string
+		limit       int
+		expectError bool
+		description string
+	}{
+		{
+			name:        "Unlimited",
+			output:      strings.Repeat("a", 1000),
+			description: "Output of any size should be accepted by default",
+		},
+		{
+			name:        "AtLimit",
+			output:      strings.Repeat("a", 100),
+			limit:       100,
+			description: "Output exactly at the limit should be accepted",
+		},
+		{
+			name:        "OverLimit",
+			output:      strings.Repeat("a", 101),
+			limit:       100,
+			expectError: true,
+			description: "Output past the limit should fail with the truncated output",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := &fakeRunner{run: func(ctx context.Context, inv Invocation) ([]byte, []byte, error) {
+				return []byte(tt.output), nil, nil
+			}}
+			client := NewClientWithConfig(Config{MaxOutputBytes: tt.limit, Runner: runner})
+
+			result, err := client.ExecuteResult("test prompt")
+			if !tt.expectError {
+				if err != nil {
+					t.Fatalf("%s: unexpected error: %v", tt.description, err)
+				}
+				if result.Text != tt.output {
+					t.Errorf("%s: expected full output, got %d bytes", tt.description, len(result.Text))
+				}
+				if result.ExitCode != 0 {
+					t.Errorf("%s: expected exit code 0, got %d", tt.description, result.ExitCode)
+				}
+				return
+			}
+
+			var tooLarge *OutputTooLargeError
+			if !errors.As(err, &tooLarge) || !errors.Is(err, ErrOutputTooLarge) {
+				t.Fatalf("%s: expected OutputTooLargeError, got %v", tt.description, err)
+			}
+			if tooLarge.Limit != tt.limit || tooLarge.Output != tt.output[:tt.limit] {
+				t.Errorf("%s: expected %d bytes of output, got %d", tt.description, tt.limit, len(tooLarge.Output))
+			}
+			if result == nil || len(result.RawOutput) != tt.limit {
+				t.Fatalf("%s: expected a partial result truncated to the limit, got %+v", tt.description, result)
+			}
+			if result.ExitCode != -1 {
+				t.Errorf("%s: expected the partial result to carry exit code -1, got %d", tt.description, result.ExitCode)
+			}
+			if len(runner.invocations()) != 1 {
+				t.Errorf("%s: expected no retries, got %d invocations", tt.description, len(runner.invocations()))
+			}
+		})
+	}
+}
+
+// TestMaxOutputBytesKillsProcess tests that a runaway gemini is killed once
+// it passes the limit instead of running to completion
+func TestMaxOutputBytesKillsProcess(t *testing.T) {
+	installFakeGemini(t, `while true; do echo "runaway output line"; done`)
+	client := NewClientWithConfig(Config{MaxOutputBytes: 4096, Timeout: 10 * time.Second, GracePeriod: -1})
+
+	start := time.Now()
+	_, err := client.Execute("hello")
+	if !errors.Is(err, ErrOutputTooLarge) {
+		t.Fatalf("Expected ErrOutputTooLarge, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected the process to be killed promptly, took %v", elapsed)
+	}
+
+	var tooLarge *OutputTooLargeError
+	if errors.As(err, &tooLarge) && len(tooLarge.Output) != 4096 {
+		t.Errorf("Expected output truncated to 4096 bytes, got %d", len(tooLarge.Output))
+	}
+}
@@ -0,0 +1,117 @@
+package geminicli
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+	"sync"
+)
+
+// outputLineLogger sits in front of a process's stdout and logs every
+// non-filtered line at Info level as soon as it is complete
+type outputLineLogger struct {
+	next   io.Writer
+	client *Client
+
+	mu      sync.Mutex
+	partial []byte // Bytes after the last newline
+	lines   int
+}
+
+// logOutput wraps inv.Stdout with an outputLineLogger when
+// LogOutputLines is set. The returned flush function logs a final line
+// without a trailing newline and must be called once the process has exited.
+func (c *Client) logOutput(inv *Invocation) func() {
+	if !c.logOutputLines {
+		return func() {}
+	}
+
+	w := &outputLineLogger{next: inv.Stdout, client: c}
+	inv.Stdout = w
+	return w.flush
+}
+
+// Write logs each complete line in p and forwards p to the wrapped writer
+func (w *outputLineLogger) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	w.partial = append(w.partial, p...)
+	for {
+		i := bytes.IndexByte(w.partial, '\n')
+		if i < 0 {
+			break
+		}
+		w.log(string(bytes.TrimSuffix(w.partial[:i], []byte("\r"))))
+		w.partial = w.partial[i+1:]
+	}
+	w.mu.Unlock()
+
+	if w.next == nil {
+		return len(p), nil
+	}
+	return w.next.Write(p)
+}
+
+// flush logs any trailing line that was not newline-terminated
+func (w *outputLineLogger) flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.partial) > 0 {
+		w.log(string(w.partial))
+		w.partial = nil
+	}
+}
+
+// log records line unless output filtering would remove it. Must be called
+// with w.mu held.
+func (w *outputLineLogger) log(line string) {
+	w.lines++
+	if w.client.stripANSI {
+		line = StripANSI(line)
+	}
+	if w.client.shouldFilterLine(line) {
+		return
+	}
+	w.client.logger.InfoWith("Gemini output line", "line_number", w.lines, "line", line)
+}
+
+// logStderrLines logs each non-blank line of a finished process's stderr at
+// Info level when LogStderr is set, whatever its exit status. Lines are not
+// filtered, since gemini's warnings are what LogStderr is for.
+func (c *Client) logStderrLines(stderr []byte) {
+	if !c.logStderr {
+		return
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(stderr))
+	scanner.Buffer(nil, len(stderr)+1)
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if c.stripANSI {
+			line = StripANSI(line)
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		c.logger.InfoWith("Gemini stderr line", "stderr", line)
+	}
+}
+
+// logDebugLines logs the "[DEBUG]" lines gemini printed on stdout at Info
+// level when both Debug and LogStderr are set. Output filtering removes them
+// from responses, so this is the only place they are seen.
+func (c *Client) logDebugLines(stdout []byte) {
+	if !c.debug || !c.logStderr {
+		return
+	}
+
+	for _, line := range strings.Split(string(stdout), "\n") {
+		if c.stripANSI {
+			line = StripANSI(line)
+		}
+		line = strings.TrimSpace(line)
+		if debugLinePattern.MatchString(line) {
+			c.logger.InfoWith("Gemini debug line", "debug", line)
+		}
+	}
+}
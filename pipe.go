@@ -0,0 +1,61 @@
+package geminicli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+)
+
+// ExecutePipe starts gemini for prompt and returns its raw stdout together
+// with the running command, for consumers that need their own framing. The
+// caller must read stdout to EOF and then call cmd.Wait, which closes the
+// pipe and releases the process; cmd.Process.Kill stops it early. Output is
+// not filtered and stderr is discarded. The prompt is checked and prepared
+// as for Execute, but no timeout applies and there are no retries or
+// AfterExecute hook; use ExecutePipeContext to bound the process.
+// ExecutePipe needs the default ExecRunner and fails with ErrUnsupported
+// when a custom Runner or DryRun is configured.
+func (c *Client) ExecutePipe(prompt string) (io.ReadCloser, *exec.Cmd, error) {
+	return c.ExecutePipeContext(context.Background(), prompt)
+}
+
+// ExecutePipeContext is ExecutePipe with the process bound to ctx: once ctx
+// is done gemini and the tools it started are stopped as on a timeout
+func (c *Client) ExecutePipeContext(ctx context.Context, prompt string) (io.ReadCloser, *exec.Cmd, error) {
+	runner, ok := c.runner.(*ExecRunner)
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: ExecutePipe needs the default ExecRunner", ErrUnsupported)
+	}
+	if c.dryRun {
+		return nil, nil, fmt.Errorf("%w: ExecutePipe cannot be used with DryRun", ErrUnsupported)
+	}
+
+	opts := c.defaultExecOptions(prompt)
+	execPrompt, err := c.preparePrompt(prompt, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	inv, err := c.newInvocation(execPrompt, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := c.waitLimiter(ctx); err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", ErrCommandFailed, err)
+	}
+
+	cmd, err := runner.command(ctx, inv)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", ErrCommandFailed, err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", ErrCommandFailed, err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", ErrCommandFailed, &startError{message: ErrCommandStart, err: err})
+	}
+
+	c.logger.DebugWith("Started Gemini command with stdout pipe", "pid", cmd.Process.Pid)
+	return stdout, cmd, nil
+}
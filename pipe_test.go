@@ -0,0 +1,91 @@
+package geminicli
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestExecutePipe tests direct access to the gemini stdout pipe
+func TestExecutePipe(t *testing.T) {
+	t.Run("ReadsRawOutput", func(t *testing.T) {
+		installFakeGemini(t, `echo 'Loaded cached credentials.'; echo "prompt: $4"`)
+
+		stdout, cmd, err := NewClient().ExecutePipe("hello")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		data, err := io.ReadAll(stdout)
+		if err != nil {
+			t.Fatalf("Failed to read stdout: %v", err)
+		}
+		if err := cmd.Wait(); err != nil {
+			t.Fatalf("Unexpected wait error: %v", err)
+		}
+		if string(data) != "Loaded cached credentials.\nprompt: hello\n" {
+			t.Errorf("Expected unfiltered output, got %q", data)
+		}
+	})
+
+	t.Run("ContextStopsProcess", func(t *testing.T) {
+		installFakeGemini(t, "echo started; exec sleep 10")
+
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		stdout, cmd, err := NewClientWithConfig(Config{GracePeriod: -1}).ExecutePipeContext(ctx, "hello")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		io.Copy(io.Discard, stdout)
+		if err := cmd.Wait(); err == nil {
+			t.Error("Expected the stopped process to report an error")
+		}
+		if elapsed := time.Since(start); elapsed > 5*time.Second {
+			t.Errorf("Expected the process to stop with ctx, took %v", elapsed)
+		}
+	})
+
+	t.Run("EmptyPrompt", func(t *testing.T) {
+		if _, _, err := NewClient().ExecutePipe(""); err == nil || err.Error() != ErrEmptyPrompt {
+			t.Errorf("Expected empty prompt error, got %v", err)
+		}
+	})
+
+	t.Run("CustomRunner", func(t *testing.T) {
+		if _, _, err := NewClient(WithRunner(&fakeRunner{})).ExecutePipe("hello"); !errors.Is(err, ErrUnsupported) {
+			t.Errorf("Expected ErrUnsupported, got %v", err)
+		}
+	})
+
+	t.Run("MissingBinary", func(t *testing.T) {
+		_, _, err := NewClientWithConfig(Config{BinaryPath: "gemini-does-not-exist"}).ExecutePipe("hello")
+		if !isStartError(err) {
+			t.Errorf("Expected a start error, got %v", err)
+		}
+	})
+}
+
+// TestExecutePipeDryRun tests that ExecutePipe refuses to start gemini in
+// dry run mode
+func TestExecutePipeDryRun(t *testing.T) {
+	marker := filepath.Join(t.TempDir(), "ran")
+	installFakeGemini(t, "touch "+marker)
+	client := NewClientWithConfig(Config{DryRun: true})
+
+	stdout, cmd, err := client.ExecutePipe("hello")
+	if !errors.Is(err, ErrUnsupported) {
+		t.Errorf("Expected ErrUnsupported, got %v", err)
+	}
+	if stdout != nil || cmd != nil {
+		t.Error("Expected no pipe or command in dry run mode")
+	}
+	if _, err := os.Stat(marker); err == nil {
+		t.Error("Expected gemini not to run in dry run mode")
+	}
+}
@@ -0,0 +1,77 @@
+package geminicli
+
+import (
+	"maps"
+	"time"
+)
+
+// redactedAPIKey replaces an API key read from APIKeyFile in a Plan
+const redactedAPIKey = "[REDACTED]"
+
+// Plan describes what Execute would run for a prompt, with every setting
+// resolved. Unlike DryRun output it is structured, so it can be asserted in
+// tests or logged as JSON.
+type Plan struct {
+	Binary      string            // gemini executable
+	Model       string            // Model requested, empty with OmitModelFlag
+	Dir         string            // Working directory gemini runs in
+	Prompt      string            // Prompt sent, after BeforeExecute and path resolution
+	Stdin       bool              // Whether the prompt is written to stdin
+	Command     []string          // Full argv, starting with Binary
+	CommandLine string            // Command shell-quoted, as DryRun returns it
+	Env         map[string]string // Variables set on top of the inherited environment; a key from APIKeyFile is redacted
+	Timeout     time.Duration     // Per-attempt timeout, 0 for none
+	Retries     int               // Maximum retries after the first attempt
+}
+
+// Explain validates prompt and returns the plan Execute would follow for it,
+// without running gemini. The BeforeExecute hook is applied because it can
+// change the prompt.
+func (c *Client) Explain(prompt string) (*Plan, error) {
+	opts := c.defaultExecOptions(prompt)
+	execPrompt, err := c.preparePrompt(prompt, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := c.invocationDir(opts.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	resolvedPrompt := c.resolvePrompt(execPrompt, opts)
+	command := c.geminiArgs(opts.model, opts.format, resolvedPrompt, opts.stdin)
+
+	env := maps.Clone(c.env)
+	if c.apiKeyFromFile {
+		env[GeminiAPIKeyEnv] = redactedAPIKey
+	}
+
+	return &Plan{
+		Binary:      c.binaryPath,
+		Model:       opts.model,
+		Dir:         dir,
+		Prompt:      resolvedPrompt,
+		Stdin:       opts.stdin,
+		Command:     command,
+		CommandLine: formatCommandLine(command),
+		Env:         env,
+		Timeout:     opts.timeout,
+		Retries:     max(c.retryCount, 0),
+	}, nil
+}
+
+// CommandString returns the command Execute would run for prompt as a
+// single shell-quoted line that can be pasted into a POSIX shell. With the
+// prompt sent on stdin, it is piped in with printf. The working directory
+// and environment overrides are not included; see Explain for those.
+func (c *Client) CommandString(prompt string) (string, error) {
+	plan, err := c.Explain(prompt)
+	if err != nil {
+		return "", err
+	}
+	if plan.Stdin {
+		return "printf '%s' " + shellQuote(plan.Prompt) + " | " + plan.CommandLine, nil
+	}
+	return plan.CommandLine, nil
+}
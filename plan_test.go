@@ -0,0 +1,139 @@
+package geminicli
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestExplain tests that Explain reports the effective settings without running gemini
+func TestExplain(t *testing.T) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get current directory: %v", err)
+	}
+	dir := t.TempDir()
+
+	runner := &fakeRunner{}
+	client := NewClientWithConfig(Config{
+		Model:            "gemini-2.5-pro",
+		WorkingDirectory: dir,
+		ResolvePaths:     true,
+		Env:              map[string]string{"GEMINI_API_KEY": "secret"},
+		Timeout:          time.Minute,
+		RetryCount:       2,
+		BeforeExecute: func(prompt string) (string, error) {
+			return strings.ToUpper(prompt[:1]) + prompt[1:], nil
+		},
+		Runner: runner,
+	})
+
+	plan, err := client.Explain("review ./main.go")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expectedPrompt := "Review " + filepath.Join(cwd, "main.go")
+	if plan.Prompt != expectedPrompt {
+		t.Errorf("Expected prompt '%s', got '%s'", expectedPrompt, plan.Prompt)
+	}
+	expectedCommand := []string{"gemini", "-m", "gemini-2.5-pro", "-p", expectedPrompt}
+	if strings.Join(plan.Command, "\x00") != strings.Join(expectedCommand, "\x00") {
+		t.Errorf("Expected command %q, got %q", expectedCommand, plan.Command)
+	}
+	if plan.CommandLine != formatCommandLine(expectedCommand) {
+		t.Errorf("Expected command line to match DryRun, got '%s'", plan.CommandLine)
+	}
+	if plan.Binary != "gemini" || plan.Model != "gemini-2.5-pro" || plan.Dir != dir {
+		t.Errorf("Unexpected binary, model or dir: %+v", plan)
+	}
+	if plan.Env["GEMINI_API_KEY"] != "secret" || len(plan.Env) != 1 {
+		t.Errorf("Expected only the configured environment overrides, got %v", plan.Env)
+	}
+	if plan.Timeout != time.Minute || plan.Retries != 2 || plan.Stdin {
+		t.Errorf("Unexpected timeout, retries or stdin: %+v", plan)
+	}
+	if len(runner.invocations()) != 0 {
+		t.Error("Expected Explain not to run gemini")
+	}
+
+	if _, err := json.Marshal(plan); err != nil {
+		t.Errorf("Expected the plan to marshal as JSON: %v", err)
+	}
+}
+
+// TestExplainValidation tests that Explain rejects prompts Execute would reject
+func TestExplainValidation(t *testing.T) {
+	client := NewClientWithConfig(Config{MaxPromptLength: 3, Runner: &fakeRunner{}})
+
+	if _, err := client.Explain(""); err == nil {
+		t.Error("Expected error for empty prompt")
+	}
+	if _, err := client.Explain("hello"); err == nil {
+		t.Error("Expected error for prompt over the length limit")
+	}
+}
+
+// TestCommandString tests that the shell command round-trips through sh
+func TestCommandString(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      Config
+		prompt      string
+		description string
+	}{
+		{
+			name:        "Plain",
+			prompt:      "hello",
+			description: "A simple prompt should be passed as is",
+		},
+		{
+			name:        "SpecialCharacters",
+			config:      Config{Model: "gemini 2.5 pro"},
+			prompt:      `it's "quoted" $HOME ` + "`date`" + ` ; rm -rf / && echo *`,
+			description: "Quotes and shell metacharacters should survive",
+		},
+		{
+			name:        "Stdin",
+			config:      Config{PromptViaStdin: true},
+			prompt:      "line one\nit's line two",
+			description: "Stdin prompts should be piped in",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.BinaryPath = "echoargs"
+			client := NewClientWithConfig(tt.config)
+			command, err := client.CommandString(tt.prompt)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			// Run the line with a stand-in binary printing each argument and
+			// stdin NUL-separated
+			script := `echoargs() { for a in "$@"; do printf '%s\0' "$a"; done; [ -t 0 ] || cat; }; ` + command
+			output, err := exec.Command("sh", "-c", script).Output()
+			if err != nil {
+				t.Fatalf("%s: command %q failed: %v", tt.description, command, err)
+			}
+
+			plan, _ := client.Explain(tt.prompt)
+			expected := strings.Join(plan.Command[1:], "\x00") + "\x00"
+			if plan.Stdin {
+				expected += tt.prompt
+			}
+			if string(output) != expected {
+				t.Errorf("%s: expected %q, got %q from %q", tt.description, expected, output, command)
+			}
+		})
+	}
+
+	if _, err := NewClient().CommandString(""); err == nil {
+		t.Error("Expected error for empty prompt")
+	}
+}
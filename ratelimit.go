@@ -0,0 +1,94 @@
+package geminicli
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Limiter paces gemini invocations. Wait blocks until a request may start or
+// ctx is done. *golang.org/x/time/rate.Limiter satisfies it, as does
+// *RateLimiter; share one value between clients to enforce a combined limit.
+type Limiter interface {
+	Wait(ctx context.Context) error
+}
+
+// RateLimiter is a token bucket Limiter that allows requestsPerSecond on
+// average with bursts of up to burst requests. It is safe for concurrent use.
+type RateLimiter struct {
+	rate  float64 // Tokens added per second
+	burst float64 // Bucket capacity
+
+	mu     sync.Mutex
+	tokens float64   // Available tokens; negative while waiters hold reservations
+	last   time.Time // When tokens was last refilled
+}
+
+// NewRateLimiter returns a RateLimiter allowing requestsPerSecond with the
+// given burst. A burst below 1 is treated as 1.
+func NewRateLimiter(requestsPerSecond float64, burst int) *RateLimiter {
+	burst = max(burst, 1)
+	return &RateLimiter{
+		rate:   requestsPerSecond,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// Wait blocks until a token is available or ctx is done. A limiter with a
+// non-positive rate never blocks.
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	if l.rate <= 0 {
+		return nil
+	}
+
+	delay := l.reserve()
+	if delay <= 0 {
+		return nil
+	}
+	if err := sleepContext(ctx, delay); err != nil {
+		l.cancel()
+		return err
+	}
+	return nil
+}
+
+// reserve takes a token, possibly going into debt, and returns how long the
+// caller must wait before using it
+func (l *RateLimiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+	l.tokens--
+	if l.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-l.tokens / l.rate * float64(time.Second))
+}
+
+// cancel returns a reserved token after the caller gave up waiting
+func (l *RateLimiter) cancel() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.tokens = min(l.burst, l.tokens+1)
+}
+
+// waitLimiter blocks until the configured limiter lets a gemini process start
+func (c *Client) waitLimiter(ctx context.Context) error {
+	if c.limiter == nil {
+		return nil
+	}
+	start := time.Now()
+	if err := c.limiter.Wait(ctx); err != nil {
+		return fmt.Errorf("%s: %w", ErrRateLimitWait, err)
+	}
+	if waited := time.Since(start); waited > time.Millisecond {
+		c.logger.DebugWith("Rate limiter delayed Gemini command", "waited", waited)
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package geminicli
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestRateLimiter tests token bucket pacing
+func TestRateLimiter(t *testing.T) {
+	tests := []struct {
+		name        string
+		rate        float64
+		burst       int
+		requests    int
+		minElapsed  time.Duration
+		maxElapsed  time.Duration
+		description string
+	}{
+		{
+			name:        "WithinBurst",
+			rate:        1,
+			burst:       3,
+			requests:    3,
+			maxElapsed:  50 * time.Millisecond,
+			description: "Requests within the burst should not wait",
+		},
+		{
+			name:        "BeyondBurst",
+			rate:        50,
+			burst:       1,
+			requests:    4,
+			minElapsed:  55 * time.Millisecond,
+			maxElapsed:  time.Second,
+			description: "Requests beyond the burst should be spaced by the rate",
+		},
+		{
+			name:        "Unlimited",
+			rate:        0,
+			requests:    100,
+			maxElapsed:  50 * time.Millisecond,
+			description: "A non-positive rate should never block",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := NewRateLimiter(tt.rate, tt.burst)
+
+			start := time.Now()
+			for i := 0; i < tt.requests; i++ {
+				if err := limiter.Wait(context.Background()); err != nil {
+					t.Fatalf("%s: unexpected error: %v", tt.description, err)
+				}
+			}
+			elapsed := time.Since(start)
+
+			if elapsed < tt.minElapsed || elapsed > tt.maxElapsed {
+				t.Errorf("%s: expected elapsed in [%v, %v], got %v", tt.description, tt.minElapsed, tt.maxElapsed, elapsed)
+			}
+		})
+	}
+}
+
+// TestRateLimiterContext tests that waiting respects cancellation
+func TestRateLimiterContext(t *testing.T) {
+	limiter := NewRateLimiter(0.1, 1)
+	limiter.Wait(context.Background())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := limiter.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+}
+
+// TestClientRateLimit tests that a shared client enforces its limit across goroutines
+func TestClientRateLimit(t *testing.T) {
+	var mu sync.Mutex
+	var starts []time.Time
+	runner := &fakeRunner{run: func(ctx context.Context, inv Invocation) ([]byte, []byte, error) {
+		mu.Lock()
+		starts = append(starts, time.Now())
+		mu.Unlock()
+		return []byte("fake response"), nil, nil
+	}}
+	client := NewClientWithConfig(Config{RateLimit: 50, Runner: runner})
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := client.Execute("hello"); err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// One request starts immediately, the other four wait 20ms each
+	if elapsed := time.Since(start); elapsed < 75*time.Millisecond {
+		t.Errorf("Expected 5 requests at 50/s to take at least 80ms, took %v", elapsed)
+	}
+	if len(starts) != 5 {
+		t.Errorf("Expected 5 gemini runs, got %d", len(starts))
+	}
+}
+
+// TestSharedLimiter tests that one Limiter paces several clients
+func TestSharedLimiter(t *testing.T) {
+	limiter := NewRateLimiter(50, 1)
+	first := NewClientWithConfig(Config{Limiter: limiter, Runner: &fakeRunner{}})
+	second := NewClientWithConfig(Config{Limiter: limiter, RateLimit: 1000, Runner: &fakeRunner{}})
+
+	start := time.Now()
+	for i := 0; i < 2; i++ {
+		if _, err := first.Execute("hello"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if _, err := second.Execute("hello"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	if elapsed := time.Since(start); elapsed < 55*time.Millisecond {
+		t.Errorf("Expected the shared limiter to pace both clients, took %v", elapsed)
+	}
+}
@@ -0,0 +1,105 @@
+package geminicli
+
+import (
+	"fmt"
+	"sync"
+)
+
+// LogEntry is a single message captured by a RecordingLogger
+type LogEntry struct {
+	Level Level
+	Msg   string
+	KV    []interface{} // Key-value pairs as passed to the logger
+}
+
+// Value returns the value logged for key, or nil if the key is absent
+func (e LogEntry) Value(key string) interface{} {
+	for i := 0; i+1 < len(e.KV); i += 2 {
+		if e.KV[i] == key {
+			return e.KV[i+1]
+		}
+	}
+	return nil
+}
+
+// TestingT is the subset of testing.TB used by RecordingLogger.AssertLogged
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+// RecordingLogger is a Logger that keeps every message in memory, for
+// asserting on log output in tests. It is safe for concurrent use.
+type RecordingLogger struct {
+	mu      sync.Mutex
+	entries []LogEntry
+}
+
+// NewRecordingLogger creates an empty RecordingLogger
+func NewRecordingLogger() *RecordingLogger {
+	return &RecordingLogger{}
+}
+
+func (r *RecordingLogger) DebugWith(msg string, keysAndValues ...interface{}) {
+	r.record(LevelDebug, msg, keysAndValues)
+}
+
+func (r *RecordingLogger) InfoWith(msg string, keysAndValues ...interface{}) {
+	r.record(LevelInfo, msg, keysAndValues)
+}
+
+func (r *RecordingLogger) WarnWith(msg string, keysAndValues ...interface{}) {
+	r.record(LevelWarn, msg, keysAndValues)
+}
+
+func (r *RecordingLogger) ErrorWith(msg string, keysAndValues ...interface{}) {
+	r.record(LevelError, msg, keysAndValues)
+}
+
+// record appends an entry
+func (r *RecordingLogger) record(level Level, msg string, keysAndValues []interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = append(r.entries, LogEntry{Level: level, Msg: msg, KV: keysAndValues})
+}
+
+// Entries returns a copy of the captured messages in the order they were
+// logged
+func (r *RecordingLogger) Entries() []LogEntry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]LogEntry(nil), r.entries...)
+}
+
+// Reset discards every captured message
+func (r *RecordingLogger) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = nil
+}
+
+// Logged reports whether msg was logged at level
+func (r *RecordingLogger) Logged(level Level, msg string) bool {
+	for _, e := range r.Entries() {
+		if e.Level == level && e.Msg == msg {
+			return true
+		}
+	}
+	return false
+}
+
+// AssertLogged fails t unless msg was logged at level, listing what was
+// logged instead
+func (r *RecordingLogger) AssertLogged(t TestingT, level Level, msg string) bool {
+	t.Helper()
+	if r.Logged(level, msg) {
+		return true
+	}
+
+	var logged []string
+	for _, e := range r.Entries() {
+		logged = append(logged, fmt.Sprintf("%s %q", e.Level, e.Msg))
+	}
+	t.Errorf("Expected %q to be logged at %s level, got %v", msg, level, logged)
+	return false
+}
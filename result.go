@@ -0,0 +1,86 @@
+package geminicli
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Result holds the outcome of a successful Gemini invocation, or the output
+// captured before a timeout when Config.ReturnPartialOnTimeout is set
+type Result struct {
+	RawOutput []byte        // Unfiltered standard output of the command
+	Text      string        // Response text after system messages were filtered
+	ExitCode  int           // Exit code of the gemini process: 0 on success, -1 for a partial result from a killed process
+	Duration  time.Duration // Wall-clock time of the call, including retries
+	Command   []string      // Exact argv used for the final attempt
+	Model     string        // Model requested for the final attempt, empty with OmitModelFlag
+
+	// Timing of the final attempt: from starting the process to its first
+	// output byte (zero if unknown), and until it exited
+	StartupDuration time.Duration
+	ExecDuration    time.Duration
+
+	// Token usage, populated when OutputFormat is OutputFormatJSON
+	PromptTokens   int
+	ResponseTokens int
+
+	// Non-blank lines in RawOutput and how many of them output filtering
+	// removed. A jump in the filtered fraction suggests gemini changed its
+	// boilerplate. Both are zero with OutputFormatJSON, which is not
+	// line-filtered.
+	RawLineCount      int
+	FilteredLineCount int
+
+	// Truncated reports whether Text looks cut off according to
+	// LooksTruncated, e.g. because gemini hit its output token limit
+	Truncated bool
+
+	// UpdateNotice is gemini's "update available" notice, found in stdout
+	// or stderr and removed from Text, or "" if there was none. See
+	// UpdateAvailable for the suggested version.
+	UpdateNotice string
+}
+
+// JSON extracts the JSON payload from Text with ExtractJSON, so fenced and
+// prose-wrapped JSON both work, and unmarshals it into v, which must be a
+// pointer. It returns ErrNoJSON if Text contains no JSON, or an error
+// wrapping the json package's error if the payload does not fit v.
+func (r *Result) JSON(v any) error {
+	payload, err := ExtractJSON(r.Text)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(payload, v); err != nil {
+		return fmt.Errorf("%s: %w", ErrDecodeJSON, err)
+	}
+	return nil
+}
+
+// resultText adapts a Result-returning call to the plain string API. A
+// non-nil result accompanying an error carries partial output.
+func resultText(result *Result, err error) (string, error) {
+	if result == nil {
+		return "", err
+	}
+	return result.Text, err
+}
+
+// countLines returns the number of non-blank lines in output and how many
+// of them shouldFilterLine removes
+func (c *Client) countLines(output []byte) (raw, filtered int) {
+	for _, line := range strings.Split(string(output), "\n") {
+		if c.stripANSI {
+			line = StripANSI(line)
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		raw++
+		if c.shouldFilterLine(line) {
+			filtered++
+		}
+	}
+	return raw, filtered
+}
@@ -0,0 +1,157 @@
+package geminicli
+
+import (
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestExecuteResult tests structured execution results
+func TestExecuteResult(t *testing.T) {
+	t.Run("EmptyPrompt", func(t *testing.T) {
+		result, err := NewClient().ExecuteResult("")
+		if err == nil {
+			t.Error("Expected error for empty prompt, but got none")
+		}
+		if result != nil {
+			t.Errorf("Expected nil result on error, got %+v", result)
+		}
+	})
+
+	t.Run("PopulatesMetadata", func(t *testing.T) {
+		installFakeGemini(t, "echo 'Loaded cached credentials.'; echo 'Hello, world!'")
+
+		result, err := NewClient().ExecuteResult("test prompt")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if result.Text != "Hello, world!" {
+			t.Errorf("Expected text 'Hello, world!', got '%s'", result.Text)
+		}
+		if string(result.RawOutput) != "Loaded cached credentials.\nHello, world!\n" {
+			t.Errorf("Expected raw output to be unfiltered, got '%s'", result.RawOutput)
+		}
+		if result.ExitCode != 0 {
+			t.Errorf("Expected exit code 0, got %d", result.ExitCode)
+		}
+		if result.Duration <= 0 {
+			t.Errorf("Expected positive duration, got %v", result.Duration)
+		}
+
+		expected := []string{GeminiCommand, "-m", DefaultModel, "-p", "test prompt"}
+		if len(result.Command) != len(expected) {
+			t.Fatalf("Expected command %v, got %v", expected, result.Command)
+		}
+		if filepath.Base(result.Command[0]) != expected[0] {
+			t.Errorf("Expected command to start with '%s', got '%s'", expected[0], result.Command[0])
+		}
+		for i := 1; i < len(expected); i++ {
+			if result.Command[i] != expected[i] {
+				t.Errorf("Expected argument %d to be '%s', got '%s'", i, expected[i], result.Command[i])
+			}
+		}
+	})
+
+	t.Run("LineCounts", func(t *testing.T) {
+		installFakeGemini(t, "echo 'Loaded cached credentials.'; echo; echo 'Authenticating'; echo 'line 1'; echo 'line 2'")
+
+		result, err := NewClient().ExecuteResult("test prompt")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if result.RawLineCount != 4 || result.FilteredLineCount != 2 {
+			t.Errorf("Expected 4 raw and 2 filtered lines, got %d and %d", result.RawLineCount, result.FilteredLineCount)
+		}
+	})
+
+	t.Run("Truncated", func(t *testing.T) {
+		for _, tt := range []struct {
+			output   string
+			expected bool
+		}{
+			{"echo 'The answer is 42.'", false},
+			{"echo 'The answer is'", true},
+		} {
+			installFakeGemini(t, tt.output)
+
+			result, err := NewClient().ExecuteResult("test prompt")
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result.Truncated != tt.expected {
+				t.Errorf("Expected Truncated %v for %q, got %v", tt.expected, result.Text, result.Truncated)
+			}
+		}
+	})
+}
+
+// TestResultJSON tests decoding a response's JSON payload into a Go value
+func TestResultJSON(t *testing.T) {
+	type person struct {
+		Name string `json:"name"`
+		Age  int    `json:"age"`
+	}
+
+	tests := []struct {
+		name        string
+		text        string
+		expected    person
+		expectedErr error
+		decodeError bool
+		description string
+	}{
+		{
+			name:        "FencedJSON",
+			text:        "Here is the data:\n```json\n{\"name\": \"Ada\", \"age\": 36}\n```",
+			expected:    person{Name: "Ada", Age: 36},
+			description: "JSON inside a fence should be decoded",
+		},
+		{
+			name:        "BareJSON",
+			text:        `{"name": "Grace", "age": 85}`,
+			expected:    person{Name: "Grace", Age: 85},
+			description: "Plain JSON should be decoded",
+		},
+		{
+			name:        "NoJSON",
+			text:        "I could not find anyone.",
+			expectedErr: ErrNoJSON,
+			description: "Text without JSON should return ErrNoJSON",
+		},
+		{
+			name:        "TypeMismatch",
+			text:        `{"name": "Ada", "age": "thirty-six"}`,
+			decodeError: true,
+			description: "JSON that does not fit the target should fail to decode",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got person
+			err := (&Result{Text: tt.text}).JSON(&got)
+
+			switch {
+			case tt.expectedErr != nil:
+				if !errors.Is(err, tt.expectedErr) {
+					t.Errorf("%s: expected %v, got %v", tt.description, tt.expectedErr, err)
+				}
+			case tt.decodeError:
+				var typeErr *json.UnmarshalTypeError
+				if !errors.As(err, &typeErr) || !strings.Contains(err.Error(), ErrDecodeJSON) {
+					t.Errorf("%s: expected a decode error, got %v", tt.description, err)
+				}
+			default:
+				if err != nil {
+					t.Fatalf("%s: unexpected error: %v", tt.description, err)
+				}
+				if got != tt.expected {
+					t.Errorf("%s: expected %+v, got %+v", tt.description, tt.expected, got)
+				}
+			}
+		})
+	}
+}
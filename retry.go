@@ -0,0 +1,72 @@
+package geminicli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// DefaultRetryableError is the retry predicate used when
+// Config.RetryableErrors is nil. It retries rate limits and attempts that
+// hit the per-call timeout or StartupTimeout; every other failure, including
+// a generic non-zero exit, is returned at once.
+func DefaultRetryableError(err error) bool {
+	switch {
+	case errors.Is(err, ErrRateLimited):
+		return true
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, ErrStartupTimeout):
+		return true
+	}
+	return false
+}
+
+// shouldRetry reports whether a failed attempt should be retried. Nothing is
+// retried once ctx is done, since the caller's cancellation or deadline
+// applies to the whole call. Empty output is retried with
+// RetryOnEmptyOutput regardless of the retry predicate.
+func (c *Client) shouldRetry(ctx context.Context, err error) bool {
+	if err == nil || ctx.Err() != nil {
+		return false
+	}
+	if c.retryOnEmptyOutput && errors.Is(err, ErrNoOutput) {
+		return true
+	}
+	if c.retryableErrors != nil {
+		return c.retryableErrors(err)
+	}
+	return DefaultRetryableError(err)
+}
+
+// errTotalDeadline is the cancellation cause used for Config.TotalDeadline;
+// it wraps context.DeadlineExceeded so contextErr reports a deadline
+var errTotalDeadline = fmt.Errorf("total deadline exceeded: %w", context.DeadlineExceeded)
+
+// isFallbackError reports whether err means the model itself could not
+// serve the request, so another model may succeed
+func isFallbackError(err error) bool {
+	return errors.Is(err, ErrRateLimited) || errors.Is(err, ErrModelUnavailable)
+}
+
+// retryDelay returns the delay before the given retry attempt (1-based),
+// using the configured BackoffPolicy or exponential backoff from
+// retryBackoff
+func (c *Client) retryDelay(attempt int) time.Duration {
+	if c.backoff != nil {
+		return c.backoff.NextDelay(attempt)
+	}
+	return ExponentialBackoff{Base: c.retryBackoff}.NextDelay(attempt)
+}
+
+// sleepContext waits for d or until ctx is done, whichever comes first
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,505 @@
+package geminicli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// countingScript returns a fake gemini script that records each invocation in
+// counterFile and fails with the given stderr until the nth call
+func countingScript(counterFile string, succeedOn int, stderr string) string {
+	return `n=$(cat "` + counterFile + `" 2>/dev/null || echo 0)
+n=$((n+1))
+echo $n > "` + counterFile + `"
+if [ $n -lt ` + strconv.Itoa(succeedOn) + ` ]; then echo '` + stderr + `' >&2; exit 1; fi
+echo "response on attempt $n"`
+}
+
+// readCounter returns the number of invocations recorded by countingScript
+func readCounter(t *testing.T, counterFile string) string {
+	t.Helper()
+	data, err := os.ReadFile(counterFile)
+	if err != nil {
+		t.Fatalf("Failed to read counter: %v", err)
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// TestExecuteRetry tests retry handling for transient failures
+func TestExecuteRetry(t *testing.T) {
+	tests := []struct {
+		name          string
+		succeedOn     int
+		stderr        string
+		retryCount    int
+		retryable     func(error) bool
+		expectError   bool
+		expectedCalls string
+		description   string
+	}{
+		{
+			name:          "TransientFailureRecovers",
+			succeedOn:     3,
+			stderr:        "429 Too Many Requests",
+			retryCount:    0,
+			expectError:   false,
+			expectedCalls: "3",
+			description:   "Should retry rate limits with default MaxRetries",
+		},
+		{
+			name:          "RetriesExhausted",
+			succeedOn:     9,
+			stderr:        "429 Too Many Requests",
+			retryCount:    2,
+			expectError:   true,
+			expectedCalls: "3",
+			description:   "Should stop after RetryCount retries",
+		},
+		{
+			name:          "GenericFailureNotRetried",
+			succeedOn:     2,
+			stderr:        "Error: something went wrong",
+			retryCount:    0,
+			expectError:   true,
+			expectedCalls: "1",
+			description:   "Should not retry unclassified failures by default",
+		},
+		{
+			name:          "CustomPredicate",
+			succeedOn:     2,
+			stderr:        "Error: something went wrong",
+			retryCount:    0,
+			retryable:     func(err error) bool { return ExitCode(err) == 1 },
+			expectError:   false,
+			expectedCalls: "2",
+			description:   "Should retry whatever RetryableErrors accepts",
+		},
+		{
+			name:          "CustomPredicateRejectsRateLimit",
+			succeedOn:     2,
+			stderr:        "429 Too Many Requests",
+			retryCount:    0,
+			retryable:     func(err error) bool { return false },
+			expectError:   true,
+			expectedCalls: "1",
+			description:   "RetryableErrors should replace the default classification",
+		},
+		{
+			name:          "AuthErrorNotRetried",
+			succeedOn:     9,
+			stderr:        "Error: authentication failed",
+			retryCount:    0,
+			expectError:   true,
+			expectedCalls: "1",
+			description:   "Should not retry authentication errors",
+		},
+		{
+			name:          "RetriesDisabled",
+			succeedOn:     2,
+			stderr:        "429 Too Many Requests",
+			retryCount:    -1,
+			expectError:   true,
+			expectedCalls: "1",
+			description:   "Should not retry when RetryCount is negative",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			counterFile := filepath.Join(t.TempDir(), "count")
+			installFakeGemini(t, countingScript(counterFile, tt.succeedOn, tt.stderr))
+
+			client := NewClientWithConfig(Config{
+				RetryCount:      tt.retryCount,
+				RetryBackoff:    time.Millisecond,
+				RetryableErrors: tt.retryable,
+			})
+			_, err := client.Execute("test prompt")
+
+			if tt.expectError && err == nil {
+				t.Errorf("Expected error for test case '%s', but got none", tt.name)
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Unexpected error for test case '%s': %v", tt.name, err)
+			}
+			if calls := readCounter(t, counterFile); calls != tt.expectedCalls {
+				t.Errorf("Expected %s invocations, got %s for test case '%s'", tt.expectedCalls, calls, tt.name)
+			}
+		})
+	}
+}
+
+// TestRetryDelay tests exponential backoff growth
+func TestRetryDelay(t *testing.T) {
+	client := NewClientWithConfig(Config{RetryBackoff: 100 * time.Millisecond})
+
+	expected := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond}
+	for i, want := range expected {
+		if got := client.retryDelay(i + 1); got != want {
+			t.Errorf("Expected delay %v for attempt %d, got %v", want, i+1, got)
+		}
+	}
+}
+
+// TestDefaultRetryableError tests the default retry classification
+func TestDefaultRetryableError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{"RateLimited", fmt.Errorf("%w: %w", ErrRateLimited, &CommandError{ExitCode: 1}), true},
+		{"Timeout", fmt.Errorf("%s after 1s: %w", ErrCommandTimeout, context.DeadlineExceeded), true},
+		{"StartupTimeout", fmt.Errorf("%w: no output within 1s", ErrStartupTimeout), true},
+		{"ModelUnavailable", fmt.Errorf("%w: %w", ErrModelUnavailable, &CommandError{ExitCode: 1}), false},
+		{"GenericFailure", &CommandError{ExitCode: 1}, false},
+		{"Authentication", &AuthError{ExitCode: 1}, false},
+		{"Canceled", context.Canceled, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DefaultRetryableError(tt.err); got != tt.expected {
+				t.Errorf("DefaultRetryableError(%v) = %v, expected %v", tt.err, got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestRetryTimeout tests that attempts hitting the per-call timeout are
+// retried, while the caller's own deadline stops the call
+func TestRetryTimeout(t *testing.T) {
+	runner := &fakeRunner{run: func(ctx context.Context, inv Invocation) ([]byte, []byte, error) {
+		<-ctx.Done()
+		return nil, nil, ctx.Err()
+	}}
+
+	client := NewClientWithConfig(Config{
+		Timeout:      10 * time.Millisecond,
+		RetryCount:   2,
+		RetryBackoff: time.Millisecond,
+		Runner:       runner,
+	})
+	if _, err := client.Execute("test prompt"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+	if calls := len(runner.invocations()); calls != 3 {
+		t.Errorf("Expected per-call timeouts to be retried, got %d gemini runs", calls)
+	}
+
+	runner = &fakeRunner{run: runner.run}
+	client = NewClientWithConfig(Config{Timeout: time.Second, RetryBackoff: time.Millisecond, Runner: runner})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := client.ExecuteContext(ctx, "test prompt"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+	if calls := len(runner.invocations()); calls != 1 {
+		t.Errorf("Expected the caller's deadline not to be retried, got %d gemini runs", calls)
+	}
+}
+
+// TestRetryOnEmptyOutput tests retrying responses that filter to nothing
+func TestRetryOnEmptyOutput(t *testing.T) {
+	tests := []struct {
+		name          string
+		retryOnEmpty  bool
+		expectedCalls int
+		description   string
+	}{
+		{
+			name:          "Enabled",
+			retryOnEmpty:  true,
+			expectedCalls: 2,
+			description:   "Empty output should be retried with RetryOnEmptyOutput",
+		},
+		{
+			name:          "Default",
+			retryOnEmpty:  false,
+			expectedCalls: 1,
+			description:   "Empty output should not be retried by default",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := &fakeRunner{}
+			runner.run = func(ctx context.Context, inv Invocation) ([]byte, []byte, error) {
+				if len(runner.invocations()) == 1 {
+					return []byte("Loaded cached credentials.\n"), nil, nil
+				}
+				return []byte("real answer\n"), nil, nil
+			}
+
+			client := NewClientWithConfig(Config{
+				RetryBackoff:       time.Millisecond,
+				RetryOnEmptyOutput: tt.retryOnEmpty,
+				Runner:             runner,
+			})
+			text, err := client.Execute("test prompt")
+
+			if calls := len(runner.invocations()); calls != tt.expectedCalls {
+				t.Errorf("%s: expected %d gemini runs, got %d", tt.description, tt.expectedCalls, calls)
+			}
+			if tt.retryOnEmpty {
+				if err != nil || text != "real answer" {
+					t.Errorf("%s: expected 'real answer', got %q (%v)", tt.description, text, err)
+				}
+			} else if !errors.Is(err, ErrNoOutput) {
+				t.Errorf("%s: expected ErrNoOutput, got %v", tt.description, err)
+			}
+		})
+	}
+}
+
+// TestRetryResolvesPathsPerAttempt tests that a retry resolves relative
+// paths against the current directory rather than the first attempt's
+func TestRetryResolvesPathsPerAttempt(t *testing.T) {
+	first, second := t.TempDir(), t.TempDir()
+	t.Chdir(first)
+
+	runner := &fakeRunner{}
+	runner.run = func(ctx context.Context, inv Invocation) ([]byte, []byte, error) {
+		if len(runner.invocations()) == 1 {
+			if err := os.Chdir(second); err != nil {
+				t.Errorf("Failed to change directory: %v", err)
+			}
+			return nil, []byte("429 RESOURCE_EXHAUSTED"), errors.New("exit status 1")
+		}
+		return []byte("done\n"), nil, nil
+	}
+
+	client := NewClientWithConfig(Config{
+		WorkingDirectory: t.TempDir(),
+		ResolvePaths:     true,
+		RetryBackoff:     time.Millisecond,
+		Runner:           runner,
+	})
+	if _, err := client.Execute("Summarize ./notes.txt"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	calls := runner.invocations()
+	if len(calls) != 2 {
+		t.Fatalf("Expected 2 invocations, got %d", len(calls))
+	}
+	for i, dir := range []string{first, second} {
+		expected := "Summarize " + filepath.Join(dir, "notes.txt")
+		if prompt := calls[i].Args[len(calls[i].Args)-1]; prompt != expected {
+			t.Errorf("Attempt %d: expected prompt '%s', got '%s'", i+1, expected, prompt)
+		}
+	}
+}
+
+// TestRetryBudget tests that retries across calls are limited by the budget
+func TestRetryBudget(t *testing.T) {
+	clock := newFakeClock()
+	runner := &fakeRunner{run: func(ctx context.Context, inv Invocation) ([]byte, []byte, error) {
+		return nil, []byte("429 RESOURCE_EXHAUSTED"), errors.New("exit status 1")
+	}}
+	client := NewClientWithConfig(Config{
+		RetryCount:  3,
+		Backoff:     ConstantBackoff{},
+		RetryBudget: RetryBudgetConfig{Retries: 2, Window: time.Minute},
+		Clock:       clock,
+		Runner:      runner,
+	})
+
+	steps := []struct {
+		advance       time.Duration
+		expectedCalls int
+		description   string
+	}{
+		{0, 3, "The first call should spend the whole budget"},
+		{0, 1, "A call with the budget spent should not be retried"},
+		{30 * time.Second, 2, "Half a window should replenish one retry"},
+		{time.Hour, 3, "Unused retries should not accumulate beyond the budget"},
+	}
+
+	for _, step := range steps {
+		clock.Advance(step.advance)
+		before := len(runner.invocations())
+
+		_, err := client.Execute("test prompt")
+
+		if calls := len(runner.invocations()) - before; calls != step.expectedCalls {
+			t.Errorf("%s: expected %d gemini runs, got %d", step.description, step.expectedCalls, calls)
+		}
+		if !errors.Is(err, ErrRetryBudgetExhausted) || !errors.Is(err, ErrRateLimited) {
+			t.Errorf("%s: expected ErrRetryBudgetExhausted wrapping ErrRateLimited, got %v", step.description, err)
+		}
+	}
+}
+
+// TestModelFallback tests falling back to other models on model-level failures
+func TestModelFallback(t *testing.T) {
+	tests := []struct {
+		name          string
+		failing       map[string]string // Model -> stderr it fails with
+		expectedModel string
+		expectedCalls int
+		expectedErr   error
+		description   string
+	}{
+		{
+			name:          "PrimarySucceeds",
+			expectedModel: "gemini-2.5-pro",
+			expectedCalls: 1,
+			description:   "The primary model should be used when it works",
+		},
+		{
+			name:          "RateLimited",
+			failing:       map[string]string{"gemini-2.5-pro": "429 RESOURCE_EXHAUSTED"},
+			expectedModel: "gemini-2.5-flash",
+			expectedCalls: 2,
+			description:   "A rate-limited primary should fall back to the next model",
+		},
+		{
+			name: "ModelUnavailable",
+			failing: map[string]string{
+				"gemini-2.5-pro":   "The model is overloaded",
+				"gemini-2.5-flash": "429 Too Many Requests",
+			},
+			expectedModel: "gemini-2.0-flash",
+			expectedCalls: 3,
+			description:   "Fallback models should be tried in order",
+		},
+		{
+			name: "AllFail",
+			failing: map[string]string{
+				"gemini-2.5-pro":   "429",
+				"gemini-2.5-flash": "429",
+				"gemini-2.0-flash": "429",
+			},
+			expectedCalls: 3,
+			expectedErr:   ErrRateLimited,
+			description:   "The last error should be returned when every model fails",
+		},
+		{
+			name:          "AuthErrorNoFallback",
+			failing:       map[string]string{"gemini-2.5-pro": "Error: invalid API key"},
+			expectedCalls: 1,
+			expectedErr:   ErrAuthentication,
+			description:   "Authentication errors should not trigger fallback",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := &fakeRunner{run: func(ctx context.Context, inv Invocation) ([]byte, []byte, error) {
+				if stderr, ok := tt.failing[inv.Args[1]]; ok {
+					return nil, []byte(stderr), errors.New("exit status 1")
+				}
+				return []byte("fake response"), nil, nil
+			}}
+			client := NewClientWithConfig(Config{
+				Model:         "gemini-2.5-pro",
+				ModelFallback: []string{"gemini-2.5-flash", "gemini-2.0-flash"},
+				RetryCount:    -1,
+				Runner:        runner,
+			})
+
+			result, err := client.ExecuteResult("hello")
+
+			if tt.expectedErr != nil {
+				if !errors.Is(err, tt.expectedErr) {
+					t.Errorf("%s: expected %v, got %v", tt.description, tt.expectedErr, err)
+				}
+			} else if err != nil {
+				t.Fatalf("%s: unexpected error: %v", tt.description, err)
+			} else if result.Model != tt.expectedModel {
+				t.Errorf("%s: expected model %s, got %s", tt.description, tt.expectedModel, result.Model)
+			}
+			if calls := len(runner.invocations()); calls != tt.expectedCalls {
+				t.Errorf("%s: expected %d gemini runs, got %d", tt.description, tt.expectedCalls, calls)
+			}
+		})
+	}
+}
+
+// TestEmptyPromptNoFallback tests that validation failures skip fallback
+func TestEmptyPromptNoFallback(t *testing.T) {
+	runner := &fakeRunner{}
+	client := NewClientWithConfig(Config{
+		ModelFallback: []string{"gemini-2.5-flash"},
+		Runner:        runner,
+	})
+
+	if _, err := client.Execute(""); err == nil {
+		t.Fatal("Expected error for empty prompt")
+	}
+	if calls := len(runner.invocations()); calls != 0 {
+		t.Errorf("Expected no gemini runs for an empty prompt, got %d", calls)
+	}
+}
+
+// TestTotalDeadline tests that a whole call, including retries, is bounded
+func TestTotalDeadline(t *testing.T) {
+	tests := []struct {
+		name          string
+		script        func(counterFile string) string
+		retryBackoff  time.Duration
+		expectedCalls string
+		expectLastErr bool
+		description   string
+	}{
+		{
+			name: "DuringBackoff",
+			script: func(counterFile string) string {
+				return countingScript(counterFile, 9, "429 Too Many Requests")
+			},
+			retryBackoff:  time.Second,
+			expectedCalls: "1",
+			expectLastErr: true,
+			description:   "A deadline passing during backoff should return the last error",
+		},
+		{
+			name: "DuringAttempt",
+			script: func(counterFile string) string {
+				return "echo 1 > " + counterFile + "; exec sleep 10"
+			},
+			retryBackoff:  time.Millisecond,
+			expectedCalls: "1",
+			description:   "A deadline passing during an attempt should stop it",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			counterFile := filepath.Join(t.TempDir(), "count")
+			installFakeGemini(t, tt.script(counterFile))
+
+			client := NewClientWithConfig(Config{
+				Timeout:       5 * time.Second,
+				GracePeriod:   10 * time.Millisecond,
+				RetryBackoff:  tt.retryBackoff,
+				TotalDeadline: 200 * time.Millisecond,
+			})
+
+			start := time.Now()
+			_, err := client.Execute("test prompt")
+			elapsed := time.Since(start)
+
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("%s: expected context.DeadlineExceeded, got %v", tt.description, err)
+			}
+			var cmdErr *CommandError
+			if tt.expectLastErr && !errors.As(err, &cmdErr) {
+				t.Errorf("%s: expected the last *CommandError to be wrapped, got %v", tt.description, err)
+			}
+			if elapsed > time.Second {
+				t.Errorf("%s: expected the call to end near the deadline, took %v", tt.description, elapsed)
+			}
+			if calls := readCounter(t, counterFile); calls != tt.expectedCalls {
+				t.Errorf("%s: expected %s invocations, got %s", tt.description, tt.expectedCalls, calls)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package geminicli
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultRetryBudgetWindow is the window used when RetryBudgetConfig.Window
+// is zero
+const DefaultRetryBudgetWindow = time.Minute
+
+// RetryBudgetConfig limits the retries a client makes across all of its
+// calls, so a burst of failures does not multiply the load on gemini. A zero
+// Retries disables the budget.
+type RetryBudgetConfig struct {
+	Retries int           // Retries allowed per Window; unused retries do not accumulate beyond this
+	Window  time.Duration // Period over which Retries are replenished (default: DefaultRetryBudgetWindow)
+}
+
+// retryBudget is a token bucket holding up to Retries tokens, refilled
+// evenly over Window. Every retry spends a token. A nil *retryBudget is
+// disabled and allows every retry.
+type retryBudget struct {
+	capacity float64 // Bucket size
+	rate     float64 // Tokens added per second
+	clock    Clock
+
+	mu     sync.Mutex
+	tokens float64   // Available tokens
+	last   time.Time // When tokens was last refilled
+}
+
+// newRetryBudget returns a full budget, or nil when config disables it
+func newRetryBudget(config RetryBudgetConfig, clock Clock) *retryBudget {
+	if config.Retries <= 0 {
+		return nil
+	}
+	window := config.Window
+	if window <= 0 {
+		window = DefaultRetryBudgetWindow
+	}
+	return &retryBudget{
+		capacity: float64(config.Retries),
+		rate:     float64(config.Retries) / window.Seconds(),
+		clock:    clock,
+		tokens:   float64(config.Retries),
+		last:     clock.Now(),
+	}
+}
+
+// spend takes a token for a retry and reports whether one was available
+func (b *retryBudget) spend() bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.clock.Now()
+	b.tokens = min(b.capacity, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
@@ -0,0 +1,62 @@
+package geminicli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Run executes gemini with exactly args, bypassing prompt and model
+// construction, for subcommands and flags the typed API does not cover. The
+// caller is responsible for every flag, including the prompt flag if one is
+// needed. The working directory, timeout, environment, rate limit,
+// authentication and rate-limit detection, MaxOutputBytes and output filtering
+// still apply; empty output is not an error. Run is not retried and does not
+// trigger hooks. With DryRun it returns the command line as Execute does.
+func (c *Client) Run(args []string) (*Result, error) {
+	start := c.clock.Now()
+	if err := c.checkSetup(c.workingDirectory); err != nil {
+		return nil, err
+	}
+	dir, err := c.invocationDir(c.workingDirectory)
+	if err != nil {
+		return nil, err
+	}
+	inv := Invocation{
+		Name: c.binaryPath,
+		Args: append([]string(nil), args...),
+		Dir:  dir,
+		Env:  c.environment(),
+	}
+	command := append([]string{inv.Name}, inv.Args...)
+	if c.dryRun {
+		return &Result{Text: c.dryRunCommandLine(inv), Command: command}, nil
+	}
+
+	if err := c.waitLimiter(context.Background()); err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrCommandFailed, err)
+	}
+
+	ctx, cancel := c.withTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	c.logger.DebugWith("Running raw Gemini command", "command", inv.Name, "args", inv.Args, "timeout", c.timeout)
+
+	execStart := c.clock.Now()
+	ctx, stopLimit := c.limitOutput(ctx, &inv)
+	output, err := c.runCommandWithTimeout(ctx, inv, c.timeout)
+	stopLimit()
+	execDuration := c.clock.Now().Sub(execStart)
+	if err != nil {
+		c.logger.ErrorWith("Gemini command execution failed", "error", err)
+		return nil, fmt.Errorf("%s: %w", ErrCommandFailed, err)
+	}
+
+	return &Result{
+		RawOutput:    output,
+		Text:         c.filterGeminiOutput(strings.TrimSpace(string(output))),
+		Command:      command,
+		Duration:     c.clock.Now().Sub(start),
+		ExecDuration: execDuration,
+	}, nil
+}
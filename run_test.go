@@ -0,0 +1,131 @@
+package geminicli
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestRun tests executing gemini with a raw argument list
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		stdout      string
+		stderr      string
+		runErr      error
+		expected    string
+		expectedErr error
+		description string
+	}{
+		{
+			name:        "Subcommand",
+			args:        []string{"mcp", "list"},
+			stdout:      "Loaded cached credentials.\nNo MCP servers configured.",
+			expected:    "No MCP servers configured.",
+			description: "Output should be filtered like Execute",
+		},
+		{
+			name:        "EmptyOutput",
+			args:        []string{"extensions", "install", "x"},
+			description: "Empty output should not be an error",
+		},
+		{
+			name:        "AuthFailure",
+			args:        []string{"-p", "hello"},
+			stderr:      "Error: invalid API key",
+			runErr:      errors.New("exit status 1"),
+			expectedErr: ErrAuthentication,
+			description: "Authentication failures should be classified",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := &fakeRunner{run: func(ctx context.Context, inv Invocation) ([]byte, []byte, error) {
+				return []byte(tt.stdout), []byte(tt.stderr), tt.runErr
+			}}
+			dir := t.TempDir()
+			client := NewClientWithConfig(Config{
+				Model:            "gemini-2.5-pro",
+				WorkingDirectory: dir,
+				Env:              map[string]string{"GEMINI_TEST": "1"},
+				Runner:           runner,
+			})
+
+			result, err := client.Run(tt.args)
+
+			if tt.expectedErr != nil {
+				if !errors.Is(err, tt.expectedErr) {
+					t.Errorf("%s: expected %v, got %v", tt.description, tt.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("%s: unexpected error: %v", tt.description, err)
+			}
+			if result.Text != tt.expected {
+				t.Errorf("%s: expected text '%s', got '%s'", tt.description, tt.expected, result.Text)
+			}
+
+			inv := runner.invocations()[0]
+			if got := strings.Join(inv.Args, " "); got != strings.Join(tt.args, " ") {
+				t.Errorf("%s: expected args exactly '%v', got '%v'", tt.description, tt.args, inv.Args)
+			}
+			if inv.Dir != dir {
+				t.Errorf("%s: expected dir '%s', got '%s'", tt.description, dir, inv.Dir)
+			}
+			if !slices.Contains(inv.Env, "GEMINI_TEST=1") {
+				t.Errorf("%s: expected configured environment", tt.description)
+			}
+		})
+	}
+}
+
+// TestRunTimeout tests that Run honors the client timeout
+func TestRunTimeout(t *testing.T) {
+	installFakeGemini(t, "exec sleep 10")
+
+	client := NewClientWithConfig(Config{Timeout: 100 * time.Millisecond})
+	_, err := client.Run([]string{"--version"})
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+}
+
+// TestRunMaxOutputBytes tests that Run kills a runaway gemini at the limit
+func TestRunMaxOutputBytes(t *testing.T) {
+	installFakeGemini(t, `while true; do echo "runaway output line"; done`)
+	client := NewClientWithConfig(Config{MaxOutputBytes: 4096, Timeout: 10 * time.Second, GracePeriod: -1})
+
+	start := time.Now()
+	_, err := client.Run([]string{"--version"})
+	if !errors.Is(err, ErrOutputTooLarge) {
+		t.Fatalf("Expected ErrOutputTooLarge, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected the process to be killed promptly, took %v", elapsed)
+	}
+}
+
+// TestRunDryRun tests that a dry run returns the command line instead of
+// starting gemini
+func TestRunDryRun(t *testing.T) {
+	runner := &fakeRunner{run: func(ctx context.Context, inv Invocation) ([]byte, []byte, error) {
+		t.Errorf("Expected gemini not to run in dry run mode, got %v", inv.Args)
+		return nil, nil, nil
+	}}
+	client := NewClientWithConfig(Config{DryRun: true, Runner: runner})
+
+	result, err := client.Run([]string{"mcp", "list"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if expected := "gemini mcp list"; result.Text != expected {
+		t.Errorf("Expected '%s', got '%s'", expected, result.Text)
+	}
+}
@@ -0,0 +1,117 @@
+package geminicli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os/exec"
+	"time"
+)
+
+// Invocation describes a single gemini process to be run by a CommandRunner
+type Invocation struct {
+	Name   string    // Command name, looked up in PATH by ExecRunner
+	Args   []string  // Arguments, excluding the command name
+	Dir    string    // Working directory for the process
+	Stdin  io.Reader // Standard input, or nil for none
+	Stdout io.Writer // Optional writer receiving stdout as it is produced
+	Env    []string  // Environment in "KEY=value" form, or nil to inherit the current process's
+}
+
+// CommandRunner executes gemini processes on behalf of a Client. Run returns
+// the captured stdout and stderr together with any error from the process.
+// It must return promptly once ctx is done. Implementations can be injected
+// with Config.Runner or WithRunner to test the client without a real binary.
+type CommandRunner interface {
+	Run(ctx context.Context, inv Invocation) (stdout []byte, stderr []byte, err error)
+}
+
+// ExecRunner is the default CommandRunner, backed by os/exec
+type ExecRunner struct {
+	// GracePeriod is how long the process may take to exit after SIGTERM
+	// when ctx is done before it is killed; zero kills immediately
+	GracePeriod time.Duration
+}
+
+// Run implements CommandRunner
+func (r *ExecRunner) Run(ctx context.Context, inv Invocation) ([]byte, []byte, error) {
+	cmd, err := r.command(ctx, inv)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	if inv.Stdout != nil {
+		// Keep a copy for error reporting while still feeding the caller's writer
+		cmd.Stdout = io.MultiWriter(&stdout, inv.Stdout)
+	} else {
+		cmd.Stdout = &stdout
+	}
+	cmd.Stderr = &stderr
+
+	if err := cmd.Start(); err != nil {
+		return nil, nil, &startError{message: ErrCommandStart, err: err}
+	}
+
+	// exec.CommandContext terminates the process when ctx is done, and
+	// WaitDelay bounds how long Wait blocks afterwards
+	err = cmd.Wait()
+	if ctx.Err() != nil {
+		// Children that outlived a stopped gemini would be orphaned
+		killProcess(cmd.Process)
+	}
+	return stdout.Bytes(), stderr.Bytes(), err
+}
+
+// command builds the unstarted process for inv, bound to ctx
+func (r *ExecRunner) command(ctx context.Context, inv Invocation) (*exec.Cmd, error) {
+	// Resolve the full path to avoid module resolution issues
+	path, err := exec.LookPath(inv.Name)
+	if err != nil {
+		return nil, &startError{message: ErrCommandNotFound, err: err}
+	}
+
+	cmd := exec.CommandContext(ctx, path, inv.Args...)
+	cmd.Dir = inv.Dir
+	cmd.Stdin = inv.Stdin
+	cmd.Env = inv.Env
+
+	// gemini runs in its own process group so the tools it spawns are
+	// stopped with it. On timeout or cancellation ask the group to exit
+	// first, and only kill it if gemini is still running once the grace
+	// period has elapsed.
+	setProcessGroup(cmd)
+	cmd.Cancel = func() error {
+		return killProcess(cmd.Process)
+	}
+	if r.GracePeriod > 0 {
+		cmd.Cancel = func() error {
+			return terminateProcess(cmd.Process)
+		}
+		cmd.WaitDelay = r.GracePeriod
+	}
+	return cmd, nil
+}
+
+// startError reports that the process could not be started at all, as
+// opposed to a process that ran and failed
+type startError struct {
+	message string
+	err     error
+}
+
+func (e *startError) Error() string {
+	return e.message + ": " + e.err.Error()
+}
+
+func (e *startError) Unwrap() error {
+	return e.err
+}
+
+// isStartError reports whether err means the process never ran
+func isStartError(err error) bool {
+	var se *startError
+	return errors.As(err, &se) || errors.Is(err, exec.ErrNotFound)
+}
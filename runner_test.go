@@ -0,0 +1,110 @@
+package geminicli
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRunner is a CommandRunner that records invocations and delegates to a
+// scripted response function
+type fakeRunner struct {
+	mu    sync.Mutex
+	calls []Invocation
+	run   func(ctx context.Context, inv Invocation) ([]byte, []byte, error)
+}
+
+// Run implements CommandRunner
+func (f *fakeRunner) Run(ctx context.Context, inv Invocation) ([]byte, []byte, error) {
+	f.mu.Lock()
+	f.calls = append(f.calls, inv)
+	f.mu.Unlock()
+
+	if f.run == nil {
+		return []byte("fake response"), nil, nil
+	}
+	return f.run(ctx, inv)
+}
+
+// invocations returns a copy of the recorded invocations
+func (f *fakeRunner) invocations() []Invocation {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Invocation(nil), f.calls...)
+}
+
+// TestCommandRunner tests command execution through an injected runner
+func TestCommandRunner(t *testing.T) {
+	t.Run("ReceivesExactArgs", func(t *testing.T) {
+		runner := &fakeRunner{}
+		client := NewClientWithConfig(Config{
+			Model:            "gemini-2.5-pro",
+			WorkingDirectory: "/work",
+			Runner:           runner,
+		})
+
+		result, err := client.Execute("hello")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if result != "fake response" {
+			t.Errorf("Expected 'fake response', got '%s'", result)
+		}
+
+		calls := runner.invocations()
+		if len(calls) != 1 {
+			t.Fatalf("Expected 1 invocation, got %d", len(calls))
+		}
+		if calls[0].Name != GeminiCommand {
+			t.Errorf("Expected command '%s', got '%s'", GeminiCommand, calls[0].Name)
+		}
+		if got := strings.Join(calls[0].Args, " "); got != "-m gemini-2.5-pro -p hello" {
+			t.Errorf("Expected args '-m gemini-2.5-pro -p hello', got '%s'", got)
+		}
+		if calls[0].Dir != "/work" {
+			t.Errorf("Expected dir '/work', got '%s'", calls[0].Dir)
+		}
+	})
+
+	t.Run("SimulatedStderr", func(t *testing.T) {
+		runner := &fakeRunner{run: func(ctx context.Context, inv Invocation) ([]byte, []byte, error) {
+			return nil, []byte("Error: unauthorized"), errors.New("exit status 1")
+		}}
+		client := NewClientWithConfig(Config{Runner: runner})
+
+		_, err := client.Execute("hello")
+		if !errors.Is(err, ErrAuthentication) {
+			t.Errorf("Expected ErrAuthentication, got: %v", err)
+		}
+	})
+
+	t.Run("SimulatedTimeout", func(t *testing.T) {
+		runner := &fakeRunner{run: func(ctx context.Context, inv Invocation) ([]byte, []byte, error) {
+			<-ctx.Done()
+			return nil, nil, errors.New("signal: killed")
+		}}
+		client := NewClientWithConfig(Config{Timeout: 10 * time.Millisecond, RetryCount: -1, Runner: runner})
+
+		_, err := client.Execute("hello")
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected context.DeadlineExceeded, got: %v", err)
+		}
+		if err != nil && !strings.Contains(err.Error(), ErrCommandTimeout) {
+			t.Errorf("Expected timeout message, got: %v", err)
+		}
+	})
+
+	t.Run("DefaultRunner", func(t *testing.T) {
+		client := NewClient(WithGracePeriod(time.Second))
+		runner, ok := client.runner.(*ExecRunner)
+		if !ok {
+			t.Fatalf("Expected default *ExecRunner, got %T", client.runner)
+		}
+		if runner.GracePeriod != time.Second {
+			t.Errorf("Expected grace period %v, got %v", time.Second, runner.GracePeriod)
+		}
+	})
+}
@@ -0,0 +1,90 @@
+package geminicli
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// DefaultSessionMaxTurns is the number of past turns a Session keeps when
+// Config.SessionMaxTurns is zero
+const DefaultSessionMaxTurns = 20
+
+// Turn is one exchange in a Session
+type Turn struct {
+	Prompt   string // What the user sent
+	Response string // What gemini answered
+}
+
+// Session is a multi-turn conversation. gemini keeps no state between
+// invocations, so every Send prepends the retained history to the prompt.
+// Sends are serialized so turns stay in order; a Session is safe for
+// concurrent use.
+type Session struct {
+	client   *Client
+	maxTurns int // Turns kept; negative keeps everything
+
+	mu      sync.Mutex
+	history []Turn
+}
+
+// NewSession starts an empty conversation that uses the client's
+// configuration for every turn
+func (c *Client) NewSession() *Session {
+	maxTurns := c.sessionMaxTurns
+	if maxTurns == 0 {
+		maxTurns = DefaultSessionMaxTurns
+	}
+	return &Session{client: c, maxTurns: maxTurns}
+}
+
+// Send executes prompt with the conversation so far as context and records
+// the exchange. Failed calls leave the history unchanged.
+func (s *Session) Send(prompt string) (string, error) {
+	if prompt == "" {
+		return "", fmt.Errorf(ErrEmptyPrompt)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	response, err := s.client.Execute(buildSessionPrompt(s.history, prompt))
+	if err != nil {
+		return response, err
+	}
+
+	s.history = append(s.history, Turn{Prompt: prompt, Response: response})
+	if s.maxTurns >= 0 && len(s.history) > s.maxTurns {
+		s.history = append([]Turn(nil), s.history[len(s.history)-s.maxTurns:]...)
+	}
+	return response, nil
+}
+
+// History returns a copy of the retained turns, oldest first
+func (s *Session) History() []Turn {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Turn(nil), s.history...)
+}
+
+// Reset forgets the conversation
+func (s *Session) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.history = nil
+}
+
+// buildSessionPrompt prepends the conversation history to prompt
+func buildSessionPrompt(history []Turn, prompt string) string {
+	if len(history) == 0 {
+		return prompt
+	}
+
+	var b strings.Builder
+	b.WriteString("Previous conversation:\n")
+	for _, turn := range history {
+		fmt.Fprintf(&b, "\nUser: %s\nAssistant: %s\n", turn.Prompt, turn.Response)
+	}
+	fmt.Fprintf(&b, "\nUser: %s", prompt)
+	return b.String()
+}
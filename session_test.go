@@ -0,0 +1,112 @@
+package geminicli
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestSession tests multi-turn history handling
+func TestSession(t *testing.T) {
+	runner := &fakeRunner{run: func(ctx context.Context, inv Invocation) ([]byte, []byte, error) {
+		prompt := inv.Args[len(inv.Args)-1]
+		return []byte("answer " + strings.Repeat("+", strings.Count(prompt, "User:"))), nil, nil
+	}}
+	client := NewClientWithConfig(Config{Runner: runner})
+	session := client.NewSession()
+
+	if _, err := session.Send("first"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := session.Send("second"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	calls := runner.invocations()
+	if prompt := calls[0].Args[len(calls[0].Args)-1]; prompt != "first" {
+		t.Errorf("Expected the first prompt to be sent as is, got '%s'", prompt)
+	}
+	expected := "Previous conversation:\n\nUser: first\nAssistant: answer\n\nUser: second"
+	if prompt := calls[1].Args[len(calls[1].Args)-1]; prompt != expected {
+		t.Errorf("Expected history to be prepended, got %q", prompt)
+	}
+
+	history := session.History()
+	if len(history) != 2 || history[1].Prompt != "second" || history[1].Response != "answer ++" {
+		t.Errorf("Expected two recorded turns, got %+v", history)
+	}
+
+	session.Reset()
+	if len(session.History()) != 0 {
+		t.Error("Expected Reset to clear the history")
+	}
+}
+
+// TestSessionMaxTurns tests that history is capped
+func TestSessionMaxTurns(t *testing.T) {
+	tests := []struct {
+		name        string
+		maxTurns    int
+		expected    int
+		description string
+	}{
+		{
+			name:        "Capped",
+			maxTurns:    2,
+			expected:    2,
+			description: "Only the most recent turns should be kept",
+		},
+		{
+			name:        "Default",
+			maxTurns:    0,
+			expected:    5,
+			description: "The default cap should not trim a short conversation",
+		},
+		{
+			name:        "Unlimited",
+			maxTurns:    -1,
+			expected:    5,
+			description: "A negative cap should keep every turn",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewClientWithConfig(Config{SessionMaxTurns: tt.maxTurns, Runner: &fakeRunner{}})
+			session := client.NewSession()
+
+			for _, prompt := range []string{"1", "2", "3", "4", "5"} {
+				if _, err := session.Send(prompt); err != nil {
+					t.Fatalf("%s: unexpected error: %v", tt.description, err)
+				}
+			}
+
+			history := session.History()
+			if len(history) != tt.expected {
+				t.Fatalf("%s: expected %d turns, got %d", tt.description, tt.expected, len(history))
+			}
+			if last := history[len(history)-1].Prompt; last != "5" {
+				t.Errorf("%s: expected the latest turn to be kept, got '%s'", tt.description, last)
+			}
+		})
+	}
+}
+
+// TestSessionFailedSend tests that failures are not recorded
+func TestSessionFailedSend(t *testing.T) {
+	runner := &fakeRunner{run: func(ctx context.Context, inv Invocation) ([]byte, []byte, error) {
+		return nil, []byte("boom"), errors.New("exit status 1")
+	}}
+	session := NewClientWithConfig(Config{Runner: runner}).NewSession()
+
+	if _, err := session.Send("hello"); err == nil {
+		t.Fatal("Expected error from failing gemini")
+	}
+	if _, err := session.Send(""); err == nil {
+		t.Fatal("Expected error for empty prompt")
+	}
+	if len(session.History()) != 0 {
+		t.Errorf("Expected failed sends to leave no history, got %+v", session.History())
+	}
+}
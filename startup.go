@@ -0,0 +1,78 @@
+package geminicli
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// startupWatch sits in front of a process's stdout to measure the time to
+// the first output byte, cancelling the run if no output arrives within the
+// startup timeout
+type startupWatch struct {
+	next    io.Writer
+	clock   Clock
+	start   time.Time
+	started chan struct{} // Closed on the first output byte
+
+	mu        sync.Mutex
+	firstByte time.Duration // Zero until the first byte is written
+}
+
+// watchStartup wraps inv.Stdout with a startupWatch and, when a startup
+// timeout is configured, derives a context that is cancelled with
+// ErrStartupTimeout if the process stays silent for too long. The returned
+// stop function must be called once the process has exited.
+func (c *Client) watchStartup(ctx context.Context, inv *Invocation) (context.Context, *startupWatch, func()) {
+	watch := &startupWatch{next: inv.Stdout, clock: c.clock, start: c.clock.Now(), started: make(chan struct{})}
+	inv.Stdout = watch
+
+	if c.startupTimeout <= 0 {
+		return ctx, watch, func() {}
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	expired := c.clock.After(c.startupTimeout)
+	go func() {
+		select {
+		case <-expired:
+			// Output arriving at the same moment wins over the timeout
+			select {
+			case <-watch.started:
+			default:
+				cancel(ErrStartupTimeout)
+			}
+		case <-watch.started:
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, watch, func() {
+		cancel(nil)
+	}
+}
+
+// Write records the first output byte and forwards p to the wrapped writer
+func (w *startupWatch) Write(p []byte) (int, error) {
+	if len(p) > 0 {
+		w.mu.Lock()
+		if w.firstByte == 0 {
+			w.firstByte = max(w.clock.Now().Sub(w.start), time.Nanosecond)
+			close(w.started)
+		}
+		w.mu.Unlock()
+	}
+
+	if w.next == nil {
+		return len(p), nil
+	}
+	return w.next.Write(p)
+}
+
+// startupDuration returns the time from starting the process to its first
+// output byte, or zero if it never wrote anything
+func (w *startupWatch) startupDuration() time.Duration {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.firstByte
+}
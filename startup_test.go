@@ -0,0 +1,77 @@
+package geminicli
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestStartupTiming tests the split between startup and execution time
+func TestStartupTiming(t *testing.T) {
+	installFakeGemini(t, `sleep 0.3; echo "first"; sleep 0.2; echo "second"`)
+
+	result, err := NewClient().ExecuteResult("hello")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if result.StartupDuration < 300*time.Millisecond {
+		t.Errorf("Expected startup duration of at least 300ms, got %v", result.StartupDuration)
+	}
+	if result.ExecDuration < result.StartupDuration+200*time.Millisecond {
+		t.Errorf("Expected exec duration %v to include the time after the first byte", result.ExecDuration)
+	}
+	if result.Duration < result.ExecDuration {
+		t.Errorf("Expected total duration %v to cover exec duration %v", result.Duration, result.ExecDuration)
+	}
+}
+
+// TestStartupTimeout tests killing processes that produce no output in time
+func TestStartupTimeout(t *testing.T) {
+	tests := []struct {
+		name        string
+		script      string
+		expectError bool
+		description string
+	}{
+		{
+			name:        "SilentProcessKilled",
+			script:      "exec sleep 10",
+			expectError: true,
+			description: "A process without output should be killed after the startup timeout",
+		},
+		{
+			name:        "SlowGenerationAllowed",
+			script:      `echo "thinking"; sleep 0.5; echo "done"`,
+			expectError: false,
+			description: "Output before the startup timeout should disarm it",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			installFakeGemini(t, tt.script)
+			client := NewClientWithConfig(Config{
+				Timeout:        10 * time.Second,
+				StartupTimeout: 200 * time.Millisecond,
+				GracePeriod:    -1,
+				RetryCount:     -1,
+			})
+
+			start := time.Now()
+			_, err := client.Execute("hello")
+			elapsed := time.Since(start)
+
+			if tt.expectError {
+				if !errors.Is(err, ErrStartupTimeout) {
+					t.Errorf("%s: expected ErrStartupTimeout, got %v", tt.description, err)
+				}
+				if elapsed > 5*time.Second {
+					t.Errorf("%s: process was not killed early, took %v", tt.description, elapsed)
+				}
+			} else if err != nil {
+				t.Errorf("%s: unexpected error: %v", tt.description, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package geminicli
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ExecuteStream executes a Gemini command and sends each non-filtered output
+// line to out as soon as gemini prints it. out is closed once the process has
+// exited, so callers can simply range over it; it must be drained or the
+// subprocess will block on writing. The client's timeout applies to the whole
+// stream.
+func (c *Client) ExecuteStream(prompt string, out chan<- string) error {
+	defer close(out)
+	return c.executeStream(context.Background(), prompt, c.defaultExecOptions(prompt), func(line string) error {
+		out <- line
+		return nil
+	})
+}
+
+// ExecuteTo executes a Gemini command and writes each non-filtered output
+// line, newline-terminated, to w as soon as gemini prints it. If w has a
+// Flush method, such as an http.ResponseWriter implementing http.Flusher, it
+// is flushed after every line. A failed write stops the command and its error
+// is returned; otherwise errors are the same as Execute's.
+func (c *Client) ExecuteTo(prompt string, w io.Writer) error {
+	flusher, _ := w.(interface{ Flush() })
+	return c.executeStream(context.Background(), prompt, c.defaultExecOptions(prompt), func(line string) error {
+		if _, err := io.WriteString(w, line+"\n"); err != nil {
+			return err
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return nil
+	})
+}
+
+// executeStream prepares prompt as Execute does, runs a Gemini invocation
+// with opts and passes filtered lines to emit. If emit fails, the command is
+// stopped and the emit error is returned. With DryRun the command line is
+// emitted as the only line instead.
+func (c *Client) executeStream(ctx context.Context, prompt string, opts execOptions, emit func(line string) error) error {
+	execPrompt, err := c.preparePrompt(prompt, opts)
+	if err != nil {
+		return err
+	}
+	inv, err := c.newInvocation(execPrompt, opts)
+	if err != nil {
+		return err
+	}
+	if c.dryRun {
+		if err := emit(c.dryRunCommandLine(inv)); err != nil {
+			return fmt.Errorf("%s: %w", ErrWriteOutput, err)
+		}
+		return nil
+	}
+
+	if err := c.waitLimiter(ctx); err != nil {
+		return fmt.Errorf("%s: %w", ErrCommandFailed, err)
+	}
+	if err := c.breaker.allow(); err != nil {
+		return err
+	}
+
+	ctx, cancel := c.withTimeout(ctx, opts.timeout)
+	defer cancel()
+	ctx, stop := context.WithCancel(ctx)
+	defer stop()
+
+	reader, writer := io.Pipe()
+	inv.Stdout = writer
+
+	// Forward lines while the command runs
+	var emitErr error
+	scanDone := make(chan struct{})
+	go func() {
+		defer close(scanDone)
+		scanner := bufio.NewScanner(reader)
+		started := false
+		for scanner.Scan() {
+			line := scanner.Text()
+			if c.stripANSI {
+				line = StripANSI(line)
+			}
+			// Preserved blank lines are only forwarded once the response
+			// has started. stream-json events are not filtered, since
+			// message text may contain a filter pattern.
+			if opts.format == OutputFormatStreamJSON {
+				if strings.TrimSpace(line) == "" {
+					continue
+				}
+			} else if c.shouldFilterLine(line) || (!started && strings.TrimSpace(line) == "") {
+				continue
+			}
+			started = true
+			if err := emit(line); err != nil {
+				emitErr = err
+				stop()
+				break
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			c.logger.WarnWith("Failed to scan Gemini output", "error", err)
+		}
+		// Keep draining so the subprocess never blocks on a full pipe
+		io.Copy(io.Discard, reader)
+	}()
+
+	ctx, _, stopWatch := c.watchStartup(ctx, &inv)
+	flushLines := c.logOutput(&inv)
+	ctx, stopLimit := c.limitOutput(ctx, &inv)
+	_, err = c.runCommandWithTimeout(ctx, inv, opts.timeout)
+	stopLimit()
+	flushLines()
+	stopWatch()
+	writer.Close()
+	<-scanDone
+
+	// A command stopped by a failed write reports cancellation, which the
+	// breaker ignores
+	c.breaker.record(err)
+	if emitErr != nil {
+		c.logger.ErrorWith("Failed to write Gemini output", "error", emitErr)
+		return fmt.Errorf("%s: %w", ErrWriteOutput, emitErr)
+	}
+	if err != nil {
+		c.attachPrompt(err, execPrompt)
+		c.logger.ErrorWith("Gemini command execution failed", "error", err)
+		return fmt.Errorf("%s: %w", ErrCommandFailed, err)
+	}
+
+	c.logger.DebugWith("Gemini stream completed successfully")
+	return nil
+}
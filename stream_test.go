@@ -0,0 +1,184 @@
+package geminicli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestExecuteStream tests line-by-line streaming of Gemini output
+func TestExecuteStream(t *testing.T) {
+	t.Run("EmptyPrompt", func(t *testing.T) {
+		out := make(chan string)
+		client := NewClient()
+
+		err := client.ExecuteStream("", out)
+		if err == nil {
+			t.Error("Expected error for empty prompt, but got none")
+		}
+		if _, ok := <-out; ok {
+			t.Error("Expected channel to be closed")
+		}
+	})
+
+	t.Run("FiltersAndForwardsLines", func(t *testing.T) {
+		installFakeGemini(t, "echo 'Loaded cached credentials.'; echo 'line 1'; echo; echo '  line 2'")
+
+		out := make(chan string)
+		client := NewClient()
+
+		errCh := make(chan error, 1)
+		go func() { errCh <- client.ExecuteStream("test prompt", out) }()
+
+		var lines []string
+		for line := range out {
+			lines = append(lines, line)
+		}
+
+		if err := <-errCh; err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		expected := []string{"line 1", "  line 2"}
+		if len(lines) != len(expected) {
+			t.Fatalf("Expected %d lines, got %d: %q", len(expected), len(lines), lines)
+		}
+		for i := range expected {
+			if lines[i] != expected[i] {
+				t.Errorf("Expected line %d to be '%s', got '%s'", i, expected[i], lines[i])
+			}
+		}
+	})
+
+	t.Run("TimeoutApplies", func(t *testing.T) {
+		installFakeGemini(t, "echo 'partial'; exec sleep 10")
+
+		out := make(chan string, 10)
+		client := NewClientWithConfig(Config{Timeout: 200 * time.Millisecond})
+
+		start := time.Now()
+		err := client.ExecuteStream("test prompt", out)
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected context.DeadlineExceeded, got: %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > 2*time.Second {
+			t.Errorf("Stream took too long: %v", elapsed)
+		}
+		if line, ok := <-out; !ok || line != "partial" {
+			t.Errorf("Expected 'partial' to be streamed before timeout, got '%s'", line)
+		}
+	})
+}
+
+// TestExecuteStreamBeforeExecute tests that streamed prompts go through the
+// BeforeExecute hook like Execute's
+func TestExecuteStreamBeforeExecute(t *testing.T) {
+	runner := &fakeRunner{}
+	client := NewClientWithConfig(Config{
+		Runner: runner,
+		BeforeExecute: func(prompt string) (string, error) {
+			return strings.ReplaceAll(prompt, "SECRET", "[REDACTED]"), nil
+		},
+	})
+
+	var buf bytes.Buffer
+	if err := client.ExecuteTo("hello SECRET", &buf); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	args := runner.invocations()[0].Args
+	if prompt := args[len(args)-1]; prompt != "hello [REDACTED]" {
+		t.Errorf("Expected prompt rewritten by BeforeExecute, got '%s'", prompt)
+	}
+}
+
+// TestExecuteStreamPreserveBlankLines tests that streamed blank lines are kept
+// inside the response but not before it
+func TestExecuteStreamPreserveBlankLines(t *testing.T) {
+	installFakeGemini(t, "echo 'Loaded cached credentials.'; echo; echo 'line 1'; echo; echo 'line 2'")
+
+	var w bytes.Buffer
+	client := NewClientWithConfig(Config{PreserveBlankLines: true})
+	if err := client.ExecuteTo("test prompt", &w); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := w.String(); got != "line 1\n\nline 2\n" {
+		t.Errorf("Expected internal blank line to be kept, got %q", got)
+	}
+}
+
+// flushRecorder records written output and counts flushes
+type flushRecorder struct {
+	bytes.Buffer
+	flushes int
+}
+
+func (f *flushRecorder) Flush() { f.flushes++ }
+
+// failingWriter fails every write
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) { return 0, errors.New("client disconnected") }
+
+// TestExecuteTo tests streaming filtered output to an io.Writer
+func TestExecuteTo(t *testing.T) {
+	t.Run("WritesAndFlushesLines", func(t *testing.T) {
+		installFakeGemini(t, "echo 'Loaded cached credentials.'; echo 'line 1'; echo; echo '  line 2'")
+
+		var w flushRecorder
+		if err := NewClient().ExecuteTo("test prompt", &w); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if got := w.String(); got != "line 1\n  line 2\n" {
+			t.Errorf("Expected filtered lines, got %q", got)
+		}
+		if w.flushes != 2 {
+			t.Errorf("Expected 2 flushes, got %d", w.flushes)
+		}
+	})
+
+	t.Run("CommandError", func(t *testing.T) {
+		installFakeGemini(t, "echo 'Error: invalid API key' >&2; exit 1")
+
+		err := NewClient().ExecuteTo("test prompt", io.Discard)
+		if !errors.Is(err, ErrAuthentication) {
+			t.Errorf("Expected ErrAuthentication, got %v", err)
+		}
+	})
+
+	t.Run("WriteErrorStopsCommand", func(t *testing.T) {
+		installFakeGemini(t, "echo 'line 1'; exec sleep 10")
+
+		start := time.Now()
+		err := NewClient().ExecuteTo("test prompt", failingWriter{})
+
+		if err == nil || !strings.Contains(err.Error(), "client disconnected") {
+			t.Errorf("Expected write error, got %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > 5*time.Second {
+			t.Errorf("Expected the command to be stopped after a failed write, took %v", elapsed)
+		}
+	})
+}
+
+// TestExecuteStreamDryRun tests that a dry run streams the command line
+// instead of starting gemini
+func TestExecuteStreamDryRun(t *testing.T) {
+	runner := &fakeRunner{run: func(ctx context.Context, inv Invocation) ([]byte, []byte, error) {
+		t.Errorf("Expected gemini not to run in dry run mode, got %v", inv.Args)
+		return nil, nil, nil
+	}}
+	client := NewClientWithConfig(Config{Model: "gemini-2.5-pro", DryRun: true, Runner: runner})
+
+	var out bytes.Buffer
+	if err := client.ExecuteTo("hello", &out); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if expected := "gemini -m gemini-2.5-pro -p hello\n"; out.String() != expected {
+		t.Errorf("Expected '%s', got '%s'", expected, out.String())
+	}
+}
@@ -0,0 +1,71 @@
+package geminicli
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+)
+
+// Chunk is one event of gemini's --output-format stream-json output
+type Chunk struct {
+	Type           string          // Event type, such as "init", "message", "tool_use" or "result"
+	Text           string          // Response text added by an assistant message; empty for other events
+	PromptTokens   int             // Input tokens, set on the final "result" event
+	ResponseTokens int             // Output tokens, set on the final "result" event
+	Raw            json.RawMessage // The event exactly as gemini printed it
+}
+
+// geminiStreamEvent mirrors the subset of a stream-json event we consume
+type geminiStreamEvent struct {
+	Type    string `json:"type"`
+	Role    string `json:"role"`
+	Content string `json:"content"`
+	Stats   *struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"stats"`
+}
+
+// ExecuteStreamJSON executes a Gemini command with --output-format
+// stream-json, whatever the client's OutputFormat, and passes each event to
+// onChunk as soon as gemini prints it. Assistant messages carry the response
+// text incrementally and the final "result" event carries token counts.
+// Lines that are not JSON, such as system messages, are skipped. If onChunk
+// returns an error the command is stopped and that error is returned
+// wrapped;
+// otherwise errors are the same as ExecuteStream's.
+func (c *Client) ExecuteStreamJSON(prompt string, onChunk func(Chunk) error) error {
+	opts := c.defaultExecOptions(prompt)
+	opts.format = OutputFormatStreamJSON
+	return c.executeStream(context.Background(), prompt, opts, func(line string) error {
+		chunk, ok := parseChunk(line)
+		if !ok {
+			c.logger.DebugWith("Skipping non-JSON stream line", "line", line)
+			return nil
+		}
+		return onChunk(chunk)
+	})
+}
+
+// parseChunk parses one stream-json line, reporting false for lines that
+// are not a JSON object
+func parseChunk(line string) (Chunk, bool) {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "{") {
+		return Chunk{}, false
+	}
+	var event geminiStreamEvent
+	if err := json.Unmarshal([]byte(line), &event); err != nil {
+		return Chunk{}, false
+	}
+
+	chunk := Chunk{Type: event.Type, Raw: json.RawMessage(line)}
+	if event.Type == "message" && event.Role == "assistant" {
+		chunk.Text = event.Content
+	}
+	if event.Stats != nil {
+		chunk.PromptTokens = event.Stats.InputTokens
+		chunk.ResponseTokens = event.Stats.OutputTokens
+	}
+	return chunk, true
+}
@@ -0,0 +1,123 @@
+package geminicli
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// streamJSONOutput is a representative gemini --output-format stream-json
+// transcript, preceded by a system message
+const streamJSONOutput = `Loaded cached credentials.
+{"type":"init","session_id":"abc","model":"gemini-2.5-flash"}
+{"type":"message","role":"user","content":"Say hello"}
+{"type":"message","role":"assistant","content":"Authenticating ","delta":true}
+{"type":"message","role":"assistant","content":"users is hard.","delta":true}
+{"type":"result","status":"success","stats":{"total_tokens":15,"input_tokens":10,"output_tokens":5}}
+`
+
+// TestExecuteStreamJSON tests delivering parsed stream-json events
+func TestExecuteStreamJSON(t *testing.T) {
+	runner := &fakeRunner{run: func(ctx context.Context, inv Invocation) ([]byte, []byte, error) {
+		inv.Stdout.Write([]byte(streamJSONOutput))
+		return []byte(streamJSONOutput), nil, nil
+	}}
+	client := NewClientWithConfig(Config{Runner: runner})
+
+	var chunks []Chunk
+	err := client.ExecuteStreamJSON("Say hello", func(chunk Chunk) error {
+		chunks = append(chunks, chunk)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	args := strings.Join(runner.invocations()[0].Args, " ")
+	if !strings.Contains(args, "--output-format stream-json") {
+		t.Errorf("Expected --output-format stream-json, got %q", args)
+	}
+
+	var types []string
+	var text strings.Builder
+	for _, chunk := range chunks {
+		types = append(types, chunk.Type)
+		text.WriteString(chunk.Text)
+	}
+	if got := strings.Join(types, ","); got != "init,message,message,message,result" {
+		t.Errorf("Unexpected event types %q", got)
+	}
+	if text.String() != "Authenticating users is hard." {
+		t.Errorf("Expected assistant text only, unfiltered, got %q", text.String())
+	}
+	last := chunks[len(chunks)-1]
+	if last.PromptTokens != 10 || last.ResponseTokens != 5 {
+		t.Errorf("Expected token counts 10/5, got %d/%d", last.PromptTokens, last.ResponseTokens)
+	}
+	if !strings.HasPrefix(string(last.Raw), `{"type":"result"`) {
+		t.Errorf("Expected raw event, got %s", last.Raw)
+	}
+}
+
+// TestExecuteStreamJSONCallbackError tests that a failing callback stops the
+// command
+func TestExecuteStreamJSONCallbackError(t *testing.T) {
+	installFakeGemini(t, `echo '{"type":"message","role":"assistant","content":"hi"}'; exec sleep 10`)
+
+	stop := errors.New("client went away")
+	err := NewClient().ExecuteStreamJSON("Say hello", func(chunk Chunk) error {
+		return stop
+	})
+	if !errors.Is(err, stop) {
+		t.Errorf("Expected the callback error, got %v", err)
+	}
+}
+
+// TestExecuteStreamJSONPreparesPrompt tests that stream-json calls check and
+// rewrite the prompt exactly as Execute does
+func TestExecuteStreamJSONPreparesPrompt(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      Config
+		expectedErr error
+		description string
+	}{
+		{
+			name:        "StrictWorkingDir",
+			config:      Config{WorkingDirectory: "/does/not/exist", StrictWorkingDir: true},
+			expectedErr: ErrWorkingDirNotFound,
+			description: "A missing working directory should fail before gemini runs",
+		},
+		{
+			name:        "FileReferences",
+			config:      Config{WorkingDirectory: t.TempDir(), ValidateFileReferences: true},
+			expectedErr: ErrFileReferenceNotFound,
+			description: "Missing @file references should be resolved against the working directory",
+		},
+		{
+			name: "BeforeExecuteRejects",
+			config: Config{BeforeExecute: func(prompt string) (string, error) {
+				return "", ErrUnsupported
+			}},
+			expectedErr: ErrUnsupported,
+			description: "A BeforeExecute error should abort the call",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := &fakeRunner{}
+			tt.config.Runner = runner
+
+			err := NewClientWithConfig(tt.config).ExecuteStreamJSON("Read @missing.txt", func(Chunk) error { return nil })
+
+			if !errors.Is(err, tt.expectedErr) {
+				t.Errorf("%s: expected %v, got %v", tt.description, tt.expectedErr, err)
+			}
+			if calls := len(runner.invocations()); calls != 0 {
+				t.Errorf("%s: expected gemini not to run, got %d invocations", tt.description, calls)
+			}
+		})
+	}
+}
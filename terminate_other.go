@@ -0,0 +1,24 @@
+//go:build !unix
+
+package geminicli
+
+import (
+	"os"
+	"os/exec"
+)
+
+// setProcessGroup does nothing since process groups are not supported on
+// this platform
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// terminateProcess kills the process immediately since SIGTERM is not
+// supported on this platform
+func terminateProcess(process *os.Process) error {
+	return process.Kill()
+}
+
+// killProcess kills the process; its children are not tracked on this
+// platform
+func killProcess(process *os.Process) error {
+	return process.Kill()
+}
@@ -0,0 +1,31 @@
+//go:build unix
+
+package geminicli
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup starts the command in a process group of its own, so tools
+// gemini spawns can be signalled together with it
+func setProcessGroup(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+}
+
+// terminateProcess asks the process and its process group to exit with
+// SIGTERM so gemini can flush output and clean up before it is forcibly
+// killed
+func terminateProcess(process *os.Process) error {
+	return syscall.Kill(-process.Pid, syscall.SIGTERM)
+}
+
+// killProcess kills the process and every other process left in its
+// process group
+func killProcess(process *os.Process) error {
+	return syscall.Kill(-process.Pid, syscall.SIGKILL)
+}
@@ -0,0 +1,91 @@
+package geminicli
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// EstimateTokens returns a rough token count for prompt without running
+// gemini. It takes the larger of two common rules of thumb, one token per
+// four bytes and four tokens per three words, so it errs on the high side for
+// English text and code. Expect it to be off by 20% or more, and to undercount
+// scripts such as Chinese or Japanese; use CountTokens when the number must
+// be exact.
+func EstimateTokens(prompt string) int {
+	if prompt == "" {
+		return 0
+	}
+	byBytes := (len(prompt) + 3) / 4
+	byWords := (len(strings.Fields(prompt))*4 + 2) / 3
+	return max(byBytes, byWords)
+}
+
+// CountTokens asks gemini for the exact number of prompt tokens. gemini has
+// no token-count command, so this sends the prompt with JSON output and a
+// one-token response limit and reads the prompt tokens from the usage
+// statistics. Unlike EstimateTokens it needs the network, takes as long as a
+// short request and counts against quota. The prompt is checked and
+// prepared as for Execute, but retries, the AfterExecute hook and the circuit
+// breaker are not involved. With DryRun it fails with ErrUnsupported, since
+// there is no count without running gemini.
+func (c *Client) CountTokens(prompt string) (int, error) {
+	if c.dryRun {
+		return 0, fmt.Errorf("%w: CountTokens cannot be used with DryRun", ErrUnsupported)
+	}
+	opts := c.defaultExecOptions(prompt)
+	opts.format = OutputFormatJSON
+	execPrompt, err := c.preparePrompt(prompt, opts)
+	if err != nil {
+		return 0, err
+	}
+	inv, err := c.newInvocation(execPrompt, opts)
+	if err != nil {
+		return 0, err
+	}
+	inv.Args = countTokensArgs(inv.Args)
+
+	if err := c.waitLimiter(context.Background()); err != nil {
+		return 0, fmt.Errorf("%s: %w", ErrCommandFailed, err)
+	}
+
+	ctx, cancel := c.withTimeout(context.Background(), opts.timeout)
+	defer cancel()
+
+	c.logger.DebugWith("Counting prompt tokens", "command", inv.Name, "prompt_length", len(prompt))
+
+	output, err := c.runCommandWithTimeout(ctx, inv, opts.timeout)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", ErrCommandFailed, err)
+	}
+	return parseTokenCount(output)
+}
+
+// countTokensArgs limits the response in gemini args with JSON output to one
+// token, replacing a configured MaxOutputTokens
+func countTokensArgs(args []string) []string {
+	if i := slices.Index(args, GeminiMaxOutputTokensFlag); i >= 0 && i+1 < len(args) {
+		args[i+1] = "1"
+		return args
+	}
+	i := slices.Index(args, GeminiOutputFormatFlag) + 2
+	return slices.Insert(args, i, GeminiMaxOutputTokensFlag, "1")
+}
+
+// parseTokenCount sums the prompt tokens reported in gemini's JSON output
+func parseTokenCount(output []byte) (int, error) {
+	raw, err := decodeGeminiJSON(output)
+	if err != nil {
+		return 0, err
+	}
+
+	tokens := 0
+	for _, model := range raw.Stats.Models {
+		tokens += model.Tokens.Prompt
+	}
+	if tokens == 0 {
+		return 0, ErrTokenCountUnavailable
+	}
+	return tokens, nil
+}
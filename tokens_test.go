@@ -0,0 +1,226 @@
+package geminicli
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestEstimateTokens tests the offline token heuristic
+func TestEstimateTokens(t *testing.T) {
+	tests := []struct {
+		name        string
+		prompt      string
+		expected    int
+		description string
+	}{
+		{
+			name:        "Empty",
+			prompt:      "",
+			expected:    0,
+			description: "An empty prompt should have no tokens",
+		},
+		{
+			name:        "ShortWords",
+			prompt:      "a b c d e f",
+			expected:    8,
+			description: "Many short words should use the word-based estimate",
+		},
+		{
+			name:        "LongWords",
+			prompt:      "internationalization",
+			expected:    5,
+			description: "Long words should use the byte-based estimate",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := EstimateTokens(tt.prompt); got != tt.expected {
+				t.Errorf("%s: expected %d, got %d", tt.description, tt.expected, got)
+			}
+		})
+	}
+}
+
+// TestCountTokens tests exact token counting through gemini's JSON stats
+func TestCountTokens(t *testing.T) {
+	tests := []struct {
+		name        string
+		stdout      string
+		expected    int
+		expectedErr error
+		description string
+	}{
+		{
+			name:        "Stats",
+			stdout:      `{"response": "O", "stats": {"models": {"gemini-2.5-pro": {"tokens": {"prompt": 42, "candidates": 1}}}}}`,
+			expected:    42,
+			description: "Prompt tokens should be read from the usage statistics",
+		},
+		{
+			name:        "EmptyResponse",
+			stdout:      `{"response": "", "stats": {"models": {"gemini-2.5-pro": {"tokens": {"prompt": 7}}}}}`,
+			expected:    7,
+			description: "An empty response should not prevent counting",
+		},
+		{
+			name:        "NoStats",
+			stdout:      `{"response": "O"}`,
+			expectedErr: ErrTokenCountUnavailable,
+			description: "Missing statistics should be reported",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := &fakeRunner{run: func(ctx context.Context, inv Invocation) ([]byte, []byte, error) {
+				return []byte(tt.stdout), nil, nil
+			}}
+			client := NewClientWithConfig(Config{Model: "gemini-2.5-pro", Runner: runner})
+
+			got, err := client.CountTokens("hello")
+
+			if tt.expectedErr != nil {
+				if !errors.Is(err, tt.expectedErr) {
+					t.Errorf("%s: expected %v, got %v", tt.description, tt.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("%s: unexpected error: %v", tt.description, err)
+			}
+			if got != tt.expected {
+				t.Errorf("%s: expected %d, got %d", tt.description, tt.expected, got)
+			}
+
+			args := strings.Join(runner.invocations()[0].Args, " ")
+			if expected := "-m gemini-2.5-pro --output-format json --max-output-tokens 1 -p hello"; args != expected {
+				t.Errorf("%s: expected args '%s', got '%s'", tt.description, expected, args)
+			}
+		})
+	}
+}
+
+// TestCountTokensArgs tests that CountTokens passes the configured gemini
+// flags and overrides only the output format and response limit
+func TestCountTokensArgs(t *testing.T) {
+	maxTokens := 500
+	tests := []struct {
+		name        string
+		config      Config
+		expected    string
+		description string
+	}{
+		{
+			name:        "SandboxExtensionsDebug",
+			config:      Config{Sandbox: true, SandboxImage: "img", Extensions: []string{"ext"}, Debug: true},
+			expected:    "--output-format json --max-output-tokens 1 --sandbox --sandbox-image img --extensions ext --debug -p hello",
+			description: "Sandbox, extension and debug flags should be passed",
+		},
+		{
+			name:        "MaxOutputTokens",
+			config:      Config{MaxOutputTokens: &maxTokens},
+			expected:    "--output-format json --max-output-tokens 1 -p hello",
+			description: "A configured response limit should be replaced",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := &fakeRunner{run: func(ctx context.Context, inv Invocation) ([]byte, []byte, error) {
+				return []byte(`{"stats": {"models": {"m": {"tokens": {"prompt": 1}}}}}`), nil, nil
+			}}
+			tt.config.OmitModelFlag = true
+			tt.config.Runner = runner
+			client := NewClientWithConfig(tt.config)
+
+			if _, err := client.CountTokens("hello"); err != nil {
+				t.Fatalf("%s: unexpected error: %v", tt.description, err)
+			}
+			if args := strings.Join(runner.invocations()[0].Args, " "); args != tt.expected {
+				t.Errorf("%s: expected args '%s', got '%s'", tt.description, tt.expected, args)
+			}
+		})
+	}
+}
+
+// TestCountTokensPreparesPrompt tests that CountTokens checks and prepares
+// the prompt as Execute does and runs gemini in the working directory
+func TestCountTokensPreparesPrompt(t *testing.T) {
+	t.Run("BeforeExecute", func(t *testing.T) {
+		runner := &fakeRunner{run: func(ctx context.Context, inv Invocation) ([]byte, []byte, error) {
+			return []byte(`{"stats": {"models": {"m": {"tokens": {"prompt": 1}}}}}`), nil, nil
+		}}
+		dir := t.TempDir()
+		client := NewClientWithConfig(Config{
+			WorkingDirectory: dir,
+			Runner:           runner,
+			BeforeExecute: func(prompt string) (string, error) {
+				return strings.ReplaceAll(prompt, "SECRET", "[REDACTED]"), nil
+			},
+		})
+
+		if _, err := client.CountTokens("hello SECRET"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		inv := runner.invocations()[0]
+		if last := inv.Args[len(inv.Args)-1]; last != "hello [REDACTED]" {
+			t.Errorf("BeforeExecute should rewrite the counted prompt, got '%s'", last)
+		}
+		if inv.Dir != dir {
+			t.Errorf("expected gemini to run in '%s', got '%s'", dir, inv.Dir)
+		}
+	})
+
+	tests := []struct {
+		name        string
+		config      Config
+		expectedErr error
+		description string
+	}{
+		{
+			name:        "UnreadableAPIKeyFile",
+			config:      Config{APIKeyFile: filepath.Join(t.TempDir(), "missing")},
+			expectedErr: ErrInvalidConfig,
+			description: "An unreadable APIKeyFile should fail before gemini runs",
+		},
+		{
+			name:        "StrictWorkingDir",
+			config:      Config{WorkingDirectory: filepath.Join(t.TempDir(), "missing"), StrictWorkingDir: true},
+			expectedErr: ErrWorkingDirNotFound,
+			description: "A missing working directory should fail before gemini runs",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := &fakeRunner{}
+			tt.config.Runner = runner
+			client := NewClientWithConfig(tt.config)
+
+			if _, err := client.CountTokens("hello"); !errors.Is(err, tt.expectedErr) {
+				t.Errorf("%s: expected %v, got %v", tt.description, tt.expectedErr, err)
+			}
+			if calls := len(runner.invocations()); calls != 0 {
+				t.Errorf("%s: expected gemini not to run, got %d invocations", tt.description, calls)
+			}
+		})
+	}
+}
+
+// TestCountTokensDryRun tests that CountTokens refuses to run gemini in dry
+// run mode
+func TestCountTokensDryRun(t *testing.T) {
+	runner := &fakeRunner{run: func(ctx context.Context, inv Invocation) ([]byte, []byte, error) {
+		t.Errorf("Expected gemini not to run in dry run mode, got %v", inv.Args)
+		return nil, nil, nil
+	}}
+	client := NewClientWithConfig(Config{DryRun: true, Runner: runner})
+
+	if _, err := client.CountTokens("hello"); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("Expected ErrUnsupported, got %v", err)
+	}
+}
@@ -0,0 +1,73 @@
+package geminicli
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// sentenceEnders are the characters a complete response usually ends with:
+// sentence punctuation, closing brackets and quotes, Markdown emphasis and
+// table borders, and their full-width equivalents
+const sentenceEnders = ".!?;:)]}\"'`*_|>。！？…）」』”’"
+
+// LooksTruncated reports whether a model response appears to have been cut
+// off, typically because gemini hit its output token limit. It is a
+// heuristic: a response is considered truncated if EndsMidSentence,
+// HasUnclosedCodeFence or HasUnbalancedBrackets reports true. An empty
+// response is not considered truncated.
+func LooksTruncated(response string) bool {
+	return EndsMidSentence(response) || HasUnclosedCodeFence(response) || HasUnbalancedBrackets(response)
+}
+
+// EndsMidSentence reports whether the last non-blank character of response
+// is not one a complete answer usually ends with, such as sentence
+// punctuation, a closing bracket or quote, or a closing code fence
+func EndsMidSentence(response string) bool {
+	response = strings.TrimSpace(response)
+	if response == "" {
+		return false
+	}
+	last, _ := utf8.DecodeLastRuneInString(response)
+	return !strings.ContainsRune(sentenceEnders, last)
+}
+
+// HasUnclosedCodeFence reports whether response opens a triple-backtick code
+// fence that is never closed
+func HasUnclosedCodeFence(response string) bool {
+	fenceLen := 0
+	for _, line := range strings.Split(response, "\n") {
+		trimmed := strings.TrimSpace(line)
+		n := fenceLength(trimmed)
+		switch {
+		case fenceLen == 0 && n > 0:
+			fenceLen = n
+		case fenceLen > 0 && n >= fenceLen && strings.TrimSpace(trimmed[n:]) == "":
+			fenceLen = 0
+		}
+	}
+	return fenceLen > 0
+}
+
+// HasUnbalancedBrackets reports whether response opens more braces, square
+// brackets or parentheses than it closes. Surplus closing brackets, common in
+// prose such as "1)" list markers, are ignored.
+func HasUnbalancedBrackets(response string) bool {
+	var braces, squares, parens int
+	for _, r := range response {
+		switch r {
+		case '{':
+			braces++
+		case '}':
+			braces = max(braces-1, 0)
+		case '[':
+			squares++
+		case ']':
+			squares = max(squares-1, 0)
+		case '(':
+			parens++
+		case ')':
+			parens = max(parens-1, 0)
+		}
+	}
+	return braces > 0 || squares > 0 || parens > 0
+}
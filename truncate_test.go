@@ -0,0 +1,88 @@
+package geminicli
+
+import "testing"
+
+// TestLooksTruncated tests the truncation heuristics individually and combined
+func TestLooksTruncated(t *testing.T) {
+	tests := []struct {
+		name             string
+		response         string
+		midSentence      bool
+		unclosedFence    bool
+		unbalanced       bool
+		expectTruncation bool
+		description      string
+	}{
+		{
+			name:        "Empty",
+			response:    "  \n",
+			description: "An empty response should not be considered truncated",
+		},
+		{
+			name:        "CompleteSentence",
+			response:    "Go was designed at Google in 2007.\n",
+			description: "A response ending with punctuation should be complete",
+		},
+		{
+			name:             "MidSentence",
+			response:         "Go was designed at Google in",
+			midSentence:      true,
+			expectTruncation: true,
+			description:      "A response ending without punctuation should be truncated",
+		},
+		{
+			name:        "FullWidthPunctuation",
+			response:    "これはテストです。",
+			description: "Full-width sentence punctuation should count as an ending",
+		},
+		{
+			name:        "ClosedCodeFence",
+			response:    "Example:\n```go\nfunc main() {}\n```",
+			description: "A response ending with a closed fence should be complete",
+		},
+		{
+			name:             "UnclosedCodeFence",
+			response:         "Example:\n```go\nfmt.Println(x);",
+			unclosedFence:    true,
+			expectTruncation: true,
+			description:      "A fence that is never closed should be truncated",
+		},
+		{
+			name:             "UnbalancedBraces",
+			response:         "The config is {\"name\": \"gemini\",",
+			midSentence:      true,
+			unbalanced:       true,
+			expectTruncation: true,
+			description:      "An unclosed brace should be truncated",
+		},
+		{
+			name:             "UnbalancedOnly",
+			response:         "Call it as f(a, b.",
+			unbalanced:       true,
+			expectTruncation: true,
+			description:      "Unbalanced brackets alone should be enough",
+		},
+		{
+			name:        "SurplusClosers",
+			response:    "Options:\n1) fast\n2) cheap.",
+			description: "Surplus closing brackets should be ignored",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := EndsMidSentence(tt.response); got != tt.midSentence {
+				t.Errorf("%s: EndsMidSentence = %v, expected %v", tt.description, got, tt.midSentence)
+			}
+			if got := HasUnclosedCodeFence(tt.response); got != tt.unclosedFence {
+				t.Errorf("%s: HasUnclosedCodeFence = %v, expected %v", tt.description, got, tt.unclosedFence)
+			}
+			if got := HasUnbalancedBrackets(tt.response); got != tt.unbalanced {
+				t.Errorf("%s: HasUnbalancedBrackets = %v, expected %v", tt.description, got, tt.unbalanced)
+			}
+			if got := LooksTruncated(tt.response); got != tt.expectTruncation {
+				t.Errorf("%s: LooksTruncated = %v, expected %v", tt.description, got, tt.expectTruncation)
+			}
+		})
+	}
+}
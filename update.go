@@ -0,0 +1,57 @@
+package geminicli
+
+import (
+	"regexp"
+	"strings"
+)
+
+// updateNoticePattern matches the notice gemini prints when a newer release
+// is available, e.g. "Gemini CLI update available! 0.1.9 → 0.1.12" or
+// "A new version is available"
+var updateNoticePattern = regexp.MustCompile(`(?i)^(?:(?:gemini(?: cli)? )?update available\b|a new version(?: of gemini(?: cli)?)? is available\b)`)
+
+// updateInstructionPattern matches the line gemini prints after the update
+// notice telling the user how to update
+var updateInstructionPattern = regexp.MustCompile(`(?i)^run npm (?:install|i) -g @google/gemini-cli\S* to update\b`)
+
+// UpdateAvailable reports whether gemini output, stdout or stderr, contains
+// a notice that a newer gemini release is available, and returns the
+// version it suggests. The version is the last one on the notice line,
+// without a leading "v", or "" if the notice names none.
+func UpdateAvailable(output []byte) (bool, string) {
+	notice := findUpdateNotice(output)
+	if notice == "" {
+		return false, ""
+	}
+	versions := semverPattern.FindAllString(notice, -1)
+	if len(versions) == 0 {
+		return true, ""
+	}
+	return true, strings.TrimPrefix(versions[len(versions)-1], "v")
+}
+
+// findUpdateNotice returns the first trimmed update notice line in output,
+// or ""
+func findUpdateNotice(output []byte) string {
+	for _, line := range strings.Split(StripANSI(string(output)), "\n") {
+		if line = strings.TrimSpace(line); updateNoticePattern.MatchString(line) {
+			return line
+		}
+	}
+	return ""
+}
+
+// isUpdateLine reports whether a trimmed output line is part of gemini's
+// update notice
+func isUpdateLine(line string) bool {
+	return updateNoticePattern.MatchString(line) || updateInstructionPattern.MatchString(line)
+}
+
+// updateNotice returns the update notice from a command's stdout, or failing
+// that its stderr, or ""
+func updateNotice(stdout, stderr []byte) string {
+	if notice := findUpdateNotice(stdout); notice != "" {
+		return notice
+	}
+	return findUpdateNotice(stderr)
+}
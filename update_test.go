@@ -0,0 +1,109 @@
+package geminicli
+
+import (
+	"context"
+	"testing"
+)
+
+// TestUpdateAvailable tests detecting gemini's update notice
+func TestUpdateAvailable(t *testing.T) {
+	tests := []struct {
+		name            string
+		output          string
+		expected        bool
+		expectedVersion string
+		description     string
+	}{
+		{
+			name:            "GeminiCLINotice",
+			output:          "Gemini CLI update available! 0.1.9 → 0.1.12\nRun npm install -g @google/gemini-cli to update\n",
+			expected:        true,
+			expectedVersion: "0.1.12",
+			description:     "The suggested version should be the last one on the notice line",
+		},
+		{
+			name:        "NewVersionNotice",
+			output:      "Hello!\nA new version is available.\n",
+			expected:    true,
+			description: "A notice without a version should be detected",
+		},
+		{
+			name:            "ColoredNotice",
+			output:          "\x1b[33mA new version of Gemini CLI is available: v0.2.0\x1b[0m\n",
+			expected:        true,
+			expectedVersion: "0.2.0",
+			description:     "ANSI colors should not hide the notice",
+		},
+		{
+			name:        "Prose",
+			output:      "Go 1.22 is out. A new version of Go is available from go.dev.\n",
+			description: "Responses mentioning other updates should not match",
+		},
+		{
+			name:        "Empty",
+			output:      "",
+			description: "Empty output should not match",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			available, version := UpdateAvailable([]byte(tt.output))
+			if available != tt.expected || version != tt.expectedVersion {
+				t.Errorf("%s: expected (%v, %q), got (%v, %q)", tt.description, tt.expected, tt.expectedVersion, available, version)
+			}
+		})
+	}
+}
+
+// TestUpdateNotice tests that update notices are removed from responses and
+// reported on Result
+func TestUpdateNotice(t *testing.T) {
+	tests := []struct {
+		name        string
+		stdout      string
+		stderr      string
+		description string
+	}{
+		{
+			name:        "Stdout",
+			stdout:      "Gemini CLI update available! 0.1.9 → 0.1.12\nRun npm install -g @google/gemini-cli to update\nThe answer is 42.\n",
+			description: "A notice on stdout should be filtered and reported",
+		},
+		{
+			name:        "Stderr",
+			stdout:      "The answer is 42.\n",
+			stderr:      "Gemini CLI update available! 0.1.9 → 0.1.12\n",
+			description: "A notice on stderr should be reported",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := &fakeRunner{run: func(ctx context.Context, inv Invocation) ([]byte, []byte, error) {
+				return []byte(tt.stdout), []byte(tt.stderr), nil
+			}}
+
+			result, err := NewClient(WithRunner(runner)).ExecuteResult("test prompt")
+			if err != nil {
+				t.Fatalf("%s: unexpected error: %v", tt.description, err)
+			}
+			if result.Text != "The answer is 42." {
+				t.Errorf("%s: expected the notice to be filtered, got %q", tt.description, result.Text)
+			}
+			if result.UpdateNotice != "Gemini CLI update available! 0.1.9 → 0.1.12" {
+				t.Errorf("%s: unexpected UpdateNotice %q", tt.description, result.UpdateNotice)
+			}
+		})
+	}
+
+	t.Run("NoNotice", func(t *testing.T) {
+		result, err := NewClient(WithRunner(&fakeRunner{})).ExecuteResult("test prompt")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if result.UpdateNotice != "" {
+			t.Errorf("Expected no UpdateNotice, got %q", result.UpdateNotice)
+		}
+	})
+}
@@ -0,0 +1,110 @@
+package geminicli
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// semverPattern matches the first semantic-version-looking token, with an
+// optional leading "v" and pre-release suffix
+var semverPattern = regexp.MustCompile(`v?(\d+)\.(\d+)\.(\d+)(-[0-9A-Za-z.\-]+)?`)
+
+// Version runs gemini --version and returns the semantic version it reports,
+// without a leading "v". Banner lines around the version are ignored.
+func (c *Client) Version() (string, error) {
+	ctx, cancel := c.withTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	output, err := c.runVersion(ctx)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", ErrCommandFailed, err)
+	}
+
+	version, err := parseVersion(string(output))
+	if err != nil {
+		return "", err
+	}
+	c.logger.DebugWith("Detected Gemini version", "version", version)
+	return version, nil
+}
+
+// runVersion runs gemini --version bounded by ctx and returns its output
+func (c *Client) runVersion(ctx context.Context) ([]byte, error) {
+	dir, err := c.invocationDir(c.workingDirectory)
+	if err != nil {
+		return nil, err
+	}
+	inv := Invocation{Name: c.binaryPath, Args: []string{GeminiVersionFlag}, Dir: dir, Env: c.environment()}
+	c.logger.DebugWith("Querying Gemini version", "command", inv.Name, "args", inv.Args)
+	return c.runCommandWithTimeout(ctx, inv, c.timeout)
+}
+
+// checkMinVersion compares the installed gemini version with c.minVersion
+func (c *Client) checkMinVersion() error {
+	installed, err := c.Version()
+	if err != nil {
+		return err
+	}
+	return c.requireMinVersion(installed)
+}
+
+// requireMinVersion fails with ErrVersionTooOld if installed is older than
+// c.minVersion
+func (c *Client) requireMinVersion(installed string) error {
+	cmp, err := compareVersions(installed, c.minVersion)
+	if err != nil {
+		return err
+	}
+	if cmp < 0 {
+		return fmt.Errorf("%w: installed %s, need at least %s", ErrVersionTooOld, installed, c.minVersion)
+	}
+	return nil
+}
+
+// parseVersion extracts the first semantic version found in output
+func parseVersion(output string) (string, error) {
+	match := semverPattern.FindString(output)
+	if match == "" {
+		return "", fmt.Errorf("%s: no version found in %q", ErrParseVersion, strings.TrimSpace(output))
+	}
+	return strings.TrimPrefix(match, "v"), nil
+}
+
+// compareVersions returns -1, 0 or 1 depending on whether version a is
+// older than, equal to or newer than b. A pre-release sorts before the
+// release it precedes; pre-release labels themselves are not ordered.
+func compareVersions(a, b string) (int, error) {
+	partsA := semverPattern.FindStringSubmatch(a)
+	partsB := semverPattern.FindStringSubmatch(b)
+	if partsA == nil {
+		return 0, fmt.Errorf("%s: %q", ErrParseVersion, a)
+	}
+	if partsB == nil {
+		return 0, fmt.Errorf("%s: %q", ErrParseVersion, b)
+	}
+
+	for i := 1; i <= 3; i++ {
+		numA, _ := strconv.Atoi(partsA[i])
+		numB, _ := strconv.Atoi(partsB[i])
+		if numA != numB {
+			if numA < numB {
+				return -1, nil
+			}
+			return 1, nil
+		}
+	}
+
+	preA, preB := partsA[4], partsB[4]
+	switch {
+	case preA == preB:
+		return 0, nil
+	case preA == "":
+		return 1, nil
+	case preB == "":
+		return -1, nil
+	}
+	return 0, nil
+}
@@ -0,0 +1,215 @@
+package geminicli
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestParseVersion tests extracting semantic versions from --version output
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		name        string
+		output      string
+		expected    string
+		expectError bool
+		description string
+	}{
+		{
+			name:        "PlainVersion",
+			output:      "0.1.12\n",
+			expected:    "0.1.12",
+			description: "Bare version should be returned as is",
+		},
+		{
+			name:        "LeadingV",
+			output:      "v1.2.3",
+			expected:    "1.2.3",
+			description: "Leading v should be stripped",
+		},
+		{
+			name:        "BannerLines",
+			output:      "Loaded cached credentials.\nGemini CLI version 0.2.0-preview.1 (build abc)\n",
+			expected:    "0.2.0-preview.1",
+			description: "First semver-looking token should be extracted from banner output",
+		},
+		{
+			name:        "NoVersion",
+			output:      "unknown flag --version",
+			expectError: true,
+			description: "Output without a version should fail",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			version, err := parseVersion(tt.output)
+			if tt.expectError {
+				if err == nil || !strings.Contains(err.Error(), ErrParseVersion) {
+					t.Errorf("%s: expected parse error, got %v", tt.description, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("%s: unexpected error: %v", tt.description, err)
+			}
+			if version != tt.expected {
+				t.Errorf("%s: expected '%s', got '%s'", tt.description, tt.expected, version)
+			}
+		})
+	}
+}
+
+// TestCompareVersions tests semantic version ordering
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{"0.1.12", "0.1.12", 0},
+		{"0.1.9", "0.1.12", -1},
+		{"1.0.0", "0.9.9", 1},
+		{"v2.0.0", "2.0.0", 0},
+		{"1.0.0-beta", "1.0.0", -1},
+		{"1.0.0", "1.0.0-beta", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+"_vs_"+tt.b, func(t *testing.T) {
+			got, err := compareVersions(tt.a, tt.b)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("Expected %d, got %d", tt.expected, got)
+			}
+		})
+	}
+}
+
+// TestVersion tests querying the installed gemini version
+func TestVersion(t *testing.T) {
+	installFakeGemini(t, `if [ "$1" = "--version" ]; then echo "gemini-cli"; echo "0.1.12"; exit 0; fi; echo ok`)
+
+	version, err := NewClient().Version()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if version != "0.1.12" {
+		t.Errorf("Expected version '0.1.12', got '%s'", version)
+	}
+}
+
+// TestValidateAvailableMinVersion tests MinVersion enforcement
+func TestValidateAvailableMinVersion(t *testing.T) {
+	installFakeGemini(t, `echo "0.1.12"`)
+
+	tests := []struct {
+		name        string
+		minVersion  string
+		expectError bool
+		description string
+	}{
+		{
+			name:        "NoMinimum",
+			minVersion:  "",
+			expectError: false,
+			description: "Without MinVersion only availability is checked",
+		},
+		{
+			name:        "Satisfied",
+			minVersion:  "0.1.0",
+			expectError: false,
+			description: "Newer installed version should pass",
+		},
+		{
+			name:        "TooOld",
+			minVersion:  "0.2.0",
+			expectError: true,
+			description: "Older installed version should fail with ErrVersionTooOld",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewClientWithConfig(Config{MinVersion: tt.minVersion})
+			err := client.ValidateAvailable()
+
+			if tt.expectError {
+				if !errors.Is(err, ErrVersionTooOld) {
+					t.Errorf("%s: expected ErrVersionTooOld, got %v", tt.description, err)
+				}
+			} else if err != nil {
+				t.Errorf("%s: unexpected error: %v", tt.description, err)
+			}
+		})
+	}
+}
+
+// TestValidateAvailableContext tests telling a missing gemini apart from one
+// that fails to run
+func TestValidateAvailableContext(t *testing.T) {
+	tests := []struct {
+		name        string
+		script      string
+		binaryPath  string
+		minVersion  string
+		expectedErr error
+		description string
+	}{
+		{
+			name:        "Runs",
+			script:      `echo "0.1.12"`,
+			description: "A binary that prints its version and exits zero should pass",
+		},
+		{
+			name:        "NotOnPath",
+			script:      `echo "0.1.12"`,
+			binaryPath:  "gemini-does-not-exist",
+			expectedErr: ErrGeminiNotFound,
+			description: "A binary missing from PATH should fail with ErrGeminiNotFound",
+		},
+		{
+			name:        "NonZeroExit",
+			script:      `echo "cannot execute binary file" >&2; exit 126`,
+			expectedErr: ErrGeminiNotRunnable,
+			description: "A binary that exits non-zero should fail with ErrGeminiNotRunnable",
+		},
+		{
+			name:        "Hangs",
+			script:      `exec sleep 10`,
+			expectedErr: ErrGeminiNotRunnable,
+			description: "A binary that does not finish before the deadline should fail with ErrGeminiNotRunnable",
+		},
+		{
+			name:        "TooOld",
+			script:      `echo "0.1.12"`,
+			minVersion:  "0.2.0",
+			expectedErr: ErrVersionTooOld,
+			description: "MinVersion should still be enforced",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			installFakeGemini(t, tt.script)
+			client := NewClientWithConfig(Config{BinaryPath: tt.binaryPath, MinVersion: tt.minVersion, GracePeriod: -1})
+
+			ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+			defer cancel()
+			err := client.ValidateAvailableContext(ctx)
+
+			if tt.expectedErr == nil {
+				if err != nil {
+					t.Errorf("%s: unexpected error: %v", tt.description, err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.expectedErr) {
+				t.Errorf("%s: expected %v, got %v", tt.description, tt.expectedErr, err)
+			}
+		})
+	}
+}